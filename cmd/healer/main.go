@@ -5,15 +5,26 @@ import (
     "fmt"
     "io"
     "log"
+    "os"
     "path/filepath"
     "time"
 
+    "k8s-healer/internal/agent"
+    "k8s-healer/internal/budget"
+    "k8s-healer/internal/cache"
+    "k8s-healer/internal/config"
     "k8s-healer/internal/collector"
+    "k8s-healer/internal/policy"
     "k8s-healer/internal/predictor"
+    "k8s-healer/internal/remediation"
     "k8s-healer/internal/actions"
     "k8s-healer/internal/diagnostics"
+    "k8s-healer/internal/eviction"
+    "k8s-healer/internal/owners"
     "k8s-healer/internal/api"
+    "k8s-healer/internal/watcher"
 
+    "k8s.io/client-go/dynamic"
     "k8s.io/client-go/kubernetes"
     "k8s.io/client-go/rest"
     "k8s.io/client-go/tools/clientcmd"
@@ -22,10 +33,30 @@ import (
 )
 
 func main() {
-    log.SetOutput(io.Discard)
+    // `healer agent` runs the node-level diagnostic agent instead
+    if len(os.Args) > 1 && os.Args[1] == "agent" {
+        if err := agent.Run(os.Args[2:]); err != nil {
+            fmt.Printf("Agent failed: %v\n", err)
+            os.Exit(1)
+        }
+        return
+    }
+    
+    cfg, err := config.Load(os.Args[1:])
+    if err != nil {
+        fmt.Printf("Invalid configuration: %v\n", err)
+        os.Exit(1)
+    }
+    
+    if cfg.LogLevel != "debug" {
+        log.SetOutput(io.Discard)
+    }
     fmt.Println("🤖 K8s AI Healer v4.0 - COMPLETE SYSTEM WITH API")
+    if cfg.DryRun {
+        fmt.Println("🧪 DRY RUN mode - no changes will be made to the cluster")
+    }
     
-    clientset, metricsClient, config, err := createClients()
+    clientset, metricsClient, restConfig, err := createClients()
     if err != nil {
         fmt.Printf("Failed to connect: %v\n", err)
         return
@@ -33,29 +64,94 @@ func main() {
     
     fmt.Println("✅ Connected to cluster")
     
-    col := collector.New(clientset, metricsClient)
-    pred := predictor.New()
-    actionEngine := actions.New(clientset, false)
-    diagEngine := diagnostics.New(clientset, config)
-    autoHealer := diagnostics.NewAutoHealer(diagEngine, false)
+    // Shared informer cache: one watch per resource instead of a List per cycle
+    kubeCache := cache.New(clientset, 10*time.Minute)
+    
+    col := collector.New(clientset, metricsClient, kubeCache, cfg)
+    pred := predictor.New(cfg)
+    scaler, err := actions.NewScaler(clientset, restConfig)
+    if err != nil {
+        fmt.Printf("Failed to create scaler: %v\n", err)
+        return
+    }
+    evictor := eviction.New(clientset)
+    registry := remediation.NewRegistry()
+    budgets := budget.New(cfg.Budgets, owners.NewResolver(clientset, kubeCache))
+    registry.SetLimiter(budgets)
+    dynamicClient, err := dynamic.NewForConfig(restConfig)
+    if err != nil {
+        fmt.Printf("Failed to create dynamic client: %v\n", err)
+        return
+    }
+    policies := policy.New(dynamicClient, clientset.Discovery(), registry, 10*time.Minute, cfg.Budgets.Window)
+    actionEngine := actions.New(clientset, kubeCache, scaler, evictor, registry, policies, cfg)
+    diagEngine := diagnostics.New(clientset, restConfig, kubeCache, cfg)
+    agents := agent.NewReceiver()
+    diagEngine.SetAgents(agents)
+    autoHealer := diagnostics.NewAutoHealer(diagEngine, evictor, registry, policies, cfg)
+    if err := autoHealer.ValidateEscalation(); err != nil {
+        fmt.Printf("Invalid configuration: %v\n", err)
+        os.Exit(1)
+    }
+    // Checks declared in the config file run after the built-in ones
+    if err := diagEngine.RegisterCustomChecks(cfg.Checks, registry); err != nil {
+        fmt.Printf("Invalid configuration: %v\n", err)
+        os.Exit(1)
+    }
+    if len(cfg.Checks) > 0 {
+        fmt.Printf("🧩 %d custom container checks registered\n", len(cfg.Checks))
+    }
+
+    var podWatcher *watcher.Watcher
+    if cfg.EventDriven {
+        podWatcher = watcher.New(kubeCache, diagEngine, autoHealer, cfg.EventWorkers)
+        if err := podWatcher.Register(); err != nil {
+            fmt.Printf("Failed to register pod watcher: %v\n", err)
+            return
+        }
+    }
+    
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    if err := kubeCache.Start(ctx.Done()); err != nil {
+        fmt.Printf("Failed to start informer cache: %v\n", err)
+        return
+    }
+    fmt.Println("✅ Informer cache synced")
+    
+    // Pick up scale-ups made before a restart so they are still undone
+    if err := actionEngine.RestoreScaleUps(ctx); err != nil {
+        fmt.Printf("⚠️  Failed to restore scale-ups: %v\n", err)
+    }
+    
+    // Policies are validated against the registry, so start watching them
+    // only after every remediation is registered
+    if err := policies.Start(ctx.Done()); err != nil {
+        fmt.Printf("Failed to watch healing policies: %v\n", err)
+        return
+    }
+    
+    if podWatcher != nil {
+        podWatcher.Run(ctx)
+        fmt.Printf("⚡ Event-driven healing enabled (%d workers)\n", cfg.EventWorkers)
+    }
     
     // NEW: Start HTTP API Server
-    apiServer := api.NewAPIServer(autoHealer, diagEngine, "8080")
+    apiServer := api.NewAPIServer(autoHealer, diagEngine, actionEngine, policies, budgets, agents, kubeCache, cfg)
     apiServer.Start()
     
     fmt.Println("🚀 AI Monitoring started - COMPLETE SYSTEM ACTIVE")
     fmt.Println("🛠️  Auto-fixing: DNS, disk, network, stuck containers")
-    fmt.Println("🌐 Web Dashboard: http://localhost:8080")
-    fmt.Println("📊 Status API: http://localhost:8080/status")
+    fmt.Printf("🌐 Web Dashboard: http://localhost:%s\n", cfg.Port)
+    fmt.Printf("📊 Status API: http://localhost:%s/status\n", cfg.Port)
+    fmt.Printf("⏱️  Check interval: %v\n", cfg.CheckInterval)
     
     for i := 1; ; i++ {
-        ctx := context.TODO()
-        
         // Standard metrics collection
         metrics, err := col.GetAllPodMetrics(ctx)
         if err != nil {
             fmt.Printf("Error getting metrics: %v\n", err)
-            time.Sleep(30 * time.Second)
+            time.Sleep(cfg.CheckInterval)
             continue
         }
         
@@ -65,40 +161,56 @@ func main() {
             fmt.Printf("Stuck container diagnostics error: %v\n", err)
         }
         
-        containerChecks, err := diagEngine.RunContainerChecks(ctx, "")
+        // Containers are healed as soon as their checks fail, by a worker of
+        // their own so a slow fix never holds up the reporting of the sweep
+        failed := make(chan diagnostics.ContainerCheckResult, 64)
+        healed := make(chan []diagnostics.HealingAction)
+        go func() {
+            var actions []diagnostics.HealingAction
+            for result := range failed {
+                actions = append(actions, autoHealer.HealContainerIssues(ctx, []diagnostics.ContainerCheckResult{result})...)
+            }
+            healed <- actions
+        }()
+        containerChecks, err := diagEngine.RunContainerChecks(ctx, "", func(result diagnostics.ContainerCheckResult) {
+            failed <- result
+        })
+        close(failed)
         if err != nil {
             fmt.Printf("Container checks error: %v\n", err)
         }
+        healingActions := <-healed
         
         restartPatterns, err := diagEngine.AnalyzeRestartPatterns(ctx, "")
         if err != nil {
             fmt.Printf("Restart analysis error: %v\n", err)
         }
         
-        // Execute auto-healing actions
-        var healingActions []diagnostics.HealingAction
-        if len(containerChecks) > 0 {
-            healingActions = autoHealer.HealContainerIssues(ctx, containerChecks)
-        }
-        
         hasIssues := false
         for _, m := range metrics {
-            if m.Restarts > 3 || m.Status != "Running" || m.CPUPercent > 15 || m.MemPercent > 15 {
+            profile := cfg.Thresholds.ProfileFor(m.Namespace, m.Labels)
+            if m.Restarts > profile.RestartWarning || m.Status != "Running" ||
+               m.CPUPercent > profile.CPUCritical || m.MemPercent > profile.MemCritical {
                 hasIssues = true
                 break
             }
         }
         
         // Show issues if any diagnostics detected problems
-        if len(stuckContainers) > 0 || len(containerChecks) > 0 || len(restartPatterns) > 0 || len(healingActions) > 0 {
+        nodeProblems := agents.HasProblems()
+        if len(stuckContainers) > 0 || len(containerChecks) > 0 || len(restartPatterns) > 0 || len(healingActions) > 0 || nodeProblems {
             hasIssues = true
         }
         
         if i%20 == 1 || hasIssues {
             fmt.Printf("🔍 Health check [%s]:\n", time.Now().Format("15:04:05"))
-            col.PrintStatus()
+            col.PrintStatus(metrics)
             
             // Print all diagnostic results
+            if nodeProblems {
+                agents.PrintProblems()
+            }
+            
             if len(stuckContainers) > 0 {
                 diagEngine.PrintDiagnostics(stuckContainers)
             }
@@ -116,8 +228,8 @@ func main() {
                 autoHealer.PrintHealingActions(healingActions)
             }
         } else {
-            fmt.Printf("[%s] 🟢 OK (%d until next check) - API: http://localhost:8080\n", 
-                time.Now().Format("15:04:05"), 20-(i%20))
+            fmt.Printf("[%s] 🟢 OK (%d until next check) - API: http://localhost:%s\n", 
+                time.Now().Format("15:04:05"), 20-(i%20), cfg.Port)
         }
         
         // Standard predictions and actions
@@ -126,10 +238,29 @@ func main() {
         
         if len(predictions) > 0 {
             pred.PrintPredictions(predictions)
-            actionEngine.ExecuteActions(predictions)
+            actionEngine.ExecuteActions(ctx, predictions)
         }
         
-        time.Sleep(30 * time.Second)
+        // Check that earlier scale-ups, restarts and rollouts took effect
+        actionEngine.VerifyActions(ctx)
+        
+        // Remediations that HealingPolicies attach to restart patterns
+        actionEngine.ExecuteRestartPolicies(ctx, restartPatterns)
+        
+        // Roll back Deployments whose newest revision is crash looping
+        actionEngine.RollbackCrashLoops(ctx, restartPatterns)
+        
+        // Raise or recommend memory limits for OOMKilled containers
+        actionEngine.RightsizeOOMKilled(ctx, restartPatterns, pred)
+        
+        // Retry pod restarts that a PodDisruptionBudget refused earlier
+        evictor.RetryDeferred(ctx)
+        
+        // Undo temporary scale-ups once they expire
+        actionEngine.RestoreExpiredHPAOverrides(ctx)
+        actionEngine.ReconcileScaleDowns(ctx, pred)
+        
+        time.Sleep(cfg.CheckInterval)
     }
 }
 
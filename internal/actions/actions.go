@@ -2,134 +2,359 @@ package actions
 
 import (
     "context"
+    "errors"
     "fmt"
-    "log"
+    "strings"
+    "sync"
     "time"
 
+    "k8s-healer/internal/cache"
+    "k8s-healer/internal/config"
+    "k8s-healer/internal/eviction"
+    "k8s-healer/internal/owners"
+    "k8s-healer/internal/policy"
     "k8s-healer/internal/predictor"
+    "k8s-healer/internal/remediation"
 
+    "k8s.io/apimachinery/pkg/api/resource"
     "k8s.io/client-go/kubernetes"
-    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type ActionEngine struct {
-    clientset    *kubernetes.Clientset
-    dryRun       bool
-    actionCounts map[string]int
+    clientset          kubernetes.Interface
+    cache              *cache.Cache
+    owners             *owners.Resolver
+    scaler             *Scaler
+    evictor            *eviction.Evictor
+    registry           *remediation.Registry
+    policies           *policy.Store
+    dryRun             bool
+    hpaOverride        time.Duration
+    scaleUpHold        time.Duration
+    scaleDownWindow    time.Duration
+    rollbackNamespaces []string
+    rightsizing        string
+    memoryHeadroom     int
+    maxMemoryLimit     resource.Quantity
+    scaleUps           map[string]*ScaleUpRecord
+    rollouts           map[string]time.Time
+    recommendations    map[string]MemoryRecommendation
+    history            []ActionRecord
+    unverified         []pendingVerification
+    mu                 sync.Mutex
 }
 
-func New(clientset *kubernetes.Clientset, dryRun bool) *ActionEngine {
-    return &ActionEngine{
-        clientset:    clientset,
-        dryRun:       dryRun,
-        actionCounts: make(map[string]int),
+// Completed actions are verified until this long after they were requested.
+const verifyDeadline = 10 * time.Minute
+
+// pendingVerification is a completed action whose effect is not verified yet.
+type pendingVerification struct {
+    req      remediation.Request
+    deadline time.Time
+}
+
+type ActionRecord struct {
+    Action    string
+    PodName   string
+    Namespace string
+    Target    string
+    Risk      string
+    Details   string
+    Timestamp time.Time
+}
+
+func New(clientset *kubernetes.Clientset, kubeCache *cache.Cache, scaler *Scaler, evictor *eviction.Evictor, registry *remediation.Registry, policies *policy.Store, cfg *config.Config) *ActionEngine {
+    a := &ActionEngine{
+        clientset:          clientset,
+        cache:              kubeCache,
+        owners:             owners.NewResolver(clientset, kubeCache),
+        scaler:             scaler,
+        evictor:            evictor,
+        registry:           registry,
+        policies:           policies,
+        dryRun:             cfg.DryRun,
+        hpaOverride:        cfg.HPAOverrideDuration,
+        scaleUpHold:        cfg.ScaleUpHold,
+        scaleDownWindow:    cfg.ScaleDownWindow,
+        rollbackNamespaces: cfg.RollbackNamespaces,
+        rightsizing:        cfg.MemoryRightsizing,
+        memoryHeadroom:     cfg.MemoryHeadroom,
+        maxMemoryLimit:     cfg.MaxMemoryLimit,
+        scaleUps:           make(map[string]*ScaleUpRecord),
+        rollouts:           make(map[string]time.Time),
+        recommendations:    make(map[string]MemoryRecommendation),
+        history:            make([]ActionRecord, 0),
     }
+    a.registerRemediations()
+    return a
 }
 
-func (a *ActionEngine) ExecuteActions(predictions []predictor.PredictionResult) {
+func (a *ActionEngine) ExecuteActions(ctx context.Context, predictions []predictor.PredictionResult) {
     if len(predictions) == 0 {
         return
     }
 
     fmt.Printf("🤖 === EXECUTING HEALING ACTIONS ===\n")
     
+    // When most replicas share the same leak, restart the whole workload
+    // instead of its pods one by one
+    covered := make(map[string]bool)
+    for _, plan := range a.planRolloutRestarts(ctx, predictions) {
+        first := plan.preds[0]
+        decision, ok := a.policies.Resolve(policy.Detection{
+            Kind:      policy.KindPrediction,
+            Name:      policy.PredictionRolloutRestart,
+            Status:    first.Risk,
+            Suggested: policy.PredictionRolloutRestart,
+            Namespace: first.PodNamespace,
+            PodName:   first.PodName,
+            Labels:    a.podLabels(first.PodNamespace, first.PodName),
+        })
+        if !ok {
+            continue
+        }
+        key := plan.workload.String()
+        if allowed, reason := a.policies.Allow(decision, key); !allowed {
+            fmt.Printf("⚠️  Skipping %s - %s\n", key, reason)
+            continue
+        }
+
+        result, err := a.dispatch(ctx, remediation.Request{
+            Action:    decision.Remediation,
+            Namespace: first.PodNamespace,
+            PodName:   first.PodName,
+            Risk:      first.Risk,
+            Reason:    fmt.Sprintf("%d pods of %s leaking memory", len(plan.preds), plan.workload),
+            DryRun:    a.dryRun || decision.DryRun,
+            Requested: time.Now(),
+            Origin:    plan,
+        })
+        if errors.Is(err, remediation.ErrUnknownAction) {
+            a.recordAction("UNKNOWN_ACTION", first, "", err.Error())
+            continue
+        }
+        if result.Status != remediation.StatusSkipped && result.Status != remediation.StatusRateLimited {
+            a.policies.Record(decision, key)
+        }
+
+        // Pods are left to their own predictions unless the rollout ran; a
+        // rule may have mapped it to a remediation of the first pod only
+        if decision.Remediation != policy.PredictionRolloutRestart {
+            continue
+        }
+        if result.Status != remediation.StatusCompleted && result.Status != remediation.StatusDryRun {
+            continue
+        }
+        for _, pred := range plan.preds {
+            covered[fmt.Sprintf("%s/%s", pred.PodNamespace, pred.PodName)] = true
+        }
+    }
+    
     for _, pred := range predictions {
         key := fmt.Sprintf("%s/%s", pred.PodNamespace, pred.PodName)
         
-        if a.actionCounts[key] >= 3 {
-            fmt.Printf("⚠️  Skipping %s - max actions reached (3)\n", key)
+        if covered[key] {
             continue
         }
         
-        switch pred.Action {
-        case "SCALE_UP_URGENT":
-            a.scaleUpDeployment(pred)
-        case "SCALE_UP":
-            a.scaleUpDeployment(pred)
-        case "RESTART_POD_URGENT":
-            a.restartPod(pred)
-        case "RESTART_POD":
-            a.restartPod(pred)
-        case "INVESTIGATE_RESTARTS":
-            a.investigatePod(pred)
-        case "MONITOR_CLOSELY":
-            a.monitorPod(pred)
-        default:
-            fmt.Printf("📊 Monitoring: %s/%s\n", pred.PodNamespace, pred.PodName)
+        decision, ok := a.policies.Resolve(policy.Detection{
+            Kind:      policy.KindPrediction,
+            Name:      pred.Action,
+            Status:    pred.Risk,
+            Suggested: pred.Action,
+            Namespace: pred.PodNamespace,
+            PodName:   pred.PodName,
+            Labels:    a.podLabels(pred.PodNamespace, pred.PodName),
+        })
+        if !ok {
+            continue
+        }
+        if allowed, reason := a.policies.Allow(decision, key); !allowed {
+            fmt.Printf("⚠️  Skipping %s - %s\n", key, reason)
+            continue
         }
         
-        a.actionCounts[key]++
+        req := predictionRequest(pred, a.dryRun || decision.DryRun)
+        req.Action = decision.Remediation
+        result, err := a.dispatch(ctx, req)
+        if errors.Is(err, remediation.ErrUnknownAction) {
+            a.recordAction("UNKNOWN_ACTION", pred, "", err.Error())
+            continue
+        }
+        if result.Status != remediation.StatusSkipped && result.Status != remediation.StatusRateLimited {
+            a.policies.Record(decision, key)
+        }
     }
     
     fmt.Printf("=====================================\n\n")
 }
 
-func (a *ActionEngine) scaleUpDeployment(pred predictor.PredictionResult) {
-    if a.dryRun {
-        fmt.Printf("🚀 [DRY RUN] Would scale UP deployment for pod: %s/%s (CPU overload)\n", 
-            pred.PodNamespace, pred.PodName)
-        return
+func (a *ActionEngine) podLabels(namespace, name string) map[string]string {
+    pod, err := a.cache.Pods.Pods(namespace).Get(name)
+    if err != nil {
+        return nil
+    }
+    return pod.Labels
+}
+
+func predictionRequest(pred predictor.PredictionResult, dryRun bool) remediation.Request {
+    return remediation.Request{
+        Action:        pred.Action,
+        Namespace:     pred.PodNamespace,
+        PodName:       pred.PodName,
+        ContainerName: pred.ContainerName,
+        Risk:          pred.Risk,
+        Reason:        strings.Join(pred.Issues, "; "),
+        DryRun:        dryRun,
+        Requested:     time.Now(),
+        Origin:        pred,
+    }
+}
+
+// predictionOf returns the prediction behind a request, or a minimal one
+// built from the request for callers that did not start from a prediction.
+func predictionOf(req remediation.Request) predictor.PredictionResult {
+    if pred, ok := req.Origin.(predictor.PredictionResult); ok {
+        return pred
+    }
+    return predictor.PredictionResult{
+        PodName:       req.PodName,
+        PodNamespace:  req.Namespace,
+        Risk:          req.Risk,
+        Action:        req.Action,
+        ContainerName: req.ContainerName,
     }
+}
+
+func (a *ActionEngine) dispatch(ctx context.Context, req remediation.Request) (remediation.Result, error) {
+    plan, result, err := a.registry.Dispatch(ctx, req)
     
-    ctx := context.TODO()
-    deployments, err := a.clientset.AppsV1().Deployments(pred.PodNamespace).List(ctx, metav1.ListOptions{})
-    if err != nil {
-        fmt.Printf("❌ Failed to list deployments: %v\n", err)
-        return
+    switch result.Status {
+    case remediation.StatusDryRun:
+        fmt.Printf("🔄 [DRY RUN] Would %s\n", plan.Description)
+    case remediation.StatusSkipped:
+        fmt.Printf("⏭️  %s\n", result.Message)
+    case remediation.StatusRateLimited:
+        fmt.Printf("⏳ %s for %s held back: %s\n", req.Action, req.Key(), result.Message)
+    case remediation.StatusFailed:
+        fmt.Printf("❌ %s for %s: %s\n", result.Action, req.Key(), result.Message)
+    case remediation.StatusCompleted:
+        if !plan.ReadOnly {
+            a.mu.Lock()
+            a.unverified = append(a.unverified, pendingVerification{req: req, deadline: req.Requested.Add(verifyDeadline)})
+            a.mu.Unlock()
+        }
     }
     
-    for _, dep := range deployments.Items {
-        if len(pred.PodName) > len(dep.Name) && pred.PodName[:len(dep.Name)] == dep.Name {
-            currentReplicas := *dep.Spec.Replicas
-            newReplicas := currentReplicas + 1
-            dep.Spec.Replicas = &newReplicas
-            
-            _, err := a.clientset.AppsV1().Deployments(pred.PodNamespace).Update(ctx, &dep, metav1.UpdateOptions{})
+    return result, err
+}
+
+// VerifyActions asks the remediation of every completed action that changed
+// the cluster whether it took effect, until it does or its deadline passes.
+func (a *ActionEngine) VerifyActions(ctx context.Context) {
+    a.mu.Lock()
+    pending := a.unverified
+    a.unverified = nil
+    a.mu.Unlock()
+    
+    var still []pendingVerification
+    for _, p := range pending {
+        verified, err := a.registry.Verify(ctx, p.req)
+        switch {
+        case verified:
+            fmt.Printf("✅ VERIFIED %s for %s\n", p.req.Action, p.req.Key())
+        case time.Now().After(p.deadline):
+            reason := "no effect"
             if err != nil {
-                fmt.Printf("❌ Failed to scale deployment: %v\n", err)
-                return
+                reason = err.Error()
             }
-            
-            fmt.Printf("🚀 AUTO-SCALED deployment %s from %d to %d replicas (CPU overload detected)\n", 
-                dep.Name, currentReplicas, newReplicas)
-            a.logAction("AUTO_SCALE_UP", pred)
-            break
+            fmt.Printf("⚠️  UNVERIFIED %s for %s after %v: %s\n", p.req.Action, p.req.Key(), verifyDeadline, reason)
+        default:
+            still = append(still, p)
         }
     }
+    
+    a.mu.Lock()
+    a.unverified = append(a.unverified, still...)
+    a.mu.Unlock()
 }
 
-func (a *ActionEngine) restartPod(pred predictor.PredictionResult) {
-    ctx := context.TODO()
+func (a *ActionEngine) scaleUpWorkload(ctx context.Context, pred predictor.PredictionResult) (remediation.Result, error) {
+    workload, err := a.owners.Resolve(ctx, pred.PodNamespace, pred.PodName)
+    if err != nil {
+        return remediation.Result{}, fmt.Errorf("cannot scale %s/%s: %v", pred.PodNamespace, pred.PodName, err)
+    }
     
-    if a.dryRun {
-        fmt.Printf("🔄 [DRY RUN] Would restart pod: %s/%s (Memory/Status issue)\n", 
-            pred.PodNamespace, pred.PodName)
-        return
+    // Bumping replicas under an HPA is reverted immediately - raise its floor instead
+    hpa, err := a.findHPA(workload)
+    if err != nil {
+        return remediation.Result{}, fmt.Errorf("failed to look up HPA for %s: %v", workload, err)
+    }
+    if hpa != nil {
+        return a.raiseHPAMinimum(ctx, hpa, workload, pred)
     }
     
-    err := a.clientset.CoreV1().Pods(pred.PodNamespace).Delete(ctx, pred.PodName, metav1.DeleteOptions{})
+    currentReplicas, newReplicas, err := a.scaler.Scale(ctx, workload, func(current int32) int32 {
+        return current + 1
+    })
     if err != nil {
-        log.Printf("❌ Failed to restart pod %s/%s: %v", pred.PodNamespace, pred.PodName, err)
-        return
+        return remediation.Result{Target: workload.String()}, err
     }
     
-    fmt.Printf("🔄 AUTO-RESTARTED pod: %s/%s (Memory/Status issue detected)\n", 
-        pred.PodNamespace, pred.PodName)
-    a.logAction("AUTO_RESTART", pred)
+    fmt.Printf("🚀 AUTO-SCALED %s from %d to %d replicas (CPU overload detected)\n", 
+        workload, currentReplicas, newReplicas)
+    a.recordScaleUp(ctx, workload, currentReplicas, newReplicas)
+    details := fmt.Sprintf("replicas %d → %d", currentReplicas, newReplicas)
+    a.recordAction("AUTO_SCALE_UP", pred, workload.String(), details)
+    
+    return remediation.Result{
+        Action:  "AUTO_SCALE_UP",
+        Status:  remediation.StatusCompleted,
+        Message: details,
+        Target:  workload.String(),
+    }, nil
+}
+
+// restartPod evicts the pod. done, if set, is called with the outcome of an
+// eviction a PodDisruptionBudget deferred.
+func (a *ActionEngine) restartPod(ctx context.Context, pred predictor.PredictionResult, done func(err error)) (remediation.Result, error) {
+    if pred.ContainerName != "" {
+        fmt.Printf("📦 Issue traced to container %s in %s/%s\n", 
+            pred.ContainerName, pred.PodNamespace, pred.PodName)
+    }
+    
+    err := a.evictor.Evict(ctx, pred.PodNamespace, pred.PodName, func(err error) {
+        if err != nil {
+            a.recordAction("AUTO_RESTART_FAILED", pred, "", fmt.Sprintf("deferred eviction failed: %v", err))
+        } else {
+            a.recordAction("AUTO_RESTART", pred, "", "evicted after PodDisruptionBudget allowed it")
+        }
+        if done != nil {
+            done(err)
+        }
+    })
+    if errors.Is(err, eviction.ErrBlockedByPDB) {
+        fmt.Printf("⏳ Restart of %s/%s blocked by PodDisruptionBudget - will retry\n", 
+            pred.PodNamespace, pred.PodName)
+        a.recordAction("BLOCKED_BY_PDB", pred, "", err.Error())
+    } else if err == nil {
+        fmt.Printf("🔄 AUTO-RESTARTED pod: %s/%s (Memory/Status issue detected)\n", 
+            pred.PodNamespace, pred.PodName)
+        a.logAction("AUTO_RESTART", pred)
+    }
+    
+    return evictionResult(err, "AUTO_RESTART", fmt.Sprintf("%s/%s", pred.PodNamespace, pred.PodName))
 }
 
 func (a *ActionEngine) investigatePod(pred predictor.PredictionResult) {
     fmt.Printf("🔍 INVESTIGATING pod: %s/%s (Restart pattern detected)\n", 
         pred.PodNamespace, pred.PodName)
     
-    ctx := context.TODO()
-    events, err := a.clientset.CoreV1().Events(pred.PodNamespace).List(ctx, metav1.ListOptions{
-        FieldSelector: fmt.Sprintf("involvedObject.name=%s", pred.PodName),
-    })
+    events, err := a.cache.EventsFor(pred.PodNamespace, pred.PodName)
     
-    if err == nil && len(events.Items) > 0 {
+    if err == nil && len(events) > 0 {
         fmt.Printf("  📋 Recent events:\n")
-        for i, event := range events.Items {
+        for i, event := range events {
             if i >= 3 {
                 break
             }
@@ -147,11 +372,42 @@ func (a *ActionEngine) monitorPod(pred predictor.PredictionResult) {
 }
 
 func (a *ActionEngine) logAction(action string, pred predictor.PredictionResult) {
-    timestamp := time.Now().Format("15:04:05")
+    a.recordAction(action, pred, "", "")
+}
+
+func (a *ActionEngine) recordAction(action string, pred predictor.PredictionResult, target, details string) {
+    now := time.Now()
     fmt.Printf("  📝 [%s] AI Action: %s for %s/%s (Risk: %s)\n", 
-        timestamp, action, pred.PodNamespace, pred.PodName, pred.Risk)
+        now.Format("15:04:05"), action, pred.PodNamespace, pred.PodName, pred.Risk)
+    
+    a.appendHistory(ActionRecord{
+        Action:    action,
+        PodName:   pred.PodName,
+        Namespace: pred.PodNamespace,
+        Target:    target,
+        Risk:      pred.Risk,
+        Details:   details,
+        Timestamp: now,
+    })
+}
+
+func (a *ActionEngine) appendHistory(record ActionRecord) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    
+    a.history = append(a.history, record)
+    
+    // Keep only last 100 actions
+    if len(a.history) > 100 {
+        a.history = a.history[len(a.history)-100:]
+    }
 }
 
-func (a *ActionEngine) GetActionCounts() map[string]int {
-    return a.actionCounts
+func (a *ActionEngine) GetActionHistory() []ActionRecord {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    
+    history := make([]ActionRecord, len(a.history))
+    copy(history, a.history)
+    return history
 }
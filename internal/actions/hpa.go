@@ -0,0 +1,200 @@
+package actions
+
+import (
+    "context"
+    "fmt"
+    "strconv"
+    "time"
+
+    "k8s-healer/internal/owners"
+    "k8s-healer/internal/predictor"
+    "k8s-healer/internal/remediation"
+
+    autoscalingv2 "k8s.io/api/autoscaling/v2"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/labels"
+    "k8s.io/apimachinery/pkg/runtime/schema"
+    "k8s.io/client-go/util/retry"
+)
+
+// The original minReplicas and the expiry are stored on the HPA itself so an
+// override is restored even if the healer restarts in between.
+const (
+    annotationOriginalMinReplicas = "healer.io/original-min-replicas"
+    annotationMinReplicasExpires  = "healer.io/min-replicas-expires"
+)
+
+func (a *ActionEngine) findHPA(workload *owners.Workload) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+    hpas, err := a.cache.HPAs.HorizontalPodAutoscalers(workload.Namespace).List(labels.Everything())
+    if err != nil {
+        return nil, err
+    }
+
+    workloadGV, _ := schema.ParseGroupVersion(workload.APIVersion)
+    for _, hpa := range hpas {
+        ref := hpa.Spec.ScaleTargetRef
+        refGV, _ := schema.ParseGroupVersion(ref.APIVersion)
+        if ref.Kind == workload.Kind && ref.Name == workload.Name && refGV.Group == workloadGV.Group {
+            return hpa, nil
+        }
+    }
+
+    return nil, nil
+}
+
+func (a *ActionEngine) raiseHPAMinimum(ctx context.Context, cached *autoscalingv2.HorizontalPodAutoscaler, workload *owners.Workload, pred predictor.PredictionResult) (remediation.Result, error) {
+    target := fmt.Sprintf("HorizontalPodAutoscaler %s/%s", cached.Namespace, cached.Name)
+    var originalMin, oldMin, newMin, maxReplicas int32
+    var extended bool
+    expires := time.Now().Add(a.hpaOverride)
+    hpaClient := a.clientset.AutoscalingV2().HorizontalPodAutoscalers(cached.Namespace)
+
+    err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+        hpa, err := hpaClient.Get(ctx, cached.Name, metav1.GetOptions{})
+        if err != nil {
+            return err
+        }
+
+        oldMin = 1
+        if hpa.Spec.MinReplicas != nil {
+            oldMin = *hpa.Spec.MinReplicas
+        }
+        maxReplicas = hpa.Spec.MaxReplicas
+
+        // Keep the value from before the first override when extending one
+        originalMin = oldMin
+        value, overridden := hpa.Annotations[annotationOriginalMinReplicas]
+        if overridden {
+            if parsed, err := strconv.ParseInt(value, 10, 32); err == nil {
+                originalMin = int32(parsed)
+            }
+        }
+
+        if hpa.Annotations == nil {
+            hpa.Annotations = make(map[string]string)
+        }
+
+        // Nothing to raise at maxReplicas; an override of ours only lasts longer
+        if oldMin >= maxReplicas {
+            newMin = oldMin
+            if !overridden {
+                return nil
+            }
+            hpa.Annotations[annotationMinReplicasExpires] = expires.UTC().Format(time.RFC3339)
+            extended = true
+            _, err = hpaClient.Update(ctx, hpa, metav1.UpdateOptions{})
+            return err
+        }
+
+        newMin = oldMin + 1
+        hpa.Annotations[annotationOriginalMinReplicas] = strconv.Itoa(int(originalMin))
+        hpa.Annotations[annotationMinReplicasExpires] = expires.UTC().Format(time.RFC3339)
+        hpa.Spec.MinReplicas = &newMin
+
+        _, err = hpaClient.Update(ctx, hpa, metav1.UpdateOptions{})
+        return err
+    })
+    if err != nil {
+        return remediation.Result{Action: "HPA_MIN_RAISED", Target: target}, 
+            fmt.Errorf("failed to raise minReplicas of HPA %s/%s: %v", cached.Namespace, cached.Name, err)
+    }
+
+    if newMin == oldMin {
+        message := fmt.Sprintf("HPA %s/%s already at maxReplicas (%d)", cached.Namespace, cached.Name, maxReplicas)
+        if extended {
+            message += fmt.Sprintf(", override extended until %s", expires.Format("15:04:05"))
+        }
+        return remediation.Result{
+            Action:  "HPA_MIN_RAISED",
+            Status:  remediation.StatusSkipped,
+            Message: message,
+            Target:  target,
+        }, nil
+    }
+
+    fmt.Printf("📈 Raised minReplicas of HPA %s/%s from %d to %d until %s (CPU overload on %s)\n", 
+        cached.Namespace, cached.Name, oldMin, newMin, expires.Format("15:04:05"), workload)
+    details := fmt.Sprintf("minReplicas %d → %d (original %d) until %s", oldMin, newMin, originalMin, expires.Format(time.RFC3339))
+    a.recordAction("HPA_MIN_RAISED", pred, target, details)
+    
+    return remediation.Result{
+        Action:  "HPA_MIN_RAISED",
+        Status:  remediation.StatusCompleted,
+        Message: details,
+        Target:  target,
+    }, nil
+}
+
+// RestoreExpiredHPAOverrides puts minReplicas back to its original value on
+// every HPA whose temporary override has expired.
+func (a *ActionEngine) RestoreExpiredHPAOverrides(ctx context.Context) {
+    hpas, err := a.cache.HPAs.List(labels.Everything())
+    if err != nil {
+        fmt.Printf("❌ Failed to list HPAs: %v\n", err)
+        return
+    }
+
+    now := time.Now()
+    for _, hpa := range hpas {
+        value, ok := hpa.Annotations[annotationMinReplicasExpires]
+        if !ok {
+            continue
+        }
+        expires, err := time.Parse(time.RFC3339, value)
+        if err == nil && now.Before(expires) {
+            continue
+        }
+
+        if a.dryRun {
+            fmt.Printf("📉 [DRY RUN] Would restore minReplicas of HPA %s/%s\n", hpa.Namespace, hpa.Name)
+            continue
+        }
+
+        a.restoreHPAMinimum(ctx, hpa.Namespace, hpa.Name)
+    }
+}
+
+func (a *ActionEngine) restoreHPAMinimum(ctx context.Context, namespace, name string) {
+    var restoredMin, oldMin int32
+    hpaClient := a.clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace)
+
+    err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+        hpa, err := hpaClient.Get(ctx, name, metav1.GetOptions{})
+        if err != nil {
+            return err
+        }
+
+        oldMin = 1
+        if hpa.Spec.MinReplicas != nil {
+            oldMin = *hpa.Spec.MinReplicas
+        }
+
+        restoredMin = oldMin
+        if value, ok := hpa.Annotations[annotationOriginalMinReplicas]; ok {
+            if parsed, err := strconv.ParseInt(value, 10, 32); err == nil {
+                restoredMin = int32(parsed)
+            }
+        }
+
+        delete(hpa.Annotations, annotationOriginalMinReplicas)
+        delete(hpa.Annotations, annotationMinReplicasExpires)
+        hpa.Spec.MinReplicas = &restoredMin
+
+        _, err = hpaClient.Update(ctx, hpa, metav1.UpdateOptions{})
+        return err
+    })
+    if err != nil {
+        fmt.Printf("❌ Failed to restore minReplicas of HPA %s/%s: %v\n", namespace, name, err)
+        return
+    }
+
+    fmt.Printf("📉 Restored minReplicas of HPA %s/%s from %d to %d (override expired)\n", 
+        namespace, name, oldMin, restoredMin)
+    a.appendHistory(ActionRecord{
+        Action:    "HPA_MIN_RESTORED",
+        Namespace: namespace,
+        Target:    fmt.Sprintf("HorizontalPodAutoscaler %s/%s", namespace, name),
+        Details:   fmt.Sprintf("minReplicas %d → %d", oldMin, restoredMin),
+        Timestamp: time.Now(),
+    })
+}
@@ -0,0 +1,102 @@
+package actions
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "k8s-healer/internal/owners"
+    "k8s-healer/internal/predictor"
+    "k8s-healer/internal/remediation"
+
+    autoscalingv2 "k8s.io/api/autoscaling/v2"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/runtime"
+)
+
+func testHPA(min, max int32, annotations map[string]string) *autoscalingv2.HorizontalPodAutoscaler {
+    return &autoscalingv2.HorizontalPodAutoscaler{
+        ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web", Annotations: annotations},
+        Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+            ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+            MinReplicas:    &min,
+            MaxReplicas:    max,
+        },
+    }
+}
+
+func TestRaiseHPAMinimum(t *testing.T) {
+    earlier := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+
+    tests := []struct {
+        name        string
+        hpa         *autoscalingv2.HorizontalPodAutoscaler
+        wantStatus  string
+        wantMin     int32
+        wantOrig    string
+        wantExtend  bool
+        wantHistory int
+    }{
+        {
+            name:        "below maxReplicas",
+            hpa:         testHPA(2, 5, nil),
+            wantStatus:  remediation.StatusCompleted,
+            wantMin:     3,
+            wantOrig:    "2",
+            wantExtend:  true,
+            wantHistory: 1,
+        },
+        {
+            name: "extends an override at maxReplicas",
+            hpa: testHPA(5, 5, map[string]string{
+                annotationOriginalMinReplicas: "3",
+                annotationMinReplicasExpires:  earlier,
+            }),
+            wantStatus: remediation.StatusSkipped,
+            wantMin:    5,
+            wantOrig:   "3",
+            wantExtend: true,
+        },
+        {
+            name:       "leaves an HPA configured at maxReplicas alone",
+            hpa:        testHPA(5, 5, nil),
+            wantStatus: remediation.StatusSkipped,
+            wantMin:    5,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            ctx := context.Background()
+            a := testEngine(t, []runtime.Object{tt.hpa})
+            a.hpaOverride = time.Hour
+            workload := &owners.Workload{Kind: "Deployment", Name: "web", Namespace: "shop", APIVersion: "apps/v1"}
+
+            result, err := a.raiseHPAMinimum(ctx, tt.hpa, workload, predictor.PredictionResult{PodNamespace: "shop", PodName: "web-1"})
+            if err != nil {
+                t.Fatalf("raiseHPAMinimum() error = %v", err)
+            }
+            if result.Status != tt.wantStatus {
+                t.Errorf("Status = %s (%s), want %s", result.Status, result.Message, tt.wantStatus)
+            }
+
+            hpa, err := a.clientset.AutoscalingV2().HorizontalPodAutoscalers("shop").Get(ctx, "web", metav1.GetOptions{})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if *hpa.Spec.MinReplicas != tt.wantMin {
+                t.Errorf("minReplicas = %d, want %d", *hpa.Spec.MinReplicas, tt.wantMin)
+            }
+            if got := hpa.Annotations[annotationOriginalMinReplicas]; got != tt.wantOrig {
+                t.Errorf("%s = %q, want %q", annotationOriginalMinReplicas, got, tt.wantOrig)
+            }
+            expires, ok := hpa.Annotations[annotationMinReplicasExpires]
+            if extended := ok && expires != earlier; extended != tt.wantExtend {
+                t.Errorf("%s = %q, want extended %v", annotationMinReplicasExpires, expires, tt.wantExtend)
+            }
+            if got := len(a.GetActionHistory()); got != tt.wantHistory {
+                t.Errorf("%d actions recorded, want %d", got, tt.wantHistory)
+            }
+        })
+    }
+}
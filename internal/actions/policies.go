@@ -0,0 +1,52 @@
+package actions
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "k8s-healer/internal/diagnostics"
+    "k8s-healer/internal/policy"
+    "k8s-healer/internal/remediation"
+)
+
+// ExecuteRestartPolicies dispatches the remediations HealingPolicies map to
+// restart patterns. Restart patterns have no built-in remediation, so only
+// pods selected by a policy rule are acted on.
+func (a *ActionEngine) ExecuteRestartPolicies(ctx context.Context, patterns []diagnostics.RestartPattern) {
+    for _, pattern := range patterns {
+        decision, ok := a.policies.Resolve(policy.Detection{
+            Kind:      policy.KindRestartPattern,
+            Name:      pattern.Pattern,
+            Status:    pattern.Severity,
+            Namespace: pattern.Namespace,
+            PodName:   pattern.PodName,
+            Labels:    a.podLabels(pattern.Namespace, pattern.PodName),
+        })
+        if !ok {
+            continue
+        }
+
+        key := fmt.Sprintf("%s/%s", pattern.Namespace, pattern.PodName)
+        if allowed, reason := a.policies.Allow(decision, key); !allowed {
+            fmt.Printf("⚠️  Skipping %s - %s\n", key, reason)
+            continue
+        }
+
+        fmt.Printf("📜 %s rule %s: %s → %s for %s\n",
+            decision.Policy, decision.Rule, pattern.Pattern, decision.Remediation, key)
+        result, _ := a.dispatch(ctx, remediation.Request{
+            Action:    decision.Remediation,
+            Namespace: pattern.Namespace,
+            PodName:   pattern.PodName,
+            Risk:      pattern.Severity,
+            Reason:    fmt.Sprintf("%s: %s", pattern.Pattern, pattern.RootCause),
+            DryRun:    a.dryRun || decision.DryRun,
+            Requested: time.Now(),
+            Origin:    pattern,
+        })
+        if result.Status != remediation.StatusSkipped && result.Status != remediation.StatusRateLimited {
+            a.policies.Record(decision, key)
+        }
+    }
+}
@@ -0,0 +1,215 @@
+package actions
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    "k8s-healer/internal/eviction"
+    "k8s-healer/internal/remediation"
+)
+
+func (a *ActionEngine) registerRemediations() {
+    a.registry.MustRegister(&scaleUpRemediation{a}, "SCALE_UP", "SCALE_UP_URGENT")
+    a.registry.MustRegister(&restartPodRemediation{a}, "RESTART_POD", "RESTART_POD_URGENT")
+    a.registry.MustRegister(&rolloutRestartRemediation{a}, "ROLLOUT_RESTART")
+    a.registry.MustRegister(&investigateRemediation{a}, "INVESTIGATE_RESTARTS", "INVESTIGATE_PERFORMANCE")
+    // Planned actions are forecasts 12h or more ahead: they are only watched
+    // unless a HealingPolicy maps them to a remediation
+    a.registry.MustRegister(&monitorRemediation{a}, "MONITOR", "MONITOR_CLOSELY", "MONITOR_MEMORY_LEAK",
+        "SCALE_UP_PLANNED", "RESTART_POD_PLANNED")
+}
+
+// scaleUpRemediation adds a replica to the pod's workload, or raises the
+// minReplicas of the HPA managing it.
+type scaleUpRemediation struct {
+    a *ActionEngine
+}
+
+func (r *scaleUpRemediation) Name() string {
+    return "scale-up"
+}
+
+func (r *scaleUpRemediation) Applies(req remediation.Request) bool {
+    _, err := r.a.cache.Pods.Pods(req.Namespace).Get(req.PodName)
+    return err == nil
+}
+
+func (r *scaleUpRemediation) Plan(ctx context.Context, req remediation.Request) (remediation.Plan, error) {
+    workload, err := r.a.owners.Resolve(ctx, req.Namespace, req.PodName)
+    if err != nil {
+        return remediation.Plan{}, err
+    }
+
+    hpa, err := r.a.findHPA(workload)
+    if err != nil {
+        return remediation.Plan{}, fmt.Errorf("failed to look up HPA for %s: %v", workload, err)
+    }
+    if hpa != nil {
+        return remediation.Plan{
+            Description: fmt.Sprintf("raise minReplicas of HPA %s/%s for %s (CPU overload)", hpa.Namespace, hpa.Name, workload),
+            Steps:       []string{"raise HPA minReplicas by one", "annotate HPA with original minReplicas and expiry"},
+        }, nil
+    }
+
+    if _, err := r.a.scaler.groupResource(workload); err != nil {
+        return remediation.Plan{}, err
+    }
+    return remediation.Plan{
+        Description: fmt.Sprintf("scale UP %s for pod %s/%s (CPU overload)", workload, req.Namespace, req.PodName),
+        Steps:       []string{"add one replica through the scale subresource", "schedule scale-down"},
+    }, nil
+}
+
+func (r *scaleUpRemediation) Execute(ctx context.Context, req remediation.Request) (remediation.Result, error) {
+    return r.a.scaleUpWorkload(ctx, predictionOf(req))
+}
+
+func (r *scaleUpRemediation) Verify(ctx context.Context, req remediation.Request) (bool, error) {
+    workload, err := r.a.owners.Resolve(ctx, req.Namespace, req.PodName)
+    if err != nil {
+        return false, err
+    }
+    return r.a.scaler.Settled(ctx, workload)
+}
+
+// restartPodRemediation evicts the pod so its controller replaces it.
+type restartPodRemediation struct {
+    a *ActionEngine
+}
+
+func (r *restartPodRemediation) Name() string {
+    return "restart-pod"
+}
+
+func (r *restartPodRemediation) Applies(req remediation.Request) bool {
+    _, err := r.a.cache.Pods.Pods(req.Namespace).Get(req.PodName)
+    return err == nil
+}
+
+func (r *restartPodRemediation) Plan(ctx context.Context, req remediation.Request) (remediation.Plan, error) {
+    return remediation.Plan{
+        Description: fmt.Sprintf("restart pod %s/%s (Memory/Status issue)", req.Namespace, req.PodName),
+        Steps:       []string{"evict pod through the Eviction API"},
+    }, nil
+}
+
+func (r *restartPodRemediation) Execute(ctx context.Context, req remediation.Request) (remediation.Result, error) {
+    return r.a.restartPod(ctx, predictionOf(req), req.Done)
+}
+
+// Verify succeeds once the pod is gone or has been recreated under the same
+// name (StatefulSets) after the request.
+func (r *restartPodRemediation) Verify(ctx context.Context, req remediation.Request) (bool, error) {
+    pod, err := r.a.cache.Pods.Pods(req.Namespace).Get(req.PodName)
+    if err != nil {
+        return true, nil
+    }
+    return pod.CreationTimestamp.Time.After(req.Requested), nil
+}
+
+// rolloutRestartRemediation restarts every pod of a workload; the request's
+// Origin is the rolloutPlan.
+type rolloutRestartRemediation struct {
+    a *ActionEngine
+}
+
+func (r *rolloutRestartRemediation) Name() string {
+    return "rollout-restart"
+}
+
+func (r *rolloutRestartRemediation) Applies(req remediation.Request) bool {
+    plan, ok := req.Origin.(rolloutPlan)
+    return ok && rolloutRestartable(plan.workload)
+}
+
+func (r *rolloutRestartRemediation) Plan(ctx context.Context, req remediation.Request) (remediation.Plan, error) {
+    plan := req.Origin.(rolloutPlan)
+    return remediation.Plan{
+        Description: fmt.Sprintf("rollout restart %s (%d pods leaking memory)", plan.workload, len(plan.preds)),
+        Steps:       []string{"set restartedAt annotation on the pod template", "wait for the rollout to complete"},
+    }, nil
+}
+
+func (r *rolloutRestartRemediation) Execute(ctx context.Context, req remediation.Request) (remediation.Result, error) {
+    plan := req.Origin.(rolloutPlan)
+    return r.a.rolloutRestart(ctx, plan.workload, plan.preds)
+}
+
+func (r *rolloutRestartRemediation) Verify(ctx context.Context, req remediation.Request) (bool, error) {
+    plan := req.Origin.(rolloutPlan)
+    done, _, err := r.a.rolloutComplete(ctx, plan.workload)
+    return done, err
+}
+
+// investigateRemediation prints the pod's recent events.
+type investigateRemediation struct {
+    a *ActionEngine
+}
+
+func (r *investigateRemediation) Name() string {
+    return "investigate"
+}
+
+func (r *investigateRemediation) Applies(req remediation.Request) bool {
+    return true
+}
+
+func (r *investigateRemediation) Plan(ctx context.Context, req remediation.Request) (remediation.Plan, error) {
+    return remediation.Plan{
+        Description: fmt.Sprintf("investigate pod %s/%s", req.Namespace, req.PodName),
+        Steps:       []string{"list recent events"},
+        ReadOnly:    true,
+    }, nil
+}
+
+func (r *investigateRemediation) Execute(ctx context.Context, req remediation.Request) (remediation.Result, error) {
+    r.a.investigatePod(predictionOf(req))
+    return remediation.Result{Action: "INVESTIGATE", Status: remediation.StatusCompleted}, nil
+}
+
+func (r *investigateRemediation) Verify(ctx context.Context, req remediation.Request) (bool, error) {
+    return true, nil
+}
+
+// monitorRemediation only flags the pod for closer attention.
+type monitorRemediation struct {
+    a *ActionEngine
+}
+
+func (r *monitorRemediation) Name() string {
+    return "monitor"
+}
+
+func (r *monitorRemediation) Applies(req remediation.Request) bool {
+    return true
+}
+
+func (r *monitorRemediation) Plan(ctx context.Context, req remediation.Request) (remediation.Plan, error) {
+    return remediation.Plan{
+        Description: fmt.Sprintf("monitor pod %s/%s closely", req.Namespace, req.PodName),
+        ReadOnly:    true,
+    }, nil
+}
+
+func (r *monitorRemediation) Execute(ctx context.Context, req remediation.Request) (remediation.Result, error) {
+    r.a.monitorPod(predictionOf(req))
+    return remediation.Result{Action: "MONITOR", Status: remediation.StatusCompleted}, nil
+}
+
+func (r *monitorRemediation) Verify(ctx context.Context, req remediation.Request) (bool, error) {
+    return true, nil
+}
+
+func evictionResult(err error, action, target string) (remediation.Result, error) {
+    if errors.Is(err, eviction.ErrBlockedByPDB) {
+        return remediation.Result{Action: action, Status: remediation.StatusBlockedByPDB, Message: err.Error(), Target: target}, nil
+    }
+    if errors.Is(err, eviction.ErrPodNotFound) {
+        return remediation.Result{Action: action, Status: remediation.StatusSkipped, Message: fmt.Sprintf("%s skipped: %v", action, err), Target: target}, nil
+    }
+    if err != nil {
+        return remediation.Result{Action: action, Status: remediation.StatusFailed, Message: err.Error(), Target: target}, err
+    }
+    return remediation.Result{Action: action, Status: remediation.StatusCompleted, Target: target}, nil
+}
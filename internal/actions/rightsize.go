@@ -0,0 +1,449 @@
+package actions
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "strings"
+    "time"
+
+    "k8s-healer/internal/config"
+    "k8s-healer/internal/diagnostics"
+    "k8s-healer/internal/owners"
+    "k8s-healer/internal/predictor"
+    "k8s-healer/internal/remediation"
+
+    corev1 "k8s.io/api/core/v1"
+    "k8s.io/apimachinery/pkg/api/resource"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/types"
+    "sigs.k8s.io/yaml"
+)
+
+// MemoryRecommendation is a proposed memory limit for a container that was
+// OOMKilled. Patch holds the strategic merge patch for the workload as YAML.
+type MemoryRecommendation struct {
+    Workload      string    `json:"workload"`
+    Container     string    `json:"container"`
+    CurrentLimit  string    `json:"current_limit"`
+    PeakUsage     string    `json:"peak_usage"`
+    ProposedLimit string    `json:"proposed_limit"`
+    CappedBy      []string  `json:"capped_by,omitempty"`
+    Patch         string    `json:"patch"`
+    Applied       bool      `json:"applied"`
+    Timestamp     time.Time `json:"timestamp"`
+}
+
+// RightsizeOOMKilled raises the memory limit of containers the restart
+// analyzer found OOMKilled, or only records the proposed patch in
+// recommend mode.
+func (a *ActionEngine) RightsizeOOMKilled(ctx context.Context, patterns []diagnostics.RestartPattern, pred *predictor.Predictor) {
+    if a.rightsizing == config.RightsizingOff {
+        return
+    }
+
+    handled := make(map[string]bool)
+    for _, pattern := range patterns {
+        if !recommendsMemoryIncrease(pattern) {
+            continue
+        }
+
+        pod, err := a.cache.Pods.Pods(pattern.Namespace).Get(pattern.PodName)
+        if err != nil {
+            continue
+        }
+        workload, err := a.owners.ResolvePod(ctx, pod)
+        if err != nil {
+            fmt.Printf("⚠️  Cannot right-size %s/%s: %v\n", pod.Namespace, pod.Name, err)
+            continue
+        }
+
+        for _, status := range pod.Status.ContainerStatuses {
+            terminated := status.LastTerminationState.Terminated
+            if terminated == nil || terminated.Reason != "OOMKilled" {
+                continue
+            }
+            key := fmt.Sprintf("%s/%s", workload, status.Name)
+            if handled[key] {
+                continue
+            }
+            handled[key] = true
+
+            a.rightsizeContainer(ctx, pod, workload, status.Name, pattern, pred)
+        }
+    }
+}
+
+func recommendsMemoryIncrease(pattern diagnostics.RestartPattern) bool {
+    for _, action := range pattern.Actions {
+        if action == "INCREASE_MEMORY_LIMITS" {
+            return true
+        }
+    }
+    return false
+}
+
+func (a *ActionEngine) rightsizeContainer(ctx context.Context, pod *corev1.Pod, workload *owners.Workload, container string, pattern diagnostics.RestartPattern, pred *predictor.Predictor) {
+    var spec *corev1.Container
+    for i := range pod.Spec.Containers {
+        if pod.Spec.Containers[i].Name == container {
+            spec = &pod.Spec.Containers[i]
+            break
+        }
+    }
+    if spec == nil {
+        return
+    }
+
+    currentLimit, ok := spec.Resources.Limits[corev1.ResourceMemory]
+    if !ok || currentLimit.IsZero() {
+        fmt.Printf("⚠️  Not right-sizing %s container %s: no memory limit set\n", workload, container)
+        return
+    }
+
+    peak := a.workloadPeakMemory(ctx, workload, container, pred)
+
+    // The container was killed at its limit, so the limit is a lower bound
+    // for what it really needs even if the last sample was below it
+    base := currentLimit.DeepCopy()
+    if peak.Cmp(base) > 0 {
+        base = peak.DeepCopy()
+    }
+    proposed := resource.NewQuantity(base.Value()*int64(100+a.memoryHeadroom)/100, resource.BinarySI)
+    proposed = roundUpToMi(proposed)
+
+    var cappedBy []string
+    if proposed.Cmp(a.maxMemoryLimit) > 0 {
+        maxLimit := a.maxMemoryLimit.DeepCopy()
+        proposed = &maxLimit
+        cappedBy = append(cappedBy, fmt.Sprintf("maxMemoryLimit %s", a.maxMemoryLimit.String()))
+    }
+    if limit, reason := a.limitRangeCap(ctx, pod, spec); limit != nil && proposed.Cmp(*limit) > 0 {
+        proposed = limit
+        cappedBy = append(cappedBy, reason)
+    }
+    if limit, reason := a.resourceQuotaCap(ctx, workload, currentLimit); limit != nil && proposed.Cmp(*limit) > 0 {
+        proposed = limit
+        cappedBy = append(cappedBy, reason)
+    }
+
+    target := fmt.Sprintf("%s container %s", workload, container)
+    if proposed.Cmp(currentLimit) <= 0 {
+        // Kept without a patch so the API shows why nothing can be done
+        blocked := MemoryRecommendation{
+            Workload:      workload.String(),
+            Container:     container,
+            CurrentLimit:  currentLimit.String(),
+            PeakUsage:     peak.String(),
+            ProposedLimit: currentLimit.String(),
+            CappedBy:      cappedBy,
+            Timestamp:     time.Now(),
+        }
+        if !a.storeRecommendation(blocked) {
+            return
+        }
+        fmt.Printf("⚠️  Cannot raise memory limit of %s above %s (capped by %s)\n",
+            target, currentLimit.String(), strings.Join(cappedBy, ", "))
+        a.recordRightsize("RIGHTSIZE_MEMORY_BLOCKED", target, pattern,
+            fmt.Sprintf("limit %s, capped by %s", currentLimit.String(), strings.Join(cappedBy, ", ")))
+        return
+    }
+
+    // Pods from before an earlier right-sizing still report the old limit
+    if templateLimit, ok := a.templateMemoryLimit(ctx, workload, container); ok && templateLimit.Cmp(*proposed) >= 0 {
+        return
+    }
+
+    patch, err := memoryLimitPatch(container, proposed)
+    if err != nil {
+        fmt.Printf("❌ Failed to build memory patch for %s: %v\n", target, err)
+        return
+    }
+    patchYAML, err := yaml.JSONToYAML(patch)
+    if err != nil {
+        fmt.Printf("❌ Failed to build memory patch for %s: %v\n", target, err)
+        return
+    }
+
+    recommendation := MemoryRecommendation{
+        Workload:      workload.String(),
+        Container:     container,
+        CurrentLimit:  currentLimit.String(),
+        PeakUsage:     peak.String(),
+        ProposedLimit: proposed.String(),
+        CappedBy:      cappedBy,
+        Patch:         string(patchYAML),
+        Timestamp:     time.Now(),
+    }
+    details := fmt.Sprintf("memory limit %s → %s (peak %s, +%d%% headroom)",
+        currentLimit.String(), proposed.String(), peak.String(), a.memoryHeadroom)
+
+    if a.rightsizing == config.RightsizingRecommend || a.dryRun || !rolloutRestartable(workload) {
+        if !a.storeRecommendation(recommendation) {
+            return
+        }
+        fmt.Printf("📐 RECOMMENDED for %s: %s\n", target, details)
+        a.recordRightsize("RIGHTSIZE_MEMORY_RECOMMENDED", target, pattern, details)
+        return
+    }
+
+    // Applied right-sizings count against the same action budgets as every
+    // remediation
+    req := remediation.Request{
+        Action:        "RIGHTSIZE_MEMORY",
+        Namespace:     pod.Namespace,
+        PodName:       pod.Name,
+        ContainerName: container,
+        Reason:        details,
+        Requested:     time.Now(),
+    }
+    if allowed, reason := a.registry.Reserve(ctx, "rightsize-memory", req); !allowed {
+        fmt.Printf("⏳ Right-sizing of %s held back: %s\n", target, reason)
+        return
+    }
+
+    if err := a.patchWorkload(ctx, workload, patch); err != nil {
+        // Nothing changed, so the action does not count against the budgets
+        a.registry.Release(ctx, "rightsize-memory", req)
+        fmt.Printf("❌ Failed to raise memory limit of %s: %v\n", target, err)
+        a.recordRightsize("RIGHTSIZE_MEMORY_FAILED", target, pattern, fmt.Sprintf("%s: %v", details, err))
+        return
+    }
+
+    recommendation.Applied = true
+    a.storeRecommendation(recommendation)
+    fmt.Printf("📐 RIGHT-SIZED %s: %s\n", target, details)
+    a.recordRightsize("RIGHTSIZE_MEMORY", target, pattern, details)
+}
+
+// workloadPeakMemory returns the highest usage of the container across all
+// pods of the workload still in the predictor history.
+func (a *ActionEngine) workloadPeakMemory(ctx context.Context, workload *owners.Workload, container string, pred *predictor.Predictor) resource.Quantity {
+    peak := *resource.NewQuantity(0, resource.BinarySI)
+
+    pods, err := a.cache.ListPods(workload.Namespace)
+    if err != nil {
+        return peak
+    }
+    for _, pod := range pods {
+        owner, err := a.owners.ResolvePod(ctx, pod)
+        if err != nil || owner.Kind != workload.Kind || owner.Name != workload.Name {
+            continue
+        }
+        if usage, ok := pred.PeakContainerMemory(pod.Namespace, pod.Name, container); ok && usage.Cmp(peak) > 0 {
+            peak = usage
+        }
+    }
+
+    return peak
+}
+
+func roundUpToMi(q *resource.Quantity) *resource.Quantity {
+    const mi = 1024 * 1024
+    value := (q.Value() + mi - 1) / mi * mi
+    return resource.NewQuantity(value, resource.BinarySI)
+}
+
+// limitRangeCap returns the highest memory limit the namespace's LimitRanges
+// allow for the container.
+func (a *ActionEngine) limitRangeCap(ctx context.Context, pod *corev1.Pod, spec *corev1.Container) (*resource.Quantity, string) {
+    limitRanges, err := a.clientset.CoreV1().LimitRanges(pod.Namespace).List(ctx, metav1.ListOptions{})
+    if err != nil {
+        return nil, ""
+    }
+
+    var capLimit *resource.Quantity
+    var reason string
+    lower := func(q resource.Quantity, why string) {
+        if capLimit == nil || q.Cmp(*capLimit) < 0 {
+            limit := q.DeepCopy()
+            capLimit = &limit
+            reason = why
+        }
+    }
+
+    for _, lr := range limitRanges.Items {
+        for _, item := range lr.Spec.Limits {
+            switch item.Type {
+            case corev1.LimitTypeContainer:
+                if max, ok := item.Max[corev1.ResourceMemory]; ok {
+                    lower(max, fmt.Sprintf("LimitRange %s container max %s", lr.Name, max.String()))
+                }
+                if ratio, ok := item.MaxLimitRequestRatio[corev1.ResourceMemory]; ok {
+                    if request, ok := spec.Resources.Requests[corev1.ResourceMemory]; ok && !request.IsZero() {
+                        max := resource.NewQuantity(int64(float64(request.Value())*ratio.AsApproximateFloat64()), resource.BinarySI)
+                        lower(*max, fmt.Sprintf("LimitRange %s limit/request ratio %s", lr.Name, ratio.String()))
+                    }
+                }
+            case corev1.LimitTypePod:
+                if max, ok := item.Max[corev1.ResourceMemory]; ok {
+                    // The other containers keep their limits
+                    remaining := max.DeepCopy()
+                    for _, c := range pod.Spec.Containers {
+                        if c.Name == spec.Name {
+                            continue
+                        }
+                        if limit, ok := c.Resources.Limits[corev1.ResourceMemory]; ok {
+                            remaining.Sub(limit)
+                        }
+                    }
+                    why := fmt.Sprintf("LimitRange %s pod max %s", lr.Name, max.String())
+                    // No room left blocks the raise instead of proposing a
+                    // negative limit
+                    if remaining.Sign() <= 0 {
+                        remaining = *resource.NewQuantity(0, resource.BinarySI)
+                        why += " used up by the other containers"
+                    }
+                    lower(remaining, why)
+                }
+            }
+        }
+    }
+
+    return capLimit, reason
+}
+
+// resourceQuotaCap returns the highest memory limit that still fits in the
+// namespace's limits.memory quota once every replica gets it.
+func (a *ActionEngine) resourceQuotaCap(ctx context.Context, workload *owners.Workload, currentLimit resource.Quantity) (*resource.Quantity, string) {
+    quotas, err := a.clientset.CoreV1().ResourceQuotas(workload.Namespace).List(ctx, metav1.ListOptions{})
+    if err != nil {
+        return nil, ""
+    }
+
+    replicas := int64(a.countWorkloadPods(ctx, workload))
+    if replicas < 1 {
+        replicas = 1
+    }
+
+    var capLimit *resource.Quantity
+    var reason string
+    for _, quota := range quotas.Items {
+        hard, ok := quota.Status.Hard[corev1.ResourceLimitsMemory]
+        if !ok {
+            continue
+        }
+        used := quota.Status.Used[corev1.ResourceLimitsMemory]
+        free := hard.Value() - used.Value()
+        if free < 0 {
+            free = 0
+        }
+        max := resource.NewQuantity(currentLimit.Value()+free/replicas, resource.BinarySI)
+        if capLimit == nil || max.Cmp(*capLimit) < 0 {
+            capLimit = max
+            reason = fmt.Sprintf("ResourceQuota %s limits.memory %s/%s used", quota.Name, used.String(), hard.String())
+        }
+    }
+
+    return capLimit, reason
+}
+
+func (a *ActionEngine) templateMemoryLimit(ctx context.Context, workload *owners.Workload, container string) (resource.Quantity, bool) {
+    var template *corev1.PodTemplateSpec
+    apps := a.clientset.AppsV1()
+
+    switch {
+    case !rolloutRestartable(workload):
+        return resource.Quantity{}, false
+    case workload.Kind == "Deployment":
+        d, err := apps.Deployments(workload.Namespace).Get(ctx, workload.Name, metav1.GetOptions{})
+        if err != nil {
+            return resource.Quantity{}, false
+        }
+        template = &d.Spec.Template
+    case workload.Kind == "StatefulSet":
+        s, err := apps.StatefulSets(workload.Namespace).Get(ctx, workload.Name, metav1.GetOptions{})
+        if err != nil {
+            return resource.Quantity{}, false
+        }
+        template = &s.Spec.Template
+    case workload.Kind == "DaemonSet":
+        d, err := apps.DaemonSets(workload.Namespace).Get(ctx, workload.Name, metav1.GetOptions{})
+        if err != nil {
+            return resource.Quantity{}, false
+        }
+        template = &d.Spec.Template
+    }
+
+    for _, c := range template.Spec.Containers {
+        if c.Name == container {
+            limit, ok := c.Resources.Limits[corev1.ResourceMemory]
+            return limit, ok
+        }
+    }
+    return resource.Quantity{}, false
+}
+
+func memoryLimitPatch(container string, limit *resource.Quantity) ([]byte, error) {
+    patch := map[string]interface{}{
+        "spec": map[string]interface{}{
+            "template": map[string]interface{}{
+                "spec": map[string]interface{}{
+                    "containers": []map[string]interface{}{{
+                        "name": container,
+                        "resources": map[string]interface{}{
+                            "limits": map[string]string{
+                                string(corev1.ResourceMemory): limit.String(),
+                            },
+                        },
+                    }},
+                },
+            },
+        },
+    }
+    return json.Marshal(patch)
+}
+
+func (a *ActionEngine) patchWorkload(ctx context.Context, workload *owners.Workload, patch []byte) error {
+    apps := a.clientset.AppsV1()
+
+    var err error
+    switch workload.Kind {
+    case "Deployment":
+        _, err = apps.Deployments(workload.Namespace).Patch(ctx, workload.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+    case "StatefulSet":
+        _, err = apps.StatefulSets(workload.Namespace).Patch(ctx, workload.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+    case "DaemonSet":
+        _, err = apps.DaemonSets(workload.Namespace).Patch(ctx, workload.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+    default:
+        err = fmt.Errorf("%s cannot be patched", workload)
+    }
+    return err
+}
+
+// storeRecommendation keeps the latest recommendation per container and
+// reports whether it differs from the one already stored.
+func (a *ActionEngine) storeRecommendation(recommendation MemoryRecommendation) bool {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+
+    key := fmt.Sprintf("%s/%s", recommendation.Workload, recommendation.Container)
+    if existing, ok := a.recommendations[key]; ok && existing.ProposedLimit == recommendation.ProposedLimit && existing.Applied == recommendation.Applied {
+        return false
+    }
+    a.recommendations[key] = recommendation
+    return true
+}
+
+func (a *ActionEngine) GetMemoryRecommendations() []MemoryRecommendation {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+
+    recommendations := make([]MemoryRecommendation, 0, len(a.recommendations))
+    for _, recommendation := range a.recommendations {
+        recommendations = append(recommendations, recommendation)
+    }
+    return recommendations
+}
+
+func (a *ActionEngine) recordRightsize(action, target string, pattern diagnostics.RestartPattern, details string) {
+    a.appendHistory(ActionRecord{
+        Action:    action,
+        PodName:   pattern.PodName,
+        Namespace: pattern.Namespace,
+        Target:    target,
+        Risk:      pattern.Severity,
+        Details:   details,
+        Timestamp: time.Now(),
+    })
+}
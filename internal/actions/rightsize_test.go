@@ -0,0 +1,259 @@
+package actions
+
+import (
+    "context"
+    "strings"
+    "testing"
+
+    "k8s-healer/internal/cache"
+    "k8s-healer/internal/owners"
+
+    corev1 "k8s.io/api/core/v1"
+    "k8s.io/apimachinery/pkg/api/resource"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/runtime"
+    "k8s.io/client-go/kubernetes/fake"
+    corelisters "k8s.io/client-go/listers/core/v1"
+    toolscache "k8s.io/client-go/tools/cache"
+)
+
+// testEngine builds an ActionEngine over a fake clientset holding objects and
+// a pod cache holding pods.
+func testEngine(t *testing.T, objects []runtime.Object, pods ...*corev1.Pod) *ActionEngine {
+    t.Helper()
+
+    indexer := toolscache.NewIndexer(toolscache.MetaNamespaceKeyFunc, toolscache.Indexers{toolscache.NamespaceIndex: toolscache.MetaNamespaceIndexFunc})
+    for _, pod := range pods {
+        if err := indexer.Add(pod); err != nil {
+            t.Fatal(err)
+        }
+    }
+    kubeCache := &cache.Cache{Pods: corelisters.NewPodLister(indexer)}
+
+    return &ActionEngine{
+        clientset: fake.NewSimpleClientset(objects...),
+        cache:     kubeCache,
+        owners:    owners.NewResolver(nil, kubeCache),
+        scaleUps:  make(map[string]*ScaleUpRecord),
+    }
+}
+
+// memoryPod is a pod of StatefulSet db whose containers have the given
+// memory limits.
+func memoryPod(name string, limits map[string]string) *corev1.Pod {
+    controller := true
+    pod := &corev1.Pod{
+        ObjectMeta: metav1.ObjectMeta{
+            Namespace: "shop",
+            Name:      name,
+            OwnerReferences: []metav1.OwnerReference{{
+                APIVersion: "apps/v1",
+                Kind:       "StatefulSet",
+                Name:       "db",
+                Controller: &controller,
+            }},
+        },
+        Status: corev1.PodStatus{Phase: corev1.PodRunning},
+    }
+    for _, container := range []string{"app", "sidecar"} {
+        limit, ok := limits[container]
+        if !ok {
+            continue
+        }
+        pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{
+            Name: container,
+            Resources: corev1.ResourceRequirements{
+                Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(limit)},
+                Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+            },
+        })
+    }
+    return pod
+}
+
+func limitRange(name string, item corev1.LimitRangeItem) *corev1.LimitRange {
+    return &corev1.LimitRange{
+        ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: name},
+        Spec:       corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{item}},
+    }
+}
+
+func memoryQuota(name, hard, used string) *corev1.ResourceQuota {
+    return &corev1.ResourceQuota{
+        ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: name},
+        Status: corev1.ResourceQuotaStatus{
+            Hard: corev1.ResourceList{corev1.ResourceLimitsMemory: resource.MustParse(hard)},
+            Used: corev1.ResourceList{corev1.ResourceLimitsMemory: resource.MustParse(used)},
+        },
+    }
+}
+
+func memoryList(value string) corev1.ResourceList {
+    return corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(value)}
+}
+
+func TestLimitRangeCap(t *testing.T) {
+    pod := memoryPod("db-0", map[string]string{"app": "512Mi", "sidecar": "256Mi"})
+
+    tests := []struct {
+        name       string
+        objects    []runtime.Object
+        want       string // empty when nothing caps the limit
+        wantReason string
+    }{
+        {name: "no LimitRange"},
+        {
+            name: "container max",
+            objects: []runtime.Object{
+                limitRange("containers", corev1.LimitRangeItem{Type: corev1.LimitTypeContainer, Max: memoryList("1Gi")}),
+            },
+            want:       "1Gi",
+            wantReason: "LimitRange containers container max 1Gi",
+        },
+        {
+            name: "limit/request ratio",
+            objects: []runtime.Object{
+                limitRange("ratio", corev1.LimitRangeItem{Type: corev1.LimitTypeContainer, MaxLimitRequestRatio: memoryList("3")}),
+            },
+            want:       "768Mi",
+            wantReason: "limit/request ratio 3",
+        },
+        {
+            name: "pod max less the other containers",
+            objects: []runtime.Object{
+                limitRange("pods", corev1.LimitRangeItem{Type: corev1.LimitTypePod, Max: memoryList("1Gi")}),
+            },
+            want:       "768Mi",
+            wantReason: "LimitRange pods pod max 1Gi",
+        },
+        {
+            name: "pod max used up by the other containers",
+            objects: []runtime.Object{
+                limitRange("pods", corev1.LimitRangeItem{Type: corev1.LimitTypePod, Max: memoryList("128Mi")}),
+            },
+            want:       "0",
+            wantReason: "used up by the other containers",
+        },
+        {
+            name: "tightest cap wins",
+            objects: []runtime.Object{
+                limitRange("containers", corev1.LimitRangeItem{Type: corev1.LimitTypeContainer, Max: memoryList("2Gi")}),
+                limitRange("pods", corev1.LimitRangeItem{Type: corev1.LimitTypePod, Max: memoryList("1Gi")}),
+            },
+            want:       "768Mi",
+            wantReason: "LimitRange pods",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            a := testEngine(t, tt.objects)
+            limit, reason := a.limitRangeCap(context.Background(), pod, &pod.Spec.Containers[0])
+            if tt.want == "" {
+                if limit != nil {
+                    t.Fatalf("limitRangeCap() = %s, want no cap", limit.String())
+                }
+                return
+            }
+            if limit == nil {
+                t.Fatalf("limitRangeCap() = nil, want %s", tt.want)
+            }
+            if limit.Cmp(resource.MustParse(tt.want)) != 0 {
+                t.Errorf("limitRangeCap() = %s, want %s", limit.String(), tt.want)
+            }
+            if !strings.Contains(reason, tt.wantReason) {
+                t.Errorf("reason = %q, want it to mention %q", reason, tt.wantReason)
+            }
+        })
+    }
+}
+
+func TestResourceQuotaCap(t *testing.T) {
+    workload := &owners.Workload{Kind: "StatefulSet", Name: "db", Namespace: "shop", APIVersion: "apps/v1"}
+    replicas := []*corev1.Pod{
+        memoryPod("db-0", map[string]string{"app": "512Mi"}),
+        memoryPod("db-1", map[string]string{"app": "512Mi"}),
+    }
+
+    tests := []struct {
+        name       string
+        objects    []runtime.Object
+        pods       []*corev1.Pod
+        want       string // empty when nothing caps the limit
+        wantReason string
+    }{
+        {name: "no ResourceQuota", pods: replicas},
+        {
+            name:       "free quota shared by the replicas",
+            objects:    []runtime.Object{memoryQuota("mem", "4Gi", "2Gi")},
+            pods:       replicas,
+            want:       "1536Mi",
+            wantReason: "ResourceQuota mem limits.memory 2Gi/4Gi used",
+        },
+        {
+            name:       "workload without running pods counts as one replica",
+            objects:    []runtime.Object{memoryQuota("mem", "4Gi", "2Gi")},
+            want:       "2560Mi",
+            wantReason: "ResourceQuota mem",
+        },
+        {
+            name:       "quota already exceeded",
+            objects:    []runtime.Object{memoryQuota("mem", "1Gi", "2Gi")},
+            pods:       replicas,
+            want:       "512Mi",
+            wantReason: "ResourceQuota mem",
+        },
+        {
+            name: "tightest quota wins",
+            objects: []runtime.Object{
+                memoryQuota("team", "8Gi", "2Gi"),
+                memoryQuota("mem", "3Gi", "2Gi"),
+            },
+            pods:       replicas,
+            want:       "1Gi",
+            wantReason: "ResourceQuota mem",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            a := testEngine(t, tt.objects, tt.pods...)
+            limit, reason := a.resourceQuotaCap(context.Background(), workload, resource.MustParse("512Mi"))
+            if tt.want == "" {
+                if limit != nil {
+                    t.Fatalf("resourceQuotaCap() = %s, want no cap", limit.String())
+                }
+                return
+            }
+            if limit == nil {
+                t.Fatalf("resourceQuotaCap() = nil, want %s", tt.want)
+            }
+            if limit.Cmp(resource.MustParse(tt.want)) != 0 {
+                t.Errorf("resourceQuotaCap() = %s, want %s", limit.String(), tt.want)
+            }
+            if !strings.Contains(reason, tt.wantReason) {
+                t.Errorf("reason = %q, want it to mention %q", reason, tt.wantReason)
+            }
+        })
+    }
+}
+
+func TestRoundUpToMi(t *testing.T) {
+    tests := []struct {
+        in   int64
+        want string
+    }{
+        {in: 0, want: "0"},
+        {in: 1, want: "1Mi"},
+        {in: 1024 * 1024, want: "1Mi"},
+        {in: 1024*1024 + 1, want: "2Mi"},
+        {in: 1536 * 1024 * 1024, want: "1536Mi"},
+    }
+
+    for _, tt := range tests {
+        got := roundUpToMi(resource.NewQuantity(tt.in, resource.BinarySI))
+        if got.Cmp(resource.MustParse(tt.want)) != 0 {
+            t.Errorf("roundUpToMi(%d) = %s, want %s", tt.in, got.String(), tt.want)
+        }
+    }
+}
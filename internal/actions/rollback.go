@@ -0,0 +1,253 @@
+package actions
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "k8s-healer/internal/diagnostics"
+    "k8s-healer/internal/remediation"
+
+    appsv1 "k8s.io/api/apps/v1"
+    corev1 "k8s.io/api/core/v1"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/labels"
+    "k8s.io/client-go/util/retry"
+)
+
+const (
+    annotationRevision = "deployment.kubernetes.io/revision"
+
+    // Set on the Deployment so the healer never rolls back onto a revision
+    // it already rolled back from.
+    annotationRolledBackFrom = "healer.io/rolled-back-from-revision"
+)
+
+// RollbackCrashLoops executes the ROLLBACK_DEPLOYMENT recommendation of the
+// restart analyzer for namespaces that opted in.
+func (a *ActionEngine) RollbackCrashLoops(ctx context.Context, patterns []diagnostics.RestartPattern) {
+    handled := make(map[string]bool)
+
+    for _, pattern := range patterns {
+        if !recommendsRollback(pattern) || !a.rollbackEnabled(pattern.Namespace) {
+            continue
+        }
+
+        pod, err := a.cache.Pods.Pods(pattern.Namespace).Get(pattern.PodName)
+        if err != nil {
+            continue
+        }
+        workload, err := a.owners.ResolvePod(ctx, pod)
+        if err != nil || workload.Kind != "Deployment" || workload.APIVersion != "apps/v1" {
+            continue
+        }
+        key := workload.String()
+        if handled[key] {
+            continue
+        }
+        handled[key] = true
+
+        a.rollbackDeployment(ctx, pod, workload.Name, pattern)
+    }
+}
+
+func recommendsRollback(pattern diagnostics.RestartPattern) bool {
+    for _, action := range pattern.Actions {
+        if action == "ROLLBACK_DEPLOYMENT" {
+            return true
+        }
+    }
+    return false
+}
+
+func (a *ActionEngine) rollbackEnabled(namespace string) bool {
+    for _, ns := range a.rollbackNamespaces {
+        if ns == namespace || ns == "*" {
+            return true
+        }
+    }
+    return false
+}
+
+func (a *ActionEngine) rollbackDeployment(ctx context.Context, pod *corev1.Pod, name string, pattern diagnostics.RestartPattern) {
+    deployment, err := a.cache.Deployments.Deployments(pod.Namespace).Get(name)
+    if err != nil {
+        fmt.Printf("❌ Cannot roll back Deployment %s/%s: %v\n", pod.Namespace, name, err)
+        return
+    }
+    key := fmt.Sprintf("Deployment %s/%s", deployment.Namespace, deployment.Name)
+
+    if deployment.Spec.Paused {
+        fmt.Printf("⏸️  Not rolling back %s: rollout is paused\n", key)
+        return
+    }
+
+    current, previous, err := a.deploymentRevisions(deployment)
+    if err != nil {
+        fmt.Printf("⚠️  Not rolling back %s: %v\n", key, err)
+        return
+    }
+
+    // Only roll back when the crashing pod runs the newest revision. Such a
+    // pod was created after the revision, so all its crashes came after too.
+    podRS := metav1.GetControllerOf(pod)
+    if podRS == nil || podRS.UID != current.UID {
+        fmt.Printf("⚠️  Not rolling back %s: crashing pod %s is not part of the newest revision\n", key, pod.Name)
+        return
+    }
+
+    healthy, reason := a.replicaSetHealthy(previous)
+    if !healthy {
+        fmt.Printf("⚠️  Not rolling back %s: previous revision %s is not healthy (%s)\n",
+            key, previous.Annotations[annotationRevision], reason)
+        return
+    }
+
+    fromRevision := current.Annotations[annotationRevision]
+    toRevision := previous.Annotations[annotationRevision]
+    if deployment.Annotations[annotationRolledBackFrom] == toRevision {
+        fmt.Printf("⚠️  Not rolling back %s: revision %s was already rolled back from\n", key, toRevision)
+        return
+    }
+
+    details := fmt.Sprintf("revision %s → %s after %s of pod %s (%d restarts, %s); images %s → %s",
+        fromRevision, toRevision, pattern.Pattern, pod.Name, pattern.RestartCount, pattern.RootCause,
+        templateImages(current.Spec.Template), templateImages(previous.Spec.Template))
+
+    if a.dryRun {
+        fmt.Printf("⏪ [DRY RUN] Would roll back %s: %s\n", key, details)
+        a.recordRollback("ROLLBACK_DEPLOYMENT_DRY_RUN", key, pattern, details)
+        return
+    }
+
+    // Rollbacks count against the same action budgets as every remediation
+    req := remediation.Request{
+        Action:    "ROLLBACK_DEPLOYMENT",
+        Namespace: pod.Namespace,
+        PodName:   pod.Name,
+        Reason:    details,
+        Requested: time.Now(),
+    }
+    if allowed, reason := a.registry.Reserve(ctx, "rollback-deployment", req); !allowed {
+        fmt.Printf("⏳ Rollback of %s held back: %s\n", key, reason)
+        return
+    }
+
+    err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+        latest, err := a.clientset.AppsV1().Deployments(deployment.Namespace).Get(ctx, deployment.Name, metav1.GetOptions{})
+        if err != nil {
+            return err
+        }
+
+        // Same as `kubectl rollout undo`: restore the previous ReplicaSet's template
+        template := previous.Spec.Template.DeepCopy()
+        delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+        latest.Spec.Template = *template
+
+        if latest.Annotations == nil {
+            latest.Annotations = make(map[string]string)
+        }
+        latest.Annotations[annotationRolledBackFrom] = fromRevision
+
+        _, err = a.clientset.AppsV1().Deployments(deployment.Namespace).Update(ctx, latest, metav1.UpdateOptions{})
+        return err
+    })
+    if err != nil {
+        // Nothing changed, so the action does not count against the budgets
+        a.registry.Release(ctx, "rollback-deployment", req)
+        fmt.Printf("❌ Failed to roll back %s: %v\n", key, err)
+        a.recordRollback("ROLLBACK_DEPLOYMENT_FAILED", key, pattern, fmt.Sprintf("%s: %v", details, err))
+        return
+    }
+
+    fmt.Printf("⏪ ROLLED BACK %s: %s\n", key, details)
+    a.recordRollback("ROLLBACK_DEPLOYMENT", key, pattern, details)
+}
+
+// deploymentRevisions returns the ReplicaSets of the newest and the previous
+// revision of the Deployment.
+func (a *ActionEngine) deploymentRevisions(deployment *appsv1.Deployment) (*appsv1.ReplicaSet, *appsv1.ReplicaSet, error) {
+    replicaSets, err := a.cache.ReplicaSets.ReplicaSets(deployment.Namespace).List(labels.Everything())
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to list replicasets: %v", err)
+    }
+
+    var owned []*appsv1.ReplicaSet
+    for _, rs := range replicaSets {
+        ref := metav1.GetControllerOf(rs)
+        if ref == nil || ref.UID != deployment.UID {
+            continue
+        }
+        if _, err := revisionOf(rs); err != nil {
+            continue
+        }
+        owned = append(owned, rs)
+    }
+
+    if len(owned) < 2 {
+        return nil, nil, fmt.Errorf("no previous revision to roll back to")
+    }
+
+    sort.Slice(owned, func(i, j int) bool {
+        ri, _ := revisionOf(owned[i])
+        rj, _ := revisionOf(owned[j])
+        return ri > rj
+    })
+
+    return owned[0], owned[1], nil
+}
+
+func revisionOf(rs *appsv1.ReplicaSet) (int64, error) {
+    return strconv.ParseInt(rs.Annotations[annotationRevision], 10, 64)
+}
+
+// replicaSetHealthy treats a ReplicaSet as healthy when it still has available
+// replicas and none of its remaining pods is crash looping. A ReplicaSet with
+// no pods proves nothing, e.g. after a Recreate rollout.
+func (a *ActionEngine) replicaSetHealthy(rs *appsv1.ReplicaSet) (bool, string) {
+    if rs.Status.AvailableReplicas == 0 {
+        return false, "it has no available replicas"
+    }
+
+    pods, err := a.cache.ListPods(rs.Namespace)
+    if err != nil {
+        return false, err.Error()
+    }
+
+    for _, pod := range pods {
+        ref := metav1.GetControllerOf(pod)
+        if ref == nil || ref.UID != rs.UID {
+            continue
+        }
+        for _, status := range pod.Status.ContainerStatuses {
+            if status.State.Waiting != nil && status.State.Waiting.Reason == "CrashLoopBackOff" {
+                return false, fmt.Sprintf("pod %s is in CrashLoopBackOff", pod.Name)
+            }
+        }
+    }
+
+    return true, ""
+}
+
+func templateImages(template corev1.PodTemplateSpec) string {
+    var images []string
+    for _, container := range template.Spec.Containers {
+        images = append(images, container.Image)
+    }
+    return strings.Join(images, ",")
+}
+
+func (a *ActionEngine) recordRollback(action, target string, pattern diagnostics.RestartPattern, details string) {
+    a.appendHistory(ActionRecord{
+        Action:    action,
+        PodName:   pattern.PodName,
+        Namespace: pattern.Namespace,
+        Target:    target,
+        Risk:      pattern.Severity,
+        Details:   details,
+        Timestamp: time.Now(),
+    })
+}
@@ -0,0 +1,245 @@
+package actions
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "k8s-healer/internal/owners"
+    "k8s-healer/internal/predictor"
+    "k8s-healer/internal/remediation"
+
+    appsv1 "k8s.io/api/apps/v1"
+    corev1 "k8s.io/api/core/v1"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/types"
+    "k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Same annotation `kubectl rollout restart` sets on the pod template.
+const annotationRestartedAt = "kubectl.kubernetes.io/restartedAt"
+
+const (
+    rolloutPollInterval = 5 * time.Second
+    rolloutTimeout      = 10 * time.Minute
+)
+
+type rolloutPlan struct {
+    workload *owners.Workload
+    preds    []predictor.PredictionResult
+}
+
+// planRolloutRestarts groups memory-leak predictions by workload and returns
+// the workloads where a majority of pods are leaking, together with the
+// predictions they cover.
+func (a *ActionEngine) planRolloutRestarts(ctx context.Context, predictions []predictor.PredictionResult) []rolloutPlan {
+    leaking := make(map[string][]predictor.PredictionResult)
+    workloads := make(map[string]*owners.Workload)
+
+    for _, pred := range predictions {
+        if !pred.MemoryLeak {
+            continue
+        }
+        workload, err := a.owners.Resolve(ctx, pred.PodNamespace, pred.PodName)
+        if err != nil || !rolloutRestartable(workload) {
+            continue
+        }
+        key := workload.String()
+        workloads[key] = workload
+        leaking[key] = append(leaking[key], pred)
+    }
+
+    var plans []rolloutPlan
+    for key, preds := range leaking {
+        total := a.countWorkloadPods(ctx, workloads[key])
+        // A single leaking replica is better served by restarting just that pod
+        if total < 2 || len(preds)*2 <= total {
+            continue
+        }
+        plans = append(plans, rolloutPlan{workload: workloads[key], preds: preds})
+    }
+
+    return plans
+}
+
+func rolloutRestartable(workload *owners.Workload) bool {
+    if workload.APIVersion != "apps/v1" {
+        return false
+    }
+    switch workload.Kind {
+    case "Deployment", "StatefulSet", "DaemonSet":
+        return true
+    }
+    return false
+}
+
+func (a *ActionEngine) countWorkloadPods(ctx context.Context, workload *owners.Workload) int {
+    pods, err := a.cache.ListPods(workload.Namespace)
+    if err != nil {
+        return 0
+    }
+
+    count := 0
+    for _, pod := range pods {
+        if pod.DeletionTimestamp != nil || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+            continue
+        }
+        owner, err := a.owners.ResolvePod(ctx, pod)
+        if err != nil || owner.Kind != workload.Kind || owner.Name != workload.Name {
+            continue
+        }
+        count++
+    }
+    return count
+}
+
+func (a *ActionEngine) rolloutRestart(ctx context.Context, workload *owners.Workload, preds []predictor.PredictionResult) (remediation.Result, error) {
+    key := workload.String()
+    pred := preds[0]
+
+    a.mu.Lock()
+    _, inProgress := a.rollouts[key]
+    a.mu.Unlock()
+    if inProgress {
+        fmt.Printf("⏳ Rollout restart of %s already in progress\n", key)
+        return remediation.Result{Status: remediation.StatusSkipped, Message: "rollout already in progress", Target: key}, nil
+    }
+
+    restartedAt := time.Now().Format(time.RFC3339)
+    patch := []byte(fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{%q:%q}}}}}`,
+        annotationRestartedAt, restartedAt))
+
+    var err error
+    apps := a.clientset.AppsV1()
+    switch workload.Kind {
+    case "Deployment":
+        _, err = apps.Deployments(workload.Namespace).Patch(ctx, workload.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+    case "StatefulSet":
+        _, err = apps.StatefulSets(workload.Namespace).Patch(ctx, workload.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+    case "DaemonSet":
+        _, err = apps.DaemonSets(workload.Namespace).Patch(ctx, workload.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+    }
+    if err != nil {
+        return remediation.Result{Target: key}, fmt.Errorf("failed to rollout restart %s: %v", key, err)
+    }
+
+    fmt.Printf("♻️  ROLLOUT RESTART of %s started (%d pods leaking memory)\n", key, len(preds))
+    details := fmt.Sprintf("%d pods with memory leak trend, restartedAt=%s", len(preds), restartedAt)
+    a.recordAction("ROLLOUT_RESTART", pred, key, details)
+
+    a.mu.Lock()
+    a.rollouts[key] = time.Now()
+    a.mu.Unlock()
+
+    go a.waitForRollout(*workload, pred)
+
+    return remediation.Result{Status: remediation.StatusCompleted, Message: details, Target: key}, nil
+}
+
+// waitForRollout polls the workload until every replica runs the restarted
+// template, then records the outcome.
+func (a *ActionEngine) waitForRollout(workload owners.Workload, pred predictor.PredictionResult) {
+    key := workload.String()
+    started := time.Now()
+
+    ctx, cancel := context.WithTimeout(context.Background(), rolloutTimeout)
+    defer cancel()
+
+    var status string
+    err := wait.PollUntilContextCancel(ctx, rolloutPollInterval, false, func(ctx context.Context) (bool, error) {
+        done, current, err := a.rolloutComplete(ctx, &workload)
+        status = current
+        return done, err
+    })
+
+    a.mu.Lock()
+    delete(a.rollouts, key)
+    a.mu.Unlock()
+
+    elapsed := time.Since(started).Round(time.Second)
+    if err != nil {
+        fmt.Printf("❌ Rollout restart of %s did not complete after %v: %s (%v)\n", key, elapsed, status, err)
+        a.recordAction("ROLLOUT_RESTART_FAILED", pred, key, fmt.Sprintf("%s after %v: %v", status, elapsed, err))
+        return
+    }
+
+    fmt.Printf("✅ Rollout restart of %s completed in %v\n", key, elapsed)
+    a.recordAction("ROLLOUT_RESTART_COMPLETED", pred, key, fmt.Sprintf("%s in %v", status, elapsed))
+}
+
+// rolloutComplete mirrors the checks of `kubectl rollout status`.
+func (a *ActionEngine) rolloutComplete(ctx context.Context, workload *owners.Workload) (bool, string, error) {
+    apps := a.clientset.AppsV1()
+
+    switch workload.Kind {
+    case "Deployment":
+        d, err := apps.Deployments(workload.Namespace).Get(ctx, workload.Name, metav1.GetOptions{})
+        if err != nil {
+            return false, "", err
+        }
+        return deploymentRolloutComplete(d)
+    case "StatefulSet":
+        s, err := apps.StatefulSets(workload.Namespace).Get(ctx, workload.Name, metav1.GetOptions{})
+        if err != nil {
+            return false, "", err
+        }
+        return statefulSetRolloutComplete(s)
+    case "DaemonSet":
+        d, err := apps.DaemonSets(workload.Namespace).Get(ctx, workload.Name, metav1.GetOptions{})
+        if err != nil {
+            return false, "", err
+        }
+        return daemonSetRolloutComplete(d)
+    }
+
+    return false, "", fmt.Errorf("%s does not support rollout restart", workload)
+}
+
+func deploymentRolloutComplete(d *appsv1.Deployment) (bool, string, error) {
+    if d.Generation > d.Status.ObservedGeneration {
+        return false, "waiting for rollout to be observed", nil
+    }
+    for _, cond := range d.Status.Conditions {
+        if cond.Type == appsv1.DeploymentProgressing && cond.Reason == "ProgressDeadlineExceeded" {
+            return false, cond.Message, fmt.Errorf("deployment %s/%s exceeded its progress deadline", d.Namespace, d.Name)
+        }
+    }
+
+    replicas := int32(1)
+    if d.Spec.Replicas != nil {
+        replicas = *d.Spec.Replicas
+    }
+    status := fmt.Sprintf("%d/%d updated, %d available", d.Status.UpdatedReplicas, replicas, d.Status.AvailableReplicas)
+    done := d.Status.UpdatedReplicas >= replicas &&
+        d.Status.Replicas == d.Status.UpdatedReplicas &&
+        d.Status.AvailableReplicas >= d.Status.UpdatedReplicas
+    return done, status, nil
+}
+
+func statefulSetRolloutComplete(s *appsv1.StatefulSet) (bool, string, error) {
+    if s.Generation > s.Status.ObservedGeneration {
+        return false, "waiting for rollout to be observed", nil
+    }
+
+    replicas := int32(1)
+    if s.Spec.Replicas != nil {
+        replicas = *s.Spec.Replicas
+    }
+    status := fmt.Sprintf("%d/%d updated, %d ready", s.Status.UpdatedReplicas, replicas, s.Status.ReadyReplicas)
+    done := s.Status.UpdatedReplicas >= replicas &&
+        s.Status.ReadyReplicas >= replicas &&
+        s.Status.CurrentRevision == s.Status.UpdateRevision
+    return done, status, nil
+}
+
+func daemonSetRolloutComplete(d *appsv1.DaemonSet) (bool, string, error) {
+    if d.Generation > d.Status.ObservedGeneration {
+        return false, "waiting for rollout to be observed", nil
+    }
+
+    status := fmt.Sprintf("%d/%d updated, %d available",
+        d.Status.UpdatedNumberScheduled, d.Status.DesiredNumberScheduled, d.Status.NumberAvailable)
+    done := d.Status.UpdatedNumberScheduled >= d.Status.DesiredNumberScheduled &&
+        d.Status.NumberAvailable >= d.Status.DesiredNumberScheduled
+    return done, status, nil
+}
@@ -0,0 +1,222 @@
+package actions
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "strings"
+
+    "k8s-healer/internal/owners"
+
+    apierrors "k8s.io/apimachinery/pkg/api/errors"
+    "k8s.io/apimachinery/pkg/api/meta"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+    "k8s.io/apimachinery/pkg/runtime/schema"
+    "k8s.io/apimachinery/pkg/types"
+    "k8s.io/client-go/discovery"
+    "k8s.io/client-go/discovery/cached/memory"
+    "k8s.io/client-go/dynamic"
+    "k8s.io/client-go/kubernetes"
+    "k8s.io/client-go/rest"
+    "k8s.io/client-go/restmapper"
+    "k8s.io/client-go/scale"
+    "k8s.io/client-go/util/retry"
+)
+
+// Scaler changes replica counts through the /scale subresource, so any
+// workload exposing it (Deployments, StatefulSets, ReplicaSets, Rollouts and
+// other CRDs) can be scaled the same way.
+type Scaler struct {
+    scales    scale.ScalesGetter
+    mapper    meta.RESTMapper
+    dynamic   dynamic.Interface
+    discovery discovery.DiscoveryInterface
+}
+
+func NewScaler(clientset *kubernetes.Clientset, config *rest.Config) (*Scaler, error) {
+    mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(clientset.Discovery()))
+    scaleKindResolver := scale.NewDiscoveryScaleKindResolver(clientset.Discovery())
+
+    scales, err := scale.NewForConfig(rest.CopyConfig(config), mapper, dynamic.LegacyAPIPathResolverFunc, scaleKindResolver)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create scale client: %v", err)
+    }
+    dynamicClient, err := dynamic.NewForConfig(rest.CopyConfig(config))
+    if err != nil {
+        return nil, fmt.Errorf("failed to create dynamic client: %v", err)
+    }
+
+    return &Scaler{
+        scales:    scales,
+        mapper:    mapper,
+        dynamic:   dynamicClient,
+        discovery: clientset.Discovery(),
+    }, nil
+}
+
+func (s *Scaler) groupResource(workload *owners.Workload) (schema.GroupResource, error) {
+    resource, err := s.resource(workload)
+    if err != nil {
+        return schema.GroupResource{}, err
+    }
+    return resource.GroupResource(), nil
+}
+
+func (s *Scaler) resource(workload *owners.Workload) (schema.GroupVersionResource, error) {
+    switch workload.Kind {
+    case "DaemonSet", "Job", "CronJob":
+        return schema.GroupVersionResource{}, fmt.Errorf("%s cannot be scaled", workload)
+    }
+
+    gv, err := schema.ParseGroupVersion(workload.APIVersion)
+    if err != nil {
+        return schema.GroupVersionResource{}, fmt.Errorf("invalid apiVersion %q for %s: %v", workload.APIVersion, workload, err)
+    }
+
+    mapping, err := s.mapper.RESTMapping(schema.GroupKind{Group: gv.Group, Kind: workload.Kind}, gv.Version)
+    if err != nil {
+        return schema.GroupVersionResource{}, fmt.Errorf("failed to map %s to a resource: %v", workload, err)
+    }
+
+    return mapping.Resource, nil
+}
+
+// Annotate sets labels and annotations on a scalable workload of any kind.
+// A nil value removes the key.
+func (s *Scaler) Annotate(ctx context.Context, workload *owners.Workload, labels, annotations map[string]*string) error {
+    resource, err := s.resource(workload)
+    if err != nil {
+        return err
+    }
+
+    patch, err := json.Marshal(map[string]interface{}{
+        "metadata": map[string]interface{}{
+            "labels":      labels,
+            "annotations": annotations,
+        },
+    })
+    if err != nil {
+        return err
+    }
+
+    _, err = s.dynamic.Resource(resource).Namespace(workload.Namespace).Patch(ctx, workload.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+    if err != nil {
+        return fmt.Errorf("failed to annotate %s: %v", workload, err)
+    }
+    return nil
+}
+
+// ListLabeled returns the workloads of every kind with a /scale subresource
+// that match the label selector.
+func (s *Scaler) ListLabeled(ctx context.Context, selector string) ([]unstructured.Unstructured, error) {
+    groups, err := s.discovery.ServerGroups()
+    if err != nil {
+        return nil, fmt.Errorf("failed to discover API groups: %v", err)
+    }
+
+    var items []unstructured.Unstructured
+    for _, group := range groups.Groups {
+        gv := group.PreferredVersion.GroupVersion
+        resources, err := s.discovery.ServerResourcesForGroupVersion(gv)
+        if err != nil {
+            // An unavailable aggregated API must not hide the other groups
+            continue
+        }
+        parsed, err := schema.ParseGroupVersion(gv)
+        if err != nil {
+            continue
+        }
+
+        for _, r := range resources.APIResources {
+            name, sub, ok := strings.Cut(r.Name, "/")
+            if !ok || sub != "scale" {
+                continue
+            }
+            list, err := s.dynamic.Resource(parsed.WithResource(name)).List(ctx, metav1.ListOptions{LabelSelector: selector})
+            if err != nil {
+                return nil, fmt.Errorf("failed to list %s: %v", parsed.WithResource(name).GroupResource(), err)
+            }
+            items = append(items, list.Items...)
+        }
+    }
+    return items, nil
+}
+
+// Exists reports whether the workload is still there.
+func (s *Scaler) Exists(ctx context.Context, workload *owners.Workload) (bool, error) {
+    resource, err := s.resource(workload)
+    if err != nil {
+        return false, err
+    }
+
+    _, err = s.dynamic.Resource(resource).Namespace(workload.Namespace).Get(ctx, workload.Name, metav1.GetOptions{})
+    if apierrors.IsNotFound(err) {
+        return false, nil
+    }
+    if err != nil {
+        return false, fmt.Errorf("failed to get %s: %v", workload, err)
+    }
+    return true, nil
+}
+
+func (s *Scaler) GetReplicas(ctx context.Context, workload *owners.Workload) (int32, error) {
+    resource, err := s.groupResource(workload)
+    if err != nil {
+        return 0, err
+    }
+
+    current, err := s.scales.Scales(workload.Namespace).Get(ctx, resource, workload.Name, metav1.GetOptions{})
+    if err != nil {
+        return 0, fmt.Errorf("failed to get scale of %s: %v", workload, err)
+    }
+
+    return current.Spec.Replicas, nil
+}
+
+// Scale sets the replica count to desired(current), retrying on conflicts
+// with a fresh read of the scale subresource each time.
+func (s *Scaler) Scale(ctx context.Context, workload *owners.Workload, desired func(current int32) int32) (int32, int32, error) {
+    resource, err := s.groupResource(workload)
+    if err != nil {
+        return 0, 0, err
+    }
+
+    var from, to int32
+    err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+        current, err := s.scales.Scales(workload.Namespace).Get(ctx, resource, workload.Name, metav1.GetOptions{})
+        if err != nil {
+            return err
+        }
+
+        from = current.Spec.Replicas
+        to = desired(from)
+        if to == from {
+            return nil
+        }
+
+        current.Spec.Replicas = to
+        _, err = s.scales.Scales(workload.Namespace).Update(ctx, resource, current, metav1.UpdateOptions{})
+        return err
+    })
+    if err != nil {
+        return 0, 0, fmt.Errorf("failed to scale %s: %v", workload, err)
+    }
+
+    return from, to, nil
+}
+
+// Settled reports whether the workload runs as many replicas as it asks for.
+func (s *Scaler) Settled(ctx context.Context, workload *owners.Workload) (bool, error) {
+    resource, err := s.groupResource(workload)
+    if err != nil {
+        return false, err
+    }
+
+    current, err := s.scales.Scales(workload.Namespace).Get(ctx, resource, workload.Name, metav1.GetOptions{})
+    if err != nil {
+        return false, fmt.Errorf("failed to get scale of %s: %v", workload, err)
+    }
+
+    return current.Status.Replicas >= current.Spec.Replicas, nil
+}
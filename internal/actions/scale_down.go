@@ -0,0 +1,254 @@
+package actions
+
+import (
+    "context"
+    "fmt"
+    "strconv"
+    "time"
+
+    "k8s-healer/internal/owners"
+    "k8s-healer/internal/predictor"
+)
+
+// Like HPA overrides, scale-ups are stored on the workload itself so they are
+// undone even if the healer restarts in between. The label finds them again.
+const (
+    labelScaledUp              = "healer.io/scaled-up"
+    annotationOriginalReplicas = "healer.io/original-replicas"
+    annotationScaledReplicas   = "healer.io/scaled-replicas"
+    annotationScaleUpExpires   = "healer.io/scale-up-expires"
+)
+
+// ScaleUpRecord tracks an automatic scale-up so it can be undone once the
+// workload has calmed down.
+type ScaleUpRecord struct {
+    Workload         owners.Workload
+    OriginalReplicas int32
+    ScaledReplicas   int32
+    ScaledAt         time.Time
+    Expires          time.Time
+    CalmSince        time.Time
+}
+
+func (a *ActionEngine) recordScaleUp(ctx context.Context, workload *owners.Workload, from, to int32) {
+    a.mu.Lock()
+    now := time.Now()
+    key := workload.String()
+    record, ok := a.scaleUps[key]
+    if ok {
+        // Keep the original count from the first scale-up, restart the clock
+        record.ScaledReplicas = to
+        record.ScaledAt = now
+        record.Expires = now.Add(a.scaleUpHold)
+        record.CalmSince = time.Time{}
+    } else {
+        record = &ScaleUpRecord{
+            Workload:         *workload,
+            OriginalReplicas: from,
+            ScaledReplicas:   to,
+            ScaledAt:         now,
+            Expires:          now.Add(a.scaleUpHold),
+        }
+        a.scaleUps[key] = record
+    }
+    persisted := *record
+    a.mu.Unlock()
+
+    scaledUp := "true"
+    original := strconv.Itoa(int(persisted.OriginalReplicas))
+    scaled := strconv.Itoa(int(persisted.ScaledReplicas))
+    expires := persisted.Expires.UTC().Format(time.RFC3339)
+    err := a.scaler.Annotate(ctx, workload, map[string]*string{labelScaledUp: &scaledUp}, map[string]*string{
+        annotationOriginalReplicas: &original,
+        annotationScaledReplicas:   &scaled,
+        annotationScaleUpExpires:   &expires,
+    })
+    if err != nil {
+        fmt.Printf("⚠️  Scale-down of %s will not survive a restart: %v\n", key, err)
+    }
+}
+
+// RestoreScaleUps rebuilds the scale-up records from the workloads' labels
+// and annotations. Call it once at startup.
+func (a *ActionEngine) RestoreScaleUps(ctx context.Context) error {
+    items, err := a.scaler.ListLabeled(ctx, labelScaledUp+"=true")
+    if err != nil {
+        return err
+    }
+
+    a.mu.Lock()
+    defer a.mu.Unlock()
+
+    for _, item := range items {
+        annotations := item.GetAnnotations()
+        original, err := strconv.ParseInt(annotations[annotationOriginalReplicas], 10, 32)
+        if err != nil {
+            continue
+        }
+        scaled, err := strconv.ParseInt(annotations[annotationScaledReplicas], 10, 32)
+        if err != nil {
+            continue
+        }
+        // An unreadable expiry means the hold is over
+        expires, _ := time.Parse(time.RFC3339, annotations[annotationScaleUpExpires])
+
+        workload := owners.Workload{
+            Kind:       item.GetKind(),
+            Name:       item.GetName(),
+            Namespace:  item.GetNamespace(),
+            APIVersion: item.GetAPIVersion(),
+        }
+        a.scaleUps[workload.String()] = &ScaleUpRecord{
+            Workload:         workload,
+            OriginalReplicas: int32(original),
+            ScaledReplicas:   int32(scaled),
+            ScaledAt:         expires.Add(-a.scaleUpHold),
+            Expires:          expires,
+        }
+    }
+    return nil
+}
+
+// forgetScaleUp drops the record and its annotations once there is nothing
+// left to undo.
+func (a *ActionEngine) forgetScaleUp(ctx context.Context, record *ScaleUpRecord) {
+    key := record.Workload.String()
+
+    a.mu.Lock()
+    delete(a.scaleUps, key)
+    a.mu.Unlock()
+
+    err := a.scaler.Annotate(ctx, &record.Workload, map[string]*string{labelScaledUp: nil}, map[string]*string{
+        annotationOriginalReplicas: nil,
+        annotationScaledReplicas:   nil,
+        annotationScaleUpExpires:   nil,
+    })
+    if err != nil {
+        fmt.Printf("⚠️  %v\n", err)
+    }
+}
+
+// ReconcileScaleDowns returns automatically scaled workloads to their original
+// replica count once the hold period has expired and every pod of the
+// workload has been STABLE or DECLINING for the configured window.
+func (a *ActionEngine) ReconcileScaleDowns(ctx context.Context, pred *predictor.Predictor) {
+    a.mu.Lock()
+    records := make([]*ScaleUpRecord, 0, len(a.scaleUps))
+    for _, record := range a.scaleUps {
+        records = append(records, record)
+    }
+    a.mu.Unlock()
+
+    now := time.Now()
+    for _, record := range records {
+        calm, found := a.workloadIsCalm(ctx, &record.Workload, pred)
+        if !found && a.workloadDeleted(ctx, record) {
+            continue
+        }
+
+        a.mu.Lock()
+        if !calm {
+            record.CalmSince = time.Time{}
+        } else if record.CalmSince.IsZero() {
+            record.CalmSince = now
+        }
+        ready := calm && !now.Before(record.Expires) && now.Sub(record.CalmSince) >= a.scaleDownWindow
+        a.mu.Unlock()
+
+        if ready {
+            a.scaleDown(ctx, record)
+        }
+    }
+}
+
+// workloadIsCalm reports whether every pod of the workload is STABLE or
+// DECLINING, and whether it has any pods at all.
+func (a *ActionEngine) workloadIsCalm(ctx context.Context, workload *owners.Workload, pred *predictor.Predictor) (bool, bool) {
+    pods, err := a.cache.ListPods(workload.Namespace)
+    if err != nil {
+        return false, true
+    }
+
+    found := false
+    for _, pod := range pods {
+        owner, err := a.owners.ResolvePod(ctx, pod)
+        if err != nil || owner.Kind != workload.Kind || owner.Name != workload.Name {
+            continue
+        }
+        found = true
+
+        trend, ok := pred.Trend(pod.Namespace, pod.Name)
+        if !ok || (trend != "STABLE" && trend != "DECLINING") {
+            return false, true
+        }
+    }
+
+    return found, found
+}
+
+// workloadDeleted drops the record of a workload that no longer exists, as
+// there is nothing left to scale down. It reports whether it did.
+func (a *ActionEngine) workloadDeleted(ctx context.Context, record *ScaleUpRecord) bool {
+    exists, err := a.scaler.Exists(ctx, &record.Workload)
+    if err != nil || exists {
+        return false
+    }
+
+    a.mu.Lock()
+    delete(a.scaleUps, record.Workload.String())
+    a.mu.Unlock()
+
+    fmt.Printf("🗑️  %s was deleted - dropping its scale-down\n", record.Workload.String())
+    return true
+}
+
+func (a *ActionEngine) scaleDown(ctx context.Context, record *ScaleUpRecord) {
+    key := record.Workload.String()
+
+    if a.dryRun {
+        fmt.Printf("📉 [DRY RUN] Would scale DOWN %s to %d replicas\n", key, record.OriginalReplicas)
+        return
+    }
+
+    changedElsewhere := false
+    from, to, err := a.scaler.Scale(ctx, &record.Workload, func(current int32) int32 {
+        // Someone else changed the replica count since we scaled - leave it alone
+        if current != record.ScaledReplicas {
+            changedElsewhere = true
+            return current
+        }
+        return record.OriginalReplicas
+    })
+    if err != nil {
+        fmt.Printf("❌ %v\n", err)
+        return
+    }
+
+    a.forgetScaleUp(ctx, record)
+
+    if changedElsewhere {
+        fmt.Printf("⚠️  %s was rescaled to %d outside the healer - dropping scale-down\n", key, from)
+        return
+    }
+
+    fmt.Printf("📉 AUTO-SCALED DOWN %s from %d to %d replicas (trend stable for %v)\n", 
+        key, from, to, time.Since(record.CalmSince).Round(time.Second))
+    a.appendHistory(ActionRecord{
+        Action:    "AUTO_SCALE_DOWN",
+        Namespace: record.Workload.Namespace,
+        Target:    key,
+        Details:   fmt.Sprintf("replicas %d → %d (scaled up at %s)", from, to, record.ScaledAt.Format(time.RFC3339)),
+        Timestamp: time.Now(),
+    })
+}
+
+func (a *ActionEngine) GetScaleUps() []ScaleUpRecord {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+
+    records := make([]ScaleUpRecord, 0, len(a.scaleUps))
+    for _, record := range a.scaleUps {
+        records = append(records, *record)
+    }
+    return records
+}
@@ -0,0 +1,49 @@
+package actions
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "k8s-healer/internal/owners"
+
+    "k8s.io/apimachinery/pkg/api/meta"
+    "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+    "k8s.io/apimachinery/pkg/runtime"
+    "k8s.io/apimachinery/pkg/runtime/schema"
+    dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestReconcileScaleDownsForgetsDeletedWorkloads(t *testing.T) {
+    deployments := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+    mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{deployments.GroupVersion()})
+    mapper.Add(deployments, meta.RESTScopeNamespace)
+
+    remaining := &unstructured.Unstructured{}
+    remaining.SetGroupVersionKind(deployments)
+    remaining.SetNamespace("shop")
+    remaining.SetName("api")
+
+    a := testEngine(t, nil)
+    a.scaler = &Scaler{
+        mapper:  mapper,
+        dynamic: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), remaining),
+    }
+    for _, name := range []string{"api", "web"} {
+        workload := owners.Workload{Kind: "Deployment", Name: name, Namespace: "shop", APIVersion: "apps/v1"}
+        a.scaleUps[workload.String()] = &ScaleUpRecord{
+            Workload:         workload,
+            OriginalReplicas: 2,
+            ScaledReplicas:   3,
+            Expires:          time.Now().Add(-time.Minute),
+        }
+    }
+
+    // Neither Deployment has pods, but only web is gone
+    a.ReconcileScaleDowns(context.Background(), nil)
+
+    records := a.GetScaleUps()
+    if len(records) != 1 || records[0].Workload.Name != "api" {
+        t.Fatalf("scale-ups left = %+v, want only Deployment shop/api", records)
+    }
+}
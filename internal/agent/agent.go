@@ -0,0 +1,81 @@
+package agent
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "os"
+    "os/signal"
+    "syscall"
+    "time"
+
+    "k8s-healer/internal/config"
+)
+
+// ReportPath is the healer API endpoint agents post their NodeReport to.
+const ReportPath = "/agent/report"
+
+// Run is the entry point of `healer agent`: it samples the node every
+// interval and posts the report to the healer until it is terminated.
+func Run(args []string) error {
+    cfg, err := config.LoadAgent(args)
+    if err != nil {
+        return err
+    }
+
+    fmt.Printf("🛰️  K8s Healer node agent on %s reporting to %s every %v\n", cfg.NodeName, cfg.HealerURL, cfg.Interval)
+
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+
+    sampler := NewSampler(cfg)
+    client := &http.Client{Timeout: 10 * time.Second}
+    ticker := time.NewTicker(cfg.Interval)
+    defer ticker.Stop()
+
+    for {
+        report := sampler.Sample()
+        for _, problem := range report.Problems {
+            fmt.Printf("🚨 %s: %s\n", problem.Type, problem.Message)
+        }
+        if err := send(ctx, client, cfg, report); err != nil {
+            fmt.Printf("⚠️  Failed to report to healer: %v\n", err)
+        }
+
+        select {
+        case <-ctx.Done():
+            fmt.Println("🛑 Node agent stopped")
+            return nil
+        case <-ticker.C:
+        }
+    }
+}
+
+func send(ctx context.Context, client *http.Client, cfg *config.AgentConfig, report NodeReport) error {
+    body, err := json.Marshal(report)
+    if err != nil {
+        return fmt.Errorf("failed to encode report: %v", err)
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.HealerURL+ReportPath, bytes.NewReader(body))
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set("Authorization", "Bearer "+cfg.Token)
+
+    resp, err := client.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+        msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+        return fmt.Errorf("healer answered %s: %s", resp.Status, bytes.TrimSpace(msg))
+    }
+    return nil
+}
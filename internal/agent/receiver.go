@@ -0,0 +1,133 @@
+package agent
+
+import (
+    "fmt"
+    "sort"
+    "sync"
+    "time"
+)
+
+// Reports older than this many agent intervals are considered stale, e.g.
+// because the agent or its node went away.
+const staleIntervals = 3
+
+// Receiver keeps the latest report of every node agent on the healer side.
+type Receiver struct {
+    mu         sync.RWMutex
+    nodes      map[string]received
+    containers map[string]string // container ID -> node
+}
+
+type received struct {
+    report  NodeReport
+    at      time.Time
+    samples map[string]ContainerSample
+}
+
+// NodeStatus is one node's latest report as served by the API.
+type NodeStatus struct {
+    NodeReport
+    ReceivedAt time.Time `json:"receivedAt"`
+    Stale      bool      `json:"stale"`
+}
+
+func NewReceiver() *Receiver {
+    return &Receiver{
+        nodes:      make(map[string]received),
+        containers: make(map[string]string),
+    }
+}
+
+// Update stores a report, replacing the previous one of the same node.
+func (r *Receiver) Update(report NodeReport) error {
+    if report.Node == "" {
+        return fmt.Errorf("report without node name")
+    }
+
+    samples := make(map[string]ContainerSample, len(report.Containers))
+    for _, sample := range report.Containers {
+        samples[sample.ContainerID] = sample
+    }
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if previous, ok := r.nodes[report.Node]; ok {
+        for id := range previous.samples {
+            delete(r.containers, id)
+        }
+    }
+    for id := range samples {
+        r.containers[id] = report.Node
+    }
+    r.nodes[report.Node] = received{report: report, at: time.Now(), samples: samples}
+    return nil
+}
+
+// Container returns the latest sample of a container, by runtime container
+// ID, if its node reported recently.
+func (r *Receiver) Container(containerID string) (ContainerSample, bool) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    node, ok := r.containers[containerID]
+    if !ok {
+        return ContainerSample{}, false
+    }
+    rec := r.nodes[node]
+    if rec.stale(time.Now()) {
+        return ContainerSample{}, false
+    }
+    sample, ok := rec.samples[containerID]
+    return sample, ok
+}
+
+// Nodes returns the latest report of every node, sorted by node name.
+func (r *Receiver) Nodes() []NodeStatus {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    now := time.Now()
+    statuses := make([]NodeStatus, 0, len(r.nodes))
+    for _, rec := range r.nodes {
+        statuses = append(statuses, NodeStatus{
+            NodeReport: rec.report,
+            ReceivedAt: rec.at,
+            Stale:      rec.stale(now),
+        })
+    }
+    sort.Slice(statuses, func(i, j int) bool { return statuses[i].Node < statuses[j].Node })
+    return statuses
+}
+
+// HasProblems reports whether any node that reported recently has a problem.
+func (r *Receiver) HasProblems() bool {
+    for _, node := range r.Nodes() {
+        if !node.Stale && len(node.Problems) > 0 {
+            return true
+        }
+    }
+    return false
+}
+
+// PrintProblems prints the problems of every node that reported recently.
+func (r *Receiver) PrintProblems() {
+    fmt.Printf("🖥️  === NODE PROBLEMS ===\n")
+    for _, node := range r.Nodes() {
+        if node.Stale {
+            continue
+        }
+        for _, problem := range node.Problems {
+            fmt.Printf("🔴 %s %s: %s\n", node.Node, problem.Type, problem.Message)
+        }
+    }
+    fmt.Printf("======================\n\n")
+}
+
+func (rec received) stale(now time.Time) bool {
+    interval := time.Duration(rec.report.IntervalSeconds) * time.Second
+    if interval <= 0 {
+        interval = 15 * time.Second
+    }
+    return now.Sub(rec.at) > staleIntervals*interval
+}
@@ -0,0 +1,409 @@
+package agent
+
+import (
+    "bufio"
+    "fmt"
+    "io/fs"
+    "os"
+    "path/filepath"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+
+    "k8s-healer/internal/config"
+)
+
+const (
+    diskPressurePercent  = 90.0
+    inodePressurePercent = 90.0
+    zombieLimit          = 100
+    runtimeHangSamples   = 2
+)
+
+var (
+    // cri-containerd-<id>.scope, crio-<id>.scope, docker-<id>.scope or a
+    // bare <id> directory with the cgroupfs driver
+    containerDirPattern = regexp.MustCompile(`(?:^|-)([0-9a-f]{64})(?:\.scope)?$`)
+    // The systemd driver writes the pod UID with underscores
+    podUIDPattern = regexp.MustCompile(`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})`)
+
+    runtimeNames = []string{"containerd", "crio", "dockerd"}
+)
+
+// Sampler reads node and container stats from the host's /proc and cgroup v2
+// hierarchy. The kernel's counters are cumulative, so the previous sample is
+// kept to report what happened during the last interval.
+type Sampler struct {
+    cfg *config.AgentConfig
+
+    prevCPU        cpuTimes
+    prevContainers map[string]counters
+    runtimeBlocked int
+}
+
+type cpuTimes struct {
+    iowait uint64
+    total  uint64
+}
+
+type counters struct {
+    read  uint64
+    write uint64
+    rx    uint64
+    tx    uint64
+}
+
+type process struct {
+    comm  string
+    state string
+}
+
+func NewSampler(cfg *config.AgentConfig) *Sampler {
+    return &Sampler{
+        cfg:            cfg,
+        prevContainers: make(map[string]counters),
+    }
+}
+
+// Sample takes one reading of the node. Containers seen for the first time
+// are only reported from the next sample on, once there is a baseline.
+func (s *Sampler) Sample() NodeReport {
+    report := NodeReport{
+        Node:            s.cfg.NodeName,
+        Timestamp:       time.Now(),
+        IntervalSeconds: int(s.cfg.Interval.Seconds()),
+        Problems:        []Problem{},
+        Containers:      []ContainerSample{},
+    }
+
+    if cpu, err := s.readCPUTimes(); err != nil {
+        report.Errors = append(report.Errors, err.Error())
+    } else {
+        if s.prevCPU.total > 0 && cpu.total > s.prevCPU.total {
+            report.IOWait = 100 * float64(cpu.iowait-s.prevCPU.iowait) / float64(cpu.total-s.prevCPU.total)
+        }
+        s.prevCPU = cpu
+    }
+
+    if data, err := os.ReadFile(filepath.Join(s.cfg.HostProc, "loadavg")); err != nil {
+        report.Errors = append(report.Errors, fmt.Sprintf("failed to read loadavg: %v", err))
+    } else if fields := strings.Fields(string(data)); len(fields) > 0 {
+        report.Load1, _ = strconv.ParseFloat(fields[0], 64)
+    }
+
+    processes := s.scanProcesses()
+    for _, p := range processes {
+        if p.state == "Z" {
+            report.Zombies++
+        }
+    }
+    report.Runtime = s.runtimeStatus(processes)
+
+    var err error
+    if report.KubeletFS, err = fsUsage(s.cfg.KubeletDir); err != nil {
+        report.Errors = append(report.Errors, err.Error())
+    }
+    if report.RuntimeFS, err = fsUsage(s.cfg.RuntimeDir); err != nil {
+        report.Errors = append(report.Errors, err.Error())
+    }
+
+    containers, err := s.sampleContainers(processes)
+    if err != nil {
+        report.Errors = append(report.Errors, err.Error())
+    }
+    report.Containers = append(report.Containers, containers...)
+
+    report.Problems = append(report.Problems, problems(report)...)
+    return report
+}
+
+func problems(report NodeReport) []Problem {
+    var found []Problem
+    for _, usage := range []FSUsage{report.KubeletFS, report.RuntimeFS} {
+        if usage.Path == "" {
+            continue
+        }
+        if usage.UsedPercent >= diskPressurePercent {
+            found = append(found, Problem{
+                Type:    ProblemDiskPressure,
+                Message: fmt.Sprintf("%s is %.0f%% full", usage.Path, usage.UsedPercent),
+            })
+        }
+        if usage.InodesUsedPercent >= inodePressurePercent {
+            found = append(found, Problem{
+                Type:    ProblemInodePressure,
+                Message: fmt.Sprintf("%s has used %.0f%% of its inodes", usage.Path, usage.InodesUsedPercent),
+            })
+        }
+    }
+    if report.Runtime.Blocked >= runtimeHangSamples {
+        found = append(found, Problem{
+            Type:    ProblemRuntimeHang,
+            Message: fmt.Sprintf("%s (pid %d) in uninterruptible sleep for %d samples", report.Runtime.Name, report.Runtime.PID, report.Runtime.Blocked),
+        })
+    }
+    if report.Zombies >= zombieLimit {
+        found = append(found, Problem{
+            Type:    ProblemZombies,
+            Message: fmt.Sprintf("%d zombie processes on the node", report.Zombies),
+        })
+    }
+    return found
+}
+
+// readCPUTimes reads the aggregate cpu line of /proc/stat.
+func (s *Sampler) readCPUTimes() (cpuTimes, error) {
+    data, err := os.ReadFile(filepath.Join(s.cfg.HostProc, "stat"))
+    if err != nil {
+        return cpuTimes{}, fmt.Errorf("failed to read /proc/stat: %v", err)
+    }
+
+    line, _, _ := strings.Cut(string(data), "\n")
+    fields := strings.Fields(line)
+    if len(fields) < 6 || fields[0] != "cpu" {
+        return cpuTimes{}, fmt.Errorf("unexpected /proc/stat format")
+    }
+
+    var times cpuTimes
+    for i, field := range fields[1:] {
+        value, err := strconv.ParseUint(field, 10, 64)
+        if err != nil {
+            return cpuTimes{}, fmt.Errorf("unexpected /proc/stat value %q", field)
+        }
+        // user nice system idle iowait irq softirq steal; guest time is
+        // already part of user and nice
+        if i >= 8 {
+            break
+        }
+        times.total += value
+        if i == 4 {
+            times.iowait = value
+        }
+    }
+    return times, nil
+}
+
+// scanProcesses reads the command and state of every process on the node.
+func (s *Sampler) scanProcesses() map[int]process {
+    processes := make(map[int]process)
+
+    entries, err := os.ReadDir(s.cfg.HostProc)
+    if err != nil {
+        return processes
+    }
+    for _, entry := range entries {
+        pid, err := strconv.Atoi(entry.Name())
+        if err != nil {
+            continue
+        }
+        if p, ok := s.readProcess(pid); ok {
+            processes[pid] = p
+        }
+    }
+    return processes
+}
+
+func (s *Sampler) readProcess(pid int) (process, bool) {
+    data, err := os.ReadFile(filepath.Join(s.cfg.HostProc, strconv.Itoa(pid), "stat"))
+    if err != nil {
+        return process{}, false
+    }
+
+    // pid (comm) state ... where comm may itself contain spaces or parens
+    stat := string(data)
+    start, end := strings.Index(stat, "("), strings.LastIndex(stat, ")")
+    if start < 0 || end < start {
+        return process{}, false
+    }
+    rest := strings.Fields(stat[end+1:])
+    if len(rest) == 0 {
+        return process{}, false
+    }
+    return process{comm: stat[start+1 : end], state: rest[0]}, true
+}
+
+// runtimeStatus finds the container runtime's main process and tracks how
+// long it has been stuck in uninterruptible sleep.
+func (s *Sampler) runtimeStatus(processes map[int]process) RuntimeStatus {
+    status := RuntimeStatus{}
+    for _, name := range runtimeNames {
+        for pid, p := range processes {
+            if p.comm == name && (status.PID == 0 || pid < status.PID) {
+                status = RuntimeStatus{Name: name, PID: pid, State: p.state}
+            }
+        }
+        if status.PID != 0 {
+            break
+        }
+    }
+
+    if status.State == "D" {
+        s.runtimeBlocked++
+    } else {
+        s.runtimeBlocked = 0
+    }
+    status.Blocked = s.runtimeBlocked
+    return status
+}
+
+// sampleContainers walks the kubepods part of the cgroup v2 hierarchy.
+func (s *Sampler) sampleContainers(processes map[int]process) ([]ContainerSample, error) {
+    root := s.cfg.HostCgroup
+    if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err != nil {
+        return nil, fmt.Errorf("cgroup v2 not found at %s, container stats unavailable", root)
+    }
+
+    var samples []ContainerSample
+    seen := make(map[string]bool)
+
+    err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+        if err != nil || !d.IsDir() || path == root {
+            return nil
+        }
+        rel, _ := filepath.Rel(root, path)
+        if !strings.HasPrefix(rel, "kubepods") {
+            return filepath.SkipDir
+        }
+
+        name := d.Name()
+        match := containerDirPattern.FindStringSubmatch(name)
+        if match == nil || strings.Contains(name, "conmon") {
+            return nil
+        }
+
+        id := match[1]
+        current, sample := s.readContainer(path, processes)
+        sample.ContainerID = id
+        if uid := podUIDPattern.FindStringSubmatch(rel); uid != nil {
+            sample.PodUID = strings.ReplaceAll(uid[1], "_", "-")
+        }
+        seen[id] = true
+
+        if prev, ok := s.prevContainers[id]; ok {
+            sample.DiskReadMB = deltaMB(current.read, prev.read)
+            sample.DiskWriteMB = deltaMB(current.write, prev.write)
+            sample.NetworkRxMB = deltaMB(current.rx, prev.rx)
+            sample.NetworkTxMB = deltaMB(current.tx, prev.tx)
+            samples = append(samples, sample)
+        }
+        s.prevContainers[id] = current
+        return filepath.SkipDir
+    })
+
+    for id := range s.prevContainers {
+        if !seen[id] {
+            delete(s.prevContainers, id)
+        }
+    }
+    if err != nil {
+        return samples, fmt.Errorf("failed to walk %s: %v", root, err)
+    }
+    return samples, nil
+}
+
+func (s *Sampler) readContainer(dir string, processes map[int]process) (counters, ContainerSample) {
+    var c counters
+    var sample ContainerSample
+
+    if lines, err := readLines(filepath.Join(dir, "io.stat")); err == nil {
+        for _, line := range lines {
+            for _, field := range strings.Fields(line)[1:] {
+                key, value, _ := strings.Cut(field, "=")
+                n, _ := strconv.ParseUint(value, 10, 64)
+                switch key {
+                case "rbytes":
+                    c.read += n
+                case "wbytes":
+                    c.write += n
+                }
+            }
+        }
+    }
+
+    if lines, err := readLines(filepath.Join(dir, "io.pressure")); err == nil {
+        for _, line := range lines {
+            if !strings.HasPrefix(line, "some ") {
+                continue
+            }
+            for _, field := range strings.Fields(line) {
+                if value, ok := strings.CutPrefix(field, "avg10="); ok {
+                    sample.IOPressure, _ = strconv.ParseFloat(value, 64)
+                }
+            }
+        }
+    }
+
+    var pids []int
+    if lines, err := readLines(filepath.Join(dir, "cgroup.procs")); err == nil {
+        for _, line := range lines {
+            if pid, err := strconv.Atoi(strings.TrimSpace(line)); err == nil {
+                pids = append(pids, pid)
+            }
+        }
+    }
+    sample.ProcessCount = len(pids)
+    for _, pid := range pids {
+        switch processes[pid].state {
+        case "Z":
+            sample.Zombies++
+        case "D":
+            sample.Blocked++
+        }
+    }
+
+    if len(pids) > 0 {
+        c.rx, c.tx = s.readNetDev(pids[0])
+    }
+    return c, sample
+}
+
+// readNetDev sums the traffic of all interfaces but loopback in the network
+// namespace of pid.
+func (s *Sampler) readNetDev(pid int) (rx, tx uint64) {
+    lines, err := readLines(filepath.Join(s.cfg.HostProc, strconv.Itoa(pid), "net", "dev"))
+    if err != nil {
+        return 0, 0
+    }
+    for _, line := range lines {
+        iface, stats, ok := strings.Cut(line, ":")
+        if !ok || strings.TrimSpace(iface) == "lo" {
+            continue
+        }
+        fields := strings.Fields(stats)
+        if len(fields) < 9 {
+            continue
+        }
+        r, _ := strconv.ParseUint(fields[0], 10, 64)
+        t, _ := strconv.ParseUint(fields[8], 10, 64)
+        rx += r
+        tx += t
+    }
+    return rx, tx
+}
+
+func readLines(path string) ([]string, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    var lines []string
+    scanner := bufio.NewScanner(f)
+    for scanner.Scan() {
+        if line := scanner.Text(); strings.TrimSpace(line) != "" {
+            lines = append(lines, line)
+        }
+    }
+    return lines, scanner.Err()
+}
+
+// deltaMB returns the growth of a counter in MB; a counter that went down
+// was reset and counts from zero.
+func deltaMB(current, previous uint64) float64 {
+    if current < previous {
+        return float64(current) / (1024 * 1024)
+    }
+    return float64(current-previous) / (1024 * 1024)
+}
@@ -0,0 +1,25 @@
+//go:build linux
+
+package agent
+
+import (
+    "fmt"
+    "syscall"
+)
+
+func fsUsage(path string) (FSUsage, error) {
+    var st syscall.Statfs_t
+    if err := syscall.Statfs(path, &st); err != nil {
+        return FSUsage{}, fmt.Errorf("failed to stat filesystem %s: %v", path, err)
+    }
+
+    usage := FSUsage{Path: path}
+    // Same as df: blocks reserved for root count as neither used nor free
+    if used := st.Blocks - st.Bfree; used+st.Bavail > 0 {
+        usage.UsedPercent = 100 * float64(used) / float64(used+st.Bavail)
+    }
+    if st.Files > 0 {
+        usage.InodesUsedPercent = 100 * float64(st.Files-st.Ffree) / float64(st.Files)
+    }
+    return usage, nil
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package agent
+
+import "fmt"
+
+// The agent only runs on Linux nodes; this keeps the binary building
+// elsewhere.
+func fsUsage(path string) (FSUsage, error) {
+    return FSUsage{}, fmt.Errorf("filesystem usage of %s not supported on this platform", path)
+}
@@ -0,0 +1,63 @@
+package agent
+
+import "time"
+
+// Node problems an agent can report.
+const (
+    ProblemDiskPressure  = "DiskPressure"
+    ProblemInodePressure = "InodePressure"
+    ProblemRuntimeHang   = "RuntimeHang"
+    ProblemZombies       = "ZombieProcesses"
+)
+
+// NodeReport is what an agent sends to the healer after every sample.
+type NodeReport struct {
+    Node            string            `json:"node"`
+    Timestamp       time.Time         `json:"timestamp"`
+    IntervalSeconds int               `json:"intervalSeconds"`
+    IOWait          float64           `json:"ioWait"`
+    Load1           float64           `json:"load1"`
+    Zombies         int               `json:"zombies"`
+    Runtime         RuntimeStatus     `json:"runtime"`
+    KubeletFS       FSUsage           `json:"kubeletFS"`
+    RuntimeFS       FSUsage           `json:"runtimeFS"`
+    Problems        []Problem         `json:"problems"`
+    Containers      []ContainerSample `json:"containers"`
+    Errors          []string          `json:"errors,omitempty"`
+}
+
+type Problem struct {
+    Type    string `json:"type"`
+    Message string `json:"message"`
+}
+
+type FSUsage struct {
+    Path              string  `json:"path"`
+    UsedPercent       float64 `json:"usedPercent"`
+    InodesUsedPercent float64 `json:"inodesUsedPercent"`
+}
+
+// RuntimeStatus is the state of the container runtime's main process.
+// Blocked counts consecutive samples it spent in uninterruptible sleep.
+type RuntimeStatus struct {
+    Name    string `json:"name"`
+    PID     int    `json:"pid"`
+    State   string `json:"state"`
+    Blocked int    `json:"blocked"`
+}
+
+// ContainerSample holds one container's cgroup stats. Disk and network
+// figures cover the last interval; network traffic is the pod's, as all
+// containers of a pod share its network namespace.
+type ContainerSample struct {
+    ContainerID  string  `json:"containerID"`
+    PodUID       string  `json:"podUID"`
+    DiskReadMB   float64 `json:"diskReadMB"`
+    DiskWriteMB  float64 `json:"diskWriteMB"`
+    NetworkRxMB  float64 `json:"networkRxMB"`
+    NetworkTxMB  float64 `json:"networkTxMB"`
+    IOPressure   float64 `json:"ioPressure"`
+    ProcessCount int     `json:"processCount"`
+    Zombies      int     `json:"zombies"`
+    Blocked      int     `json:"blocked"`
+}
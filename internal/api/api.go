@@ -1,18 +1,33 @@
 package api
 
 import (
+    "crypto/subtle"
     "encoding/json"
     "fmt"
+    "net"
     "net/http"
     "time"
     
+    "k8s-healer/internal/actions"
+    "k8s-healer/internal/agent"
+    "k8s-healer/internal/budget"
+    "k8s-healer/internal/cache"
+    "k8s-healer/internal/config"
     "k8s-healer/internal/diagnostics"
+    "k8s-healer/internal/policy"
 )
 
 type APIServer struct {
     autoHealer   *diagnostics.AutoHealer
     diagEngine   *diagnostics.DiagnosticsEngine
+    actionEngine *actions.ActionEngine
+    policies     *policy.Store
+    budgets      *budget.Budget
+    agents       *agent.Receiver
+    agentToken   string
+    cache        *cache.Cache
     port         string
+    dryRun       bool
 }
 
 type StatusResponse struct {
@@ -21,13 +36,21 @@ type StatusResponse struct {
     TotalActions  int                             `json:"total_actions"`
     RecentActions []diagnostics.HealingAction     `json:"recent_actions"`
     SystemHealth  string                          `json:"system_health"`
+    DryRun        bool                            `json:"dry_run"`
 }
 
-func NewAPIServer(autoHealer *diagnostics.AutoHealer, diagEngine *diagnostics.DiagnosticsEngine, port string) *APIServer {
+func NewAPIServer(autoHealer *diagnostics.AutoHealer, diagEngine *diagnostics.DiagnosticsEngine, actionEngine *actions.ActionEngine, policies *policy.Store, budgets *budget.Budget, agents *agent.Receiver, kubeCache *cache.Cache, cfg *config.Config) *APIServer {
     return &APIServer{
-        autoHealer: autoHealer,
-        diagEngine: diagEngine,
-        port:       port,
+        autoHealer:   autoHealer,
+        diagEngine:   diagEngine,
+        actionEngine: actionEngine,
+        policies:     policies,
+        budgets:      budgets,
+        agents:       agents,
+        agentToken:   cfg.AgentToken,
+        cache:        kubeCache,
+        port:         cfg.Port,
+        dryRun:       cfg.DryRun,
     }
 }
 
@@ -35,6 +58,11 @@ func (s *APIServer) Start() {
     http.HandleFunc("/", s.handleRoot)
     http.HandleFunc("/status", s.handleStatus)
     http.HandleFunc("/actions", s.handleActions)
+    http.HandleFunc("/recommendations", s.handleRecommendations)
+    http.HandleFunc("/policies", s.handlePolicies)
+    http.HandleFunc("/budgets", s.handleBudgets)
+    http.HandleFunc("/nodes", s.handleNodes)
+    http.HandleFunc(agent.ReportPath, s.handleAgentReport)
     http.HandleFunc("/health", s.handleHealth)
     
     fmt.Printf("🌐 API Server starting on port %s\n", s.port)
@@ -105,15 +133,18 @@ func (s *APIServer) handleStatus(w http.ResponseWriter, r *http.Request) {
     
     systemHealth := "HEALTHY"
     if len(recentActions) > 0 {
-        criticalCount := 0
+        criticalCount, degradedCount := 0, 0
         for _, action := range recentActions {
-            if action.ActionType == "RESTART_POD_NETWORK" || action.Status == "FAILED" {
+            if action.ActionType == "RESTART_POD_NETWORK" || action.Status == diagnostics.StatusFailed ||
+               action.Verification == diagnostics.VerificationUnresolved {
                 criticalCount++
+            } else if action.Status == diagnostics.StatusPartial {
+                degradedCount++
             }
         }
         if criticalCount > 5 {
             systemHealth = "CRITICAL"
-        } else if criticalCount > 0 {
+        } else if criticalCount > 0 || degradedCount > 0 {
             systemHealth = "WARNING"
         }
     }
@@ -124,6 +155,7 @@ func (s *APIServer) handleStatus(w http.ResponseWriter, r *http.Request) {
         TotalActions:  len(history),
         RecentActions: recentActions,
         SystemHealth:  systemHealth,
+        DryRun:        s.dryRun,
     }
     
     w.Header().Set("Content-Type", "application/json")
@@ -139,11 +171,122 @@ func (s *APIServer) handleActions(w http.ResponseWriter, r *http.Request) {
     w.Header().Set("Access-Control-Allow-Origin", "*")
     
     json.NewEncoder(w).Encode(map[string]interface{}{
-        "total_actions": len(history),
-        "actions":       history,
+        "total_actions":    len(history),
+        "actions":          history,
+        "workload_actions": s.actionEngine.GetActionHistory(),
+        "active_scale_ups": s.actionEngine.GetScaleUps(),
     })
 }
 
+func (s *APIServer) handleRecommendations(w http.ResponseWriter, r *http.Request) {
+    recommendations := s.actionEngine.GetMemoryRecommendations()
+    
+    // ?format=yaml returns just the patches, ready for kubectl patch
+    if r.URL.Query().Get("format") == "yaml" {
+        w.Header().Set("Content-Type", "application/yaml")
+        for _, rec := range recommendations {
+            if rec.Patch == "" {
+                continue
+            }
+            fmt.Fprintf(w, "# %s container %s: %s -> %s\n", rec.Workload, rec.Container, rec.CurrentLimit, rec.ProposedLimit)
+            fmt.Fprintf(w, "%s---\n", rec.Patch)
+        }
+        return
+    }
+    
+    w.Header().Set("Content-Type", "application/json")
+    w.Header().Set("Access-Control-Allow-Origin", "*")
+    
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "total":           len(recommendations),
+        "recommendations": recommendations,
+    })
+}
+
+func (s *APIServer) handlePolicies(w http.ResponseWriter, r *http.Request) {
+    policies := s.policies.Policies()
+    
+    w.Header().Set("Content-Type", "application/json")
+    w.Header().Set("Access-Control-Allow-Origin", "*")
+    
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "total":    len(policies),
+        "policies": policies,
+    })
+}
+
+func (s *APIServer) handleBudgets(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    w.Header().Set("Access-Control-Allow-Origin", "*")
+    
+    json.NewEncoder(w).Encode(s.budgets.Status())
+}
+
+func (s *APIServer) handleNodes(w http.ResponseWriter, r *http.Request) {
+    nodes := s.agents.Nodes()
+    
+    w.Header().Set("Content-Type", "application/json")
+    w.Header().Set("Access-Control-Allow-Origin", "*")
+    
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "total": len(nodes),
+        "nodes": nodes,
+    })
+}
+
+// handleAgentReport receives the reports of the node agents. Agents must
+// send HEALER_AGENT_TOKEN as a bearer token, and reports are refused while it
+// is unset. A report is only accepted from a pod running on its node.
+func (s *APIServer) handleAgentReport(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+    if s.agentToken == "" {
+        http.Error(w, "agent reports are disabled: HEALER_AGENT_TOKEN is not set", http.StatusForbidden)
+        return
+    }
+    expected := []byte("Bearer " + s.agentToken)
+    if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+        http.Error(w, "unauthorized", http.StatusUnauthorized)
+        return
+    }
+    
+    var report agent.NodeReport
+    if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<20)).Decode(&report); err != nil {
+        http.Error(w, fmt.Sprintf("invalid report: %v", err), http.StatusBadRequest)
+        return
+    }
+    if !s.reportedFromNode(r, report.Node) {
+        http.Error(w, fmt.Sprintf("report for node %q does not come from a pod on that node", report.Node), http.StatusForbidden)
+        return
+    }
+    if err := s.agents.Update(report); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// reportedFromNode reports whether the request comes from the IP of a pod
+// scheduled on node, so one agent cannot report for another node.
+func (s *APIServer) reportedFromNode(r *http.Request, node string) bool {
+    ip, _, err := net.SplitHostPort(r.RemoteAddr)
+    if err != nil || node == "" {
+        return false
+    }
+    pods, err := s.cache.PodsOnNode(node)
+    if err != nil {
+        return false
+    }
+    for _, pod := range pods {
+        if pod.Status.PodIP == ip {
+            return true
+        }
+    }
+    return false
+}
+
 func (s *APIServer) handleHealth(w http.ResponseWriter, r *http.Request) {
     w.Header().Set("Content-Type", "application/json")
     w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -0,0 +1,241 @@
+package budget
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "sync"
+    "time"
+
+    "k8s-healer/internal/config"
+    "k8s-healer/internal/owners"
+    "k8s-healer/internal/remediation"
+)
+
+// Budget is the remediation.Limiter shared by the ActionEngine and the
+// AutoHealer. Every executed remediation counts against sliding-window
+// budgets for its pod, the pod's workload, its namespace and the cluster, and
+// starts a cooldown for that remediation on the same target.
+type Budget struct {
+    cfg    config.BudgetConfig
+    owners *owners.Resolver
+
+    mu        sync.Mutex
+    windows   map[string]*window
+    lastRun   map[string]cooldown
+    workloads map[string]string
+}
+
+type window struct {
+    limit int
+    times []time.Time
+}
+
+type cooldown struct {
+    remediation string
+    target      string
+    until       time.Time
+}
+
+type scope struct {
+    key   string
+    limit int
+}
+
+// Usage is one budget as served by the API.
+type Usage struct {
+    Scope string `json:"scope"`
+    Used  int    `json:"used"`
+    Limit int    `json:"limit"`
+}
+
+type CooldownStatus struct {
+    Remediation string `json:"remediation"`
+    Target      string `json:"target"`
+    Remaining   string `json:"remaining"`
+}
+
+type Status struct {
+    Window    string           `json:"window"`
+    Limits    map[string]int   `json:"limits"`
+    Usage     []Usage          `json:"usage"`
+    Cooldowns []CooldownStatus `json:"cooldowns"`
+}
+
+func New(cfg config.BudgetConfig, resolver *owners.Resolver) *Budget {
+    return &Budget{
+        cfg:       cfg,
+        owners:    resolver,
+        windows:   make(map[string]*window),
+        lastRun:   make(map[string]cooldown),
+        workloads: make(map[string]string),
+    }
+}
+
+// Allow checks the budgets and cooldown of a request and, if it may run,
+// reserves its slot under the same lock, so concurrent callers cannot both
+// take the last one. Release gives the slot back.
+func (b *Budget) Allow(ctx context.Context, name string, req remediation.Request) (bool, string) {
+    scopes := b.scopes(ctx, req)
+    now := time.Now()
+
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    b.prune(now)
+
+    if last, ok := b.lastRun[name+"|"+req.Key()]; ok {
+        if remaining := last.until.Sub(now); remaining > 0 {
+            return false, fmt.Sprintf("%s on %s cooling down for %v", name, req.Key(), remaining.Round(time.Second))
+        }
+    }
+
+    for _, s := range scopes {
+        if s.limit == 0 {
+            continue
+        }
+        if used := b.used(s.key, now); used >= s.limit {
+            return false, fmt.Sprintf("%s action budget exhausted (%d/%d per %v)", s.key, used, s.limit, b.cfg.Window)
+        }
+    }
+
+    for _, s := range scopes {
+        if s.limit == 0 {
+            continue
+        }
+        w, ok := b.windows[s.key]
+        if !ok {
+            w = &window{limit: s.limit}
+            b.windows[s.key] = w
+        }
+        w.times = append(w.times, now)
+    }
+
+    if d := b.cfg.CooldownFor(name); d > 0 {
+        b.lastRun[name+"|"+req.Key()] = cooldown{
+            remediation: name,
+            target:      req.Key(),
+            until:       now.Add(d),
+        }
+    }
+    return true, ""
+}
+
+// Release gives back the slot Allow reserved for a remediation that did not
+// run after all. Slots are interchangeable, so the newest one of every scope
+// is dropped.
+func (b *Budget) Release(ctx context.Context, name string, req remediation.Request) {
+    scopes := b.scopes(ctx, req)
+
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    for _, s := range scopes {
+        if w, ok := b.windows[s.key]; ok && len(w.times) > 0 {
+            w.times = w.times[:len(w.times)-1]
+        }
+    }
+    delete(b.lastRun, name+"|"+req.Key())
+}
+
+// scopes lists the budgets a request counts against. The pod's workload is
+// remembered so Release still finds it after the pod has been evicted.
+func (b *Budget) scopes(ctx context.Context, req remediation.Request) []scope {
+    podKey := fmt.Sprintf("%s/%s", req.Namespace, req.PodName)
+
+    b.mu.Lock()
+    workload, known := b.workloads[podKey]
+    b.mu.Unlock()
+
+    if !known && b.owners != nil {
+        if w, err := b.owners.Resolve(ctx, req.Namespace, req.PodName); err == nil {
+            workload = w.String()
+            b.mu.Lock()
+            b.workloads[podKey] = workload
+            b.mu.Unlock()
+        }
+    }
+
+    scopes := []scope{
+        {key: "pod " + podKey, limit: b.cfg.PerPod},
+        {key: "namespace " + req.Namespace, limit: b.cfg.PerNamespace},
+        {key: "cluster", limit: b.cfg.Cluster},
+    }
+    if workload != "" {
+        scopes = append(scopes, scope{key: "workload " + workload, limit: b.cfg.PerWorkload})
+    }
+    return scopes
+}
+
+// used drops timestamps that left the window and returns what remains.
+func (b *Budget) used(key string, now time.Time) int {
+    w, ok := b.windows[key]
+    if !ok {
+        return 0
+    }
+
+    cutoff := now.Add(-b.cfg.Window)
+    kept := w.times[:0]
+    for _, t := range w.times {
+        if t.After(cutoff) {
+            kept = append(kept, t)
+        }
+    }
+    w.times = kept
+    return len(kept)
+}
+
+func (b *Budget) prune(now time.Time) {
+    for key := range b.windows {
+        if b.used(key, now) == 0 {
+            delete(b.windows, key)
+        }
+    }
+    for key, c := range b.lastRun {
+        if !c.until.After(now) {
+            delete(b.lastRun, key)
+        }
+    }
+    if len(b.windows) == 0 {
+        b.workloads = make(map[string]string)
+    }
+}
+
+// Status returns current usage of every non-empty budget and the active
+// cooldowns.
+func (b *Budget) Status() Status {
+    now := time.Now()
+
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    b.prune(now)
+
+    status := Status{
+        Window: b.cfg.Window.String(),
+        Limits: map[string]int{
+            "pod":       b.cfg.PerPod,
+            "workload":  b.cfg.PerWorkload,
+            "namespace": b.cfg.PerNamespace,
+            "cluster":   b.cfg.Cluster,
+        },
+        Usage:     make([]Usage, 0, len(b.windows)),
+        Cooldowns: make([]CooldownStatus, 0, len(b.lastRun)),
+    }
+    for key, w := range b.windows {
+        status.Usage = append(status.Usage, Usage{Scope: key, Used: len(w.times), Limit: w.limit})
+    }
+    for _, c := range b.lastRun {
+        status.Cooldowns = append(status.Cooldowns, CooldownStatus{
+            Remediation: c.remediation,
+            Target:      c.target,
+            Remaining:   c.until.Sub(now).Round(time.Second).String(),
+        })
+    }
+
+    sort.Slice(status.Usage, func(i, j int) bool { return status.Usage[i].Scope < status.Usage[j].Scope })
+    sort.Slice(status.Cooldowns, func(i, j int) bool {
+        return status.Cooldowns[i].Target+status.Cooldowns[i].Remediation < status.Cooldowns[j].Target+status.Cooldowns[j].Remediation
+    })
+    return status
+}
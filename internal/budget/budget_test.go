@@ -0,0 +1,223 @@
+package budget
+
+import (
+    "context"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+
+    "k8s-healer/internal/config"
+    "k8s-healer/internal/remediation"
+)
+
+func testConfig() config.BudgetConfig {
+    return config.BudgetConfig{
+        Window:       time.Hour,
+        PerPod:       2,
+        PerWorkload:  3,
+        PerNamespace: 4,
+        Cluster:      5,
+        Cooldown:     0,
+        Cooldowns:    map[string]time.Duration{},
+    }
+}
+
+func request(namespace, pod string) remediation.Request {
+    return remediation.Request{Namespace: namespace, PodName: pod}
+}
+
+func TestAllowSlidingWindow(t *testing.T) {
+    tests := []struct {
+        name    string
+        earlier []time.Duration // ages of actions already in the pod's window
+        allowed bool
+    }{
+        {name: "empty window", allowed: true},
+        {name: "one slot left", earlier: []time.Duration{10 * time.Minute}, allowed: true},
+        {name: "exhausted", earlier: []time.Duration{10 * time.Minute, 20 * time.Minute}, allowed: false},
+        {name: "old actions left the window", earlier: []time.Duration{90 * time.Minute, 2 * time.Hour}, allowed: true},
+        {name: "one left the window", earlier: []time.Duration{10 * time.Minute, 61 * time.Minute}, allowed: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            b := New(testConfig(), nil)
+            now := time.Now()
+            w := &window{limit: 2}
+            for _, age := range tt.earlier {
+                w.times = append(w.times, now.Add(-age))
+            }
+            b.windows["pod default/web-1"] = w
+
+            allowed, reason := b.Allow(context.Background(), "restart-pod", request("default", "web-1"))
+            if allowed != tt.allowed {
+                t.Fatalf("Allow() = %v (%s), want %v", allowed, reason, tt.allowed)
+            }
+            if !allowed && !strings.Contains(reason, "pod default/web-1 action budget exhausted") {
+                t.Errorf("reason = %q, want the exhausted pod budget", reason)
+            }
+        })
+    }
+}
+
+func TestAllowReservesAndReleaseGivesBack(t *testing.T) {
+    b := New(testConfig(), nil)
+    ctx := context.Background()
+    req := request("default", "web-1")
+
+    for i := 0; i < 2; i++ {
+        if allowed, reason := b.Allow(ctx, "restart-pod", req); !allowed {
+            t.Fatalf("Allow() #%d refused: %s", i+1, reason)
+        }
+    }
+    if allowed, _ := b.Allow(ctx, "restart-pod", req); allowed {
+        t.Fatal("Allow() beyond the pod budget was allowed")
+    }
+
+    b.Release(ctx, "restart-pod", req)
+    if allowed, reason := b.Allow(ctx, "restart-pod", req); !allowed {
+        t.Fatalf("Allow() after Release refused: %s", reason)
+    }
+}
+
+func TestAllowCooldown(t *testing.T) {
+    tests := []struct {
+        name        string
+        remediation string
+        until       time.Duration // relative to now; 0 means no cooldown
+        allowed     bool
+    }{
+        {name: "no cooldown", remediation: "restart-pod", allowed: true},
+        {name: "cooling down", remediation: "restart-pod", until: 10 * time.Minute, allowed: false},
+        {name: "cooldown expired", remediation: "restart-pod", until: -time.Second, allowed: true},
+        {name: "other remediation", remediation: "cleanup-tmp", until: 10 * time.Minute, allowed: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            b := New(testConfig(), nil)
+            req := request("default", "web-1")
+            if tt.until != 0 {
+                b.lastRun[tt.remediation+"|"+req.Key()] = cooldown{
+                    remediation: tt.remediation,
+                    target:      req.Key(),
+                    until:       time.Now().Add(tt.until),
+                }
+            }
+
+            allowed, reason := b.Allow(context.Background(), "restart-pod", req)
+            if allowed != tt.allowed {
+                t.Fatalf("Allow() = %v (%s), want %v", allowed, reason, tt.allowed)
+            }
+        })
+    }
+}
+
+func TestAllowStartsCooldown(t *testing.T) {
+    cfg := testConfig()
+    cfg.Cooldown = 5 * time.Minute
+    cfg.Cooldowns["cleanup-tmp"] = 30 * time.Minute
+
+    tests := []struct {
+        remediation string
+        want        time.Duration
+    }{
+        {remediation: "restart-pod", want: 5 * time.Minute},
+        {remediation: "cleanup-tmp", want: 30 * time.Minute},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.remediation, func(t *testing.T) {
+            b := New(cfg, nil)
+            req := request("default", "web-1")
+
+            before := time.Now()
+            if allowed, reason := b.Allow(context.Background(), tt.remediation, req); !allowed {
+                t.Fatalf("Allow() refused: %s", reason)
+            }
+            c, ok := b.lastRun[tt.remediation+"|"+req.Key()]
+            if !ok {
+                t.Fatal("no cooldown started")
+            }
+            if got := c.until.Sub(before); got < tt.want || got > tt.want+time.Second {
+                t.Errorf("cooldown = %v, want %v", got, tt.want)
+            }
+            if allowed, _ := b.Allow(context.Background(), tt.remediation, req); allowed {
+                t.Error("second Allow() during cooldown was allowed")
+            }
+        })
+    }
+}
+
+func TestScopes(t *testing.T) {
+    tests := []struct {
+        name      string
+        workloads map[string]string
+        want      []scope
+    }{
+        {
+            name: "unknown workload",
+            want: []scope{
+                {key: "pod default/web-1", limit: 2},
+                {key: "namespace default", limit: 4},
+                {key: "cluster", limit: 5},
+            },
+        },
+        {
+            name:      "remembered workload",
+            workloads: map[string]string{"default/web-1": "Deployment default/web"},
+            want: []scope{
+                {key: "pod default/web-1", limit: 2},
+                {key: "namespace default", limit: 4},
+                {key: "cluster", limit: 5},
+                {key: "workload Deployment default/web", limit: 3},
+            },
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            b := New(testConfig(), nil)
+            for pod, workload := range tt.workloads {
+                b.workloads[pod] = workload
+            }
+
+            got := b.scopes(context.Background(), request("default", "web-1"))
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("scopes() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestAllowSharedScopes(t *testing.T) {
+    tests := []struct {
+        name    string
+        cfg     func(*config.BudgetConfig)
+        pods    []string
+        allowed int
+    }{
+        {name: "namespace budget", cfg: func(c *config.BudgetConfig) { c.PerNamespace = 2 }, pods: []string{"a", "b", "c"}, allowed: 2},
+        {name: "cluster budget", cfg: func(c *config.BudgetConfig) { c.Cluster = 1 }, pods: []string{"a", "b"}, allowed: 1},
+        {name: "disabled levels", cfg: func(c *config.BudgetConfig) { c.PerNamespace, c.Cluster = 0, 0 }, pods: []string{"a", "b", "c", "d", "e", "f"}, allowed: 6},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            cfg := testConfig()
+            tt.cfg(&cfg)
+            b := New(cfg, nil)
+
+            allowed := 0
+            for _, pod := range tt.pods {
+                if ok, _ := b.Allow(context.Background(), "restart-pod", request("default", pod)); ok {
+                    allowed++
+                }
+            }
+            if allowed != tt.allowed {
+                t.Errorf("allowed %d actions, want %d", allowed, tt.allowed)
+            }
+        })
+    }
+}
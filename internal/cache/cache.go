@@ -0,0 +1,113 @@
+package cache
+
+import (
+    "fmt"
+    "time"
+
+    corev1 "k8s.io/api/core/v1"
+    "k8s.io/apimachinery/pkg/labels"
+    "k8s.io/client-go/informers"
+    "k8s.io/client-go/kubernetes"
+    appslisters "k8s.io/client-go/listers/apps/v1"
+    autoscalinglisters "k8s.io/client-go/listers/autoscaling/v2"
+    corelisters "k8s.io/client-go/listers/core/v1"
+    toolscache "k8s.io/client-go/tools/cache"
+)
+
+const podsByNodeIndex = "spec.nodeName"
+
+// Cache is the shared informer cache built once in main and handed to the
+// collector, diagnostics and action engines so they read from listers
+// instead of listing from the API server on every cycle.
+type Cache struct {
+    factory     informers.SharedInformerFactory
+    podInformer toolscache.SharedIndexInformer
+
+    Pods        corelisters.PodLister
+    Nodes       corelisters.NodeLister
+    Events      corelisters.EventLister
+    Deployments appslisters.DeploymentLister
+    ReplicaSets appslisters.ReplicaSetLister
+    HPAs        autoscalinglisters.HorizontalPodAutoscalerLister
+}
+
+func New(clientset *kubernetes.Clientset, resync time.Duration) *Cache {
+    factory := informers.NewSharedInformerFactory(clientset, resync)
+
+    podInformer := factory.Core().V1().Pods().Informer()
+    podInformer.AddIndexers(toolscache.Indexers{
+        podsByNodeIndex: func(obj interface{}) ([]string, error) {
+            pod, ok := obj.(*corev1.Pod)
+            if !ok || pod.Spec.NodeName == "" {
+                return nil, nil
+            }
+            return []string{pod.Spec.NodeName}, nil
+        },
+    })
+
+    return &Cache{
+        factory:     factory,
+        podInformer: podInformer,
+        Pods:        factory.Core().V1().Pods().Lister(),
+        Nodes:       factory.Core().V1().Nodes().Lister(),
+        Events:      factory.Core().V1().Events().Lister(),
+        Deployments: factory.Apps().V1().Deployments().Lister(),
+        ReplicaSets: factory.Apps().V1().ReplicaSets().Lister(),
+        HPAs:        factory.Autoscaling().V2().HorizontalPodAutoscalers().Lister(),
+    }
+}
+
+// Start runs the informers and blocks until every cache has synced.
+func (c *Cache) Start(stopCh <-chan struct{}) error {
+    c.factory.Start(stopCh)
+
+    for informerType, synced := range c.factory.WaitForCacheSync(stopCh) {
+        if !synced {
+            return fmt.Errorf("failed to sync informer cache for %v", informerType)
+        }
+    }
+
+    return nil
+}
+
+func (c *Cache) AddPodHandler(handler toolscache.ResourceEventHandler) error {
+    _, err := c.podInformer.AddEventHandler(handler)
+    return err
+}
+
+func (c *Cache) ListPods(namespace string) ([]*corev1.Pod, error) {
+    if namespace == "" {
+        return c.Pods.List(labels.Everything())
+    }
+    return c.Pods.Pods(namespace).List(labels.Everything())
+}
+
+func (c *Cache) PodsOnNode(nodeName string) ([]*corev1.Pod, error) {
+    objs, err := c.podInformer.GetIndexer().ByIndex(podsByNodeIndex, nodeName)
+    if err != nil {
+        return nil, err
+    }
+
+    pods := make([]*corev1.Pod, 0, len(objs))
+    for _, obj := range objs {
+        if pod, ok := obj.(*corev1.Pod); ok {
+            pods = append(pods, pod)
+        }
+    }
+    return pods, nil
+}
+
+func (c *Cache) EventsFor(namespace, name string) ([]*corev1.Event, error) {
+    events, err := c.Events.Events(namespace).List(labels.Everything())
+    if err != nil {
+        return nil, err
+    }
+
+    var matching []*corev1.Event
+    for _, event := range events {
+        if event.InvolvedObject.Name == name {
+            matching = append(matching, event)
+        }
+    }
+    return matching, nil
+}
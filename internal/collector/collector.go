@@ -6,15 +6,22 @@ import (
     "strings"
     "time"
 
+    "k8s-healer/internal/cache"
+    "k8s-healer/internal/config"
+
     "k8s.io/client-go/kubernetes"
     metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
     metricsclient "k8s.io/metrics/pkg/client/clientset/versioned"
     "k8s.io/apimachinery/pkg/api/resource"
+    "k8s.io/apimachinery/pkg/labels"
+    corev1 "k8s.io/api/core/v1"
 )
 
 type Collector struct {
     clientset     *kubernetes.Clientset
     metricsClient *metricsclient.Clientset
+    cache         *cache.Cache
+    thresholds    config.ThresholdConfig
 }
 
 type PodMetrics struct {
@@ -28,6 +35,29 @@ type PodMetrics struct {
     Restarts     int32
     Age          time.Duration
     NodeName     string
+    Labels       map[string]string
+    CPUCapacity  string
+    MemCapacity  string
+    CPUBasis     string
+    MemBasis     string
+    Containers   []ContainerMetrics
+}
+
+type ContainerMetrics struct {
+    Name            string
+    CPUUsage        string
+    MemUsage        string
+    CPUPercent      float64
+    MemPercent      float64
+    CPURequest      string
+    CPULimit        string
+    MemRequest      string
+    MemLimit        string
+    CPUBasis        string
+    MemBasis        string
+    Restarts        int32
+    LastTermination string
+    Ready           bool
 }
 
 type NodeMetrics struct {
@@ -39,16 +69,18 @@ type NodeMetrics struct {
     PodCount     int
 }
 
-func New(clientset *kubernetes.Clientset, metricsClient *metricsclient.Clientset) *Collector {
+func New(clientset *kubernetes.Clientset, metricsClient *metricsclient.Clientset, kubeCache *cache.Cache, cfg *config.Config) *Collector {
     return &Collector{
         clientset:     clientset,
         metricsClient: metricsClient,
+        cache:         kubeCache,
+        thresholds:    cfg.Thresholds,
     }
 }
 
 func (c *Collector) GetAllPodMetrics(ctx context.Context) ([]PodMetrics, error) {
-    // Get pods from K8s API (NO kubectl!)
-    pods, err := c.clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+    // Get pods from the informer cache (NO kubectl!)
+    pods, err := c.cache.ListPods("")
     if err != nil {
         return nil, fmt.Errorf("failed to get pods: %v", err)
     }
@@ -60,28 +92,34 @@ func (c *Collector) GetAllPodMetrics(ctx context.Context) ([]PodMetrics, error)
         // Continue without metrics - better than failing
     }
 
-    // Create metrics map for fast lookup
-    metricsMap := make(map[string]map[string]resource.Quantity)
+    // Per-container usage: pod key -> container name -> usage
+    metricsMap := make(map[string]map[string]corev1.ResourceList)
     if podMetricsAPI != nil {
         for _, podMetric := range podMetricsAPI.Items {
             key := fmt.Sprintf("%s/%s", podMetric.Namespace, podMetric.Name)
-            containerMetrics := make(map[string]resource.Quantity)
+            usageByContainer := make(map[string]corev1.ResourceList)
             
             for _, container := range podMetric.Containers {
-                if cpu, exists := container.Usage["cpu"]; exists {
-                    containerMetrics["cpu"] = cpu
-                }
-                if memory, exists := container.Usage["memory"]; exists {
-                    containerMetrics["memory"] = memory
-                }
+                usageByContainer[container.Name] = container.Usage
             }
-            metricsMap[key] = containerMetrics
+            metricsMap[key] = usageByContainer
+        }
+    }
+
+    // Node allocatable is the last-resort basis for containers without requests/limits
+    nodeAllocatable := make(map[string]corev1.ResourceList)
+    nodes, err := c.cache.Nodes.List(labels.Everything())
+    if err != nil {
+        fmt.Printf("Warning: Failed to get node allocatable: %v\n", err)
+    } else {
+        for _, node := range nodes {
+            nodeAllocatable[node.Name] = node.Status.Allocatable
         }
     }
 
     var metrics []PodMetrics
     
-    for _, pod := range pods.Items {
+    for _, pod := range pods {
         // Skip system pods
         if strings.Contains(pod.Namespace, "kube-") || 
            strings.Contains(pod.Namespace, "healer-") {
@@ -103,26 +141,32 @@ func (c *Collector) GetAllPodMetrics(ctx context.Context) ([]PodMetrics, error)
             Restarts:  restarts,
             Age:       age,
             NodeName:  pod.Spec.NodeName,
+            Labels:    pod.Labels,
             CPUUsage:  "0m",
             MemUsage:  "0Mi",
             CPUPercent: 0.0,
             MemPercent: 0.0,
+            CPUBasis:  BasisUnknown,
+            MemBasis:  BasisUnknown,
         }
 
+        usageByContainer, hasMetrics := metricsMap[podKey]
+        allocatable := nodeAllocatable[pod.Spec.NodeName]
+        podMetric.Containers = c.buildContainerMetrics(pod, usageByContainer, allocatable)
+
         // Get actual metrics if available
-        if containerMetrics, exists := metricsMap[podKey]; exists {
-            if cpu, hasCPU := containerMetrics["cpu"]; hasCPU {
-                podMetric.CPUUsage = cpu.String()
-                // Convert to percentage (simplified)
-                cpuMilli := float64(cpu.MilliValue())
-                podMetric.CPUPercent = cpuMilli / 10.0 // Rough percentage
-            }
-            if memory, hasMem := containerMetrics["memory"]; hasMem {
-                podMetric.MemUsage = memory.String()
-                // Convert to percentage (simplified - assumes 1Gi limit)
-                memBytes := float64(memory.Value())
-                podMetric.MemPercent = (memBytes / (1024 * 1024 * 1024)) * 100
-            }
+        if hasMetrics {
+            cpu := podUsage(pod, usageByContainer, corev1.ResourceCPU, allocatable)
+            podMetric.CPUUsage = cpu.Usage.String()
+            podMetric.CPUCapacity = cpu.Capacity.String()
+            podMetric.CPUBasis = cpu.Basis
+            podMetric.CPUPercent = cpu.Percent()
+            
+            memory := podUsage(pod, usageByContainer, corev1.ResourceMemory, allocatable)
+            podMetric.MemUsage = memory.Usage.String()
+            podMetric.MemCapacity = memory.Capacity.String()
+            podMetric.MemBasis = memory.Basis
+            podMetric.MemPercent = memory.Percent()
         }
 
         metrics = append(metrics, podMetric)
@@ -131,8 +175,47 @@ func (c *Collector) GetAllPodMetrics(ctx context.Context) ([]PodMetrics, error)
     return metrics, nil
 }
 
+func (c *Collector) buildContainerMetrics(pod *corev1.Pod, usage map[string]corev1.ResourceList, allocatable corev1.ResourceList) []ContainerMetrics {
+    statuses := make(map[string]corev1.ContainerStatus)
+    for _, cs := range pod.Status.ContainerStatuses {
+        statuses[cs.Name] = cs
+    }
+
+    var containers []ContainerMetrics
+    for _, container := range pod.Spec.Containers {
+        cpu := containerUsage(container, usage[container.Name], corev1.ResourceCPU, allocatable)
+        memory := containerUsage(container, usage[container.Name], corev1.ResourceMemory, allocatable)
+
+        cm := ContainerMetrics{
+            Name:       container.Name,
+            CPUUsage:   cpu.Usage.String(),
+            MemUsage:   memory.Usage.String(),
+            CPUPercent: cpu.Percent(),
+            MemPercent: memory.Percent(),
+            CPURequest: quantityString(container.Resources.Requests, corev1.ResourceCPU),
+            CPULimit:   quantityString(container.Resources.Limits, corev1.ResourceCPU),
+            MemRequest: quantityString(container.Resources.Requests, corev1.ResourceMemory),
+            MemLimit:   quantityString(container.Resources.Limits, corev1.ResourceMemory),
+            CPUBasis:   cpu.Basis,
+            MemBasis:   memory.Basis,
+        }
+
+        if status, ok := statuses[container.Name]; ok {
+            cm.Restarts = status.RestartCount
+            cm.Ready = status.Ready
+            if status.LastTerminationState.Terminated != nil {
+                cm.LastTermination = status.LastTerminationState.Terminated.Reason
+            }
+        }
+
+        containers = append(containers, cm)
+    }
+
+    return containers
+}
+
 func (c *Collector) GetNodeMetrics(ctx context.Context) ([]NodeMetrics, error) {
-    nodes, err := c.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+    nodes, err := c.cache.Nodes.List(labels.Everything())
     if err != nil {
         return nil, fmt.Errorf("failed to get nodes: %v", err)
     }
@@ -158,7 +241,7 @@ func (c *Collector) GetNodeMetrics(ctx context.Context) ([]NodeMetrics, error) {
     }
 
     var nodeMetrics []NodeMetrics
-    for _, node := range nodes.Items {
+    for _, node := range nodes {
         metric := NodeMetrics{
             Name:       node.Name,
             CPUUsage:   "0m",
@@ -189,11 +272,9 @@ func (c *Collector) GetNodeMetrics(ctx context.Context) ([]NodeMetrics, error) {
         }
 
         // Count pods on this node
-        pods, err := c.clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{
-            FieldSelector: fmt.Sprintf("spec.nodeName=%s", node.Name),
-        })
+        pods, err := c.cache.PodsOnNode(node.Name)
         if err == nil {
-            metric.PodCount = len(pods.Items)
+            metric.PodCount = len(pods)
         }
 
         nodeMetrics = append(nodeMetrics, metric)
@@ -202,14 +283,8 @@ func (c *Collector) GetNodeMetrics(ctx context.Context) ([]NodeMetrics, error) {
     return nodeMetrics, nil
 }
 
-func (c *Collector) PrintStatus() {
+func (c *Collector) PrintStatus(metrics []PodMetrics) {
     ctx := context.TODO()
-    
-    metrics, err := c.GetAllPodMetrics(ctx)
-    if err != nil {
-        fmt.Printf("Failed to get pod metrics: %v\n", err)
-        return
-    }
 
     nodeMetrics, err := c.GetNodeMetrics(ctx)
     if err != nil {
@@ -235,22 +310,38 @@ func (c *Collector) PrintStatus() {
     // Print pod status
     fmt.Printf("🚀 PODS:\n")
     for _, metric := range metrics {
+        profile := c.thresholds.ProfileFor(metric.Namespace, metric.Labels)
         status := "✅ HEALTHY"
-        if metric.Restarts > 5 {
+        if metric.Restarts > profile.RestartCritical {
             status = "⚠️  HIGH RESTARTS"
         }
         if metric.Status != "Running" {
             status = "❌ NOT RUNNING"
         }
-        if metric.CPUPercent > 80 || metric.MemPercent > 80 {
+        if metric.CPUPercent > profile.CPUCritical || metric.MemPercent > profile.MemCritical {
             status = "🔥 HIGH RESOURCE USAGE"
         }
 
         fmt.Printf("  Pod: %s/%s - %s\n", metric.Namespace, metric.Name, status)
-        fmt.Printf("    Resources: CPU: %.1f%% (%s), Memory: %.1f%% (%s)\n", 
-            metric.CPUPercent, metric.CPUUsage, metric.MemPercent, metric.MemUsage)
-        fmt.Printf("    Restarts: %d, Age: %v, Node: %s\n\n", 
+        if profile.Name != "default" {
+            fmt.Printf("    Threshold profile: %s\n", profile.Name)
+        }
+        fmt.Printf("    Resources: CPU: %.1f%% (%s of %s %s), Memory: %.1f%% (%s of %s %s)\n", 
+            metric.CPUPercent, metric.CPUUsage, metric.CPUCapacity, metric.CPUBasis,
+            metric.MemPercent, metric.MemUsage, metric.MemCapacity, metric.MemBasis)
+        fmt.Printf("    Restarts: %d, Age: %v, Node: %s\n", 
             metric.Restarts, metric.Age.Round(time.Second), metric.NodeName)
+        if len(metric.Containers) > 1 {
+            for _, container := range metric.Containers {
+                ready := "ready"
+                if !container.Ready {
+                    ready = "not ready"
+                }
+                fmt.Printf("      - %s: CPU %.1f%%, Memory %.1f%%, Restarts: %d, %s\n",
+                    container.Name, container.CPUPercent, container.MemPercent, container.Restarts, ready)
+            }
+        }
+        fmt.Printf("\n")
     }
     fmt.Printf("====================================\n\n")
 }
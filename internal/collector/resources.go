@@ -0,0 +1,108 @@
+package collector
+
+import (
+    corev1 "k8s.io/api/core/v1"
+    "k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Basis describes what a usage percentage was computed against.
+const (
+    BasisLimits   = "limits"
+    BasisRequests = "requests"
+    BasisNode     = "node-allocatable"
+    BasisUnknown  = "unknown"
+)
+
+type resourceUsage struct {
+    Usage    resource.Quantity
+    Capacity resource.Quantity
+    Basis    string
+}
+
+func (r resourceUsage) Percent() float64 {
+    if r.Capacity.IsZero() {
+        return 0
+    }
+    return r.Usage.AsApproximateFloat64() / r.Capacity.AsApproximateFloat64() * 100
+}
+
+// containerCapacity returns the container's limit for the resource, falling
+// back to its request and then to the node's allocatable amount.
+func containerCapacity(container corev1.Container, name corev1.ResourceName, allocatable corev1.ResourceList) (resource.Quantity, string) {
+    if limit, ok := container.Resources.Limits[name]; ok && !limit.IsZero() {
+        return limit, BasisLimits
+    }
+    if request, ok := container.Resources.Requests[name]; ok && !request.IsZero() {
+        return request, BasisRequests
+    }
+    if nodeAmount, ok := allocatable[name]; ok && !nodeAmount.IsZero() {
+        return nodeAmount, BasisNode
+    }
+    return resource.Quantity{}, BasisUnknown
+}
+
+func newResourceUsage(name corev1.ResourceName) resourceUsage {
+    result := resourceUsage{
+        Usage:    *resource.NewQuantity(0, resource.DecimalSI),
+        Capacity: *resource.NewQuantity(0, resource.DecimalSI),
+        Basis:    BasisLimits,
+    }
+    if name == corev1.ResourceMemory {
+        result.Usage.Format = resource.BinarySI
+        result.Capacity.Format = resource.BinarySI
+    }
+    return result
+}
+
+func containerUsage(container corev1.Container, usage corev1.ResourceList, name corev1.ResourceName, allocatable corev1.ResourceList) resourceUsage {
+    result := newResourceUsage(name)
+    if amount, ok := usage[name]; ok {
+        result.Usage.Add(amount)
+    }
+
+    capacity, basis := containerCapacity(container, name, allocatable)
+    result.Capacity.Add(capacity)
+    result.Basis = basis
+
+    return result
+}
+
+// podUsage sums usage and capacity over all containers of the pod. The
+// reported basis is the weakest one used by any container.
+func podUsage(pod *corev1.Pod, usage map[string]corev1.ResourceList, name corev1.ResourceName, allocatable corev1.ResourceList) resourceUsage {
+    result := newResourceUsage(name)
+
+    for _, container := range pod.Spec.Containers {
+        containerResult := containerUsage(container, usage[container.Name], name, allocatable)
+        result.Usage.Add(containerResult.Usage)
+        result.Capacity.Add(containerResult.Capacity)
+        result.Basis = weakerBasis(result.Basis, containerResult.Basis)
+    }
+
+    // Several unbounded containers can't together use more than the node has
+    if nodeAmount, ok := allocatable[name]; ok && result.Basis == BasisNode && result.Capacity.Cmp(nodeAmount) > 0 {
+        result.Capacity = nodeAmount.DeepCopy()
+    }
+
+    return result
+}
+
+func quantityString(list corev1.ResourceList, name corev1.ResourceName) string {
+    if amount, ok := list[name]; ok {
+        return amount.String()
+    }
+    return "none"
+}
+
+func weakerBasis(a, b string) string {
+    rank := map[string]int{
+        BasisLimits:   0,
+        BasisRequests: 1,
+        BasisNode:     2,
+        BasisUnknown:  3,
+    }
+    if rank[b] > rank[a] {
+        return b
+    }
+    return a
+}
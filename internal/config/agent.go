@@ -0,0 +1,123 @@
+package config
+
+import (
+    "flag"
+    "fmt"
+    "os"
+    "strings"
+    "time"
+)
+
+// AgentConfig holds the settings of `healer agent`, the node-level diagnostic
+// agent run as a DaemonSet. Host paths point at the node's filesystems as
+// mounted into the agent pod.
+// Precedence (lowest to highest): defaults, environment, CLI flags.
+type AgentConfig struct {
+    HealerURL  string
+    Token      string
+    NodeName   string
+    Interval   time.Duration
+    HostProc   string
+    HostCgroup string
+    KubeletDir string
+    RuntimeDir string
+}
+
+func DefaultAgent() *AgentConfig {
+    return &AgentConfig{
+        HealerURL:  "http://k8s-healer.healer-system:8080",
+        Interval:   15 * time.Second,
+        HostProc:   "/host/proc",
+        HostCgroup: "/host/sys/fs/cgroup",
+        KubeletDir: "/host/var/lib/kubelet",
+        RuntimeDir: "/host/var/lib/containerd",
+    }
+}
+
+// LoadAgent builds the agent configuration from defaults, HEALER_*
+// environment variables and command line flags.
+func LoadAgent(args []string) (*AgentConfig, error) {
+    cfg := DefaultAgent()
+
+    fs := flag.NewFlagSet("healer agent", flag.ContinueOnError)
+    healerURL := fs.String("healer-url", "", "base URL of the healer API (env: HEALER_URL)")
+    nodeName := fs.String("node-name", "", "name of the node the agent runs on (env: HEALER_NODE_NAME)")
+    interval := fs.String("interval", "", "sampling interval, seconds or duration (env: HEALER_AGENT_INTERVAL)")
+    if err := fs.Parse(args); err != nil {
+        return nil, err
+    }
+
+    setFlags := make(map[string]bool)
+    fs.Visit(func(f *flag.Flag) {
+        setFlags[f.Name] = true
+    })
+
+    // 1. Environment
+    envStrings := []struct {
+        env   string
+        value *string
+    }{
+        {"HEALER_URL", &cfg.HealerURL},
+        {"HEALER_AGENT_TOKEN", &cfg.Token},
+        {"HEALER_NODE_NAME", &cfg.NodeName},
+        {"HEALER_HOST_PROC", &cfg.HostProc},
+        {"HEALER_HOST_CGROUP", &cfg.HostCgroup},
+        {"HEALER_KUBELET_DIR", &cfg.KubeletDir},
+        {"HEALER_RUNTIME_DIR", &cfg.RuntimeDir},
+    }
+    for _, es := range envStrings {
+        if v := os.Getenv(es.env); v != "" {
+            *es.value = v
+        }
+    }
+    if v := os.Getenv("HEALER_AGENT_INTERVAL"); v != "" {
+        d, err := parseInterval(v)
+        if err != nil {
+            return nil, fmt.Errorf("invalid HEALER_AGENT_INTERVAL %q: %v", v, err)
+        }
+        cfg.Interval = d
+    }
+
+    // 2. Flags
+    if setFlags["healer-url"] {
+        cfg.HealerURL = *healerURL
+    }
+    if setFlags["node-name"] {
+        cfg.NodeName = *nodeName
+    }
+    if setFlags["interval"] {
+        d, err := parseInterval(*interval)
+        if err != nil {
+            return nil, fmt.Errorf("invalid -interval: %v", err)
+        }
+        cfg.Interval = d
+    }
+
+    if cfg.NodeName == "" {
+        if hostname, err := os.Hostname(); err == nil {
+            cfg.NodeName = hostname
+        }
+    }
+
+    if err := cfg.Validate(); err != nil {
+        return nil, err
+    }
+    return cfg, nil
+}
+
+func (c *AgentConfig) Validate() error {
+    if !strings.HasPrefix(c.HealerURL, "http://") && !strings.HasPrefix(c.HealerURL, "https://") {
+        return fmt.Errorf("invalid healer URL %q: must start with http:// or https://", c.HealerURL)
+    }
+    c.HealerURL = strings.TrimSuffix(c.HealerURL, "/")
+    if c.Token == "" {
+        return fmt.Errorf("HEALER_AGENT_TOKEN must be set: the healer refuses reports without it")
+    }
+    if c.NodeName == "" {
+        return fmt.Errorf("node name must be set")
+    }
+    if c.Interval < 5*time.Second {
+        return fmt.Errorf("agent interval %v too short: minimum is 5s", c.Interval)
+    }
+    return nil
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+    "testing"
+)
+
+func TestLoadAgent(t *testing.T) {
+    tests := []struct {
+        name    string
+        env     map[string]string
+        args    []string
+        wantErr bool
+    }{
+        {name: "token and node name", env: map[string]string{"HEALER_AGENT_TOKEN": "secret", "HEALER_NODE_NAME": "node-1"}},
+        {name: "missing token", env: map[string]string{"HEALER_NODE_NAME": "node-1"}, wantErr: true},
+        {name: "invalid healer URL", env: map[string]string{"HEALER_AGENT_TOKEN": "secret"}, args: []string{"-healer-url", "healer:8080"}, wantErr: true},
+        {name: "interval too short", env: map[string]string{"HEALER_AGENT_TOKEN": "secret"}, args: []string{"-interval", "1s"}, wantErr: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            for _, name := range []string{"HEALER_URL", "HEALER_AGENT_TOKEN", "HEALER_NODE_NAME", "HEALER_AGENT_INTERVAL"} {
+                t.Setenv(name, "")
+            }
+            for name, value := range tt.env {
+                t.Setenv(name, value)
+            }
+
+            cfg, err := LoadAgent(tt.args)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("LoadAgent() error = %v, wantErr %v", err, tt.wantErr)
+            }
+            if err == nil && cfg.Token != tt.env["HEALER_AGENT_TOKEN"] {
+                t.Errorf("Token = %q, want %q", cfg.Token, tt.env["HEALER_AGENT_TOKEN"])
+            }
+        })
+    }
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+    "fmt"
+    "time"
+)
+
+// BudgetConfig limits how many cluster-changing healing actions may run
+// within a sliding Window, per pod, per workload, per namespace and across
+// the cluster. A limit of 0 disables that level. Cooldown is the minimum time
+// between two runs of the same remediation on the same target; Cooldowns
+// overrides it per remediation name (e.g. "cleanup-tmp").
+type BudgetConfig struct {
+    Window       time.Duration
+    PerPod       int
+    PerWorkload  int
+    PerNamespace int
+    Cluster      int
+    Cooldown     time.Duration
+    Cooldowns    map[string]time.Duration
+}
+
+type fileBudgets struct {
+    Window       string            `json:"window"`
+    PerPod       *int              `json:"perPod"`
+    PerWorkload  *int              `json:"perWorkload"`
+    PerNamespace *int              `json:"perNamespace"`
+    Cluster      *int              `json:"cluster"`
+    Cooldown     string            `json:"cooldown"`
+    Cooldowns    map[string]string `json:"cooldowns"`
+}
+
+func DefaultBudgets() BudgetConfig {
+    return BudgetConfig{
+        Window:       time.Hour,
+        PerPod:       3,
+        PerWorkload:  10,
+        PerNamespace: 20,
+        Cluster:      50,
+        Cooldown:     5 * time.Minute,
+        Cooldowns: map[string]time.Duration{
+            "cleanup-tmp":  30 * time.Minute,
+            "cleanup-disk": 30 * time.Minute,
+        },
+    }
+}
+
+// CooldownFor returns the cooldown of a remediation.
+func (b *BudgetConfig) CooldownFor(remediation string) time.Duration {
+    if cooldown, ok := b.Cooldowns[remediation]; ok {
+        return cooldown
+    }
+    return b.Cooldown
+}
+
+func (b *BudgetConfig) apply(fb *fileBudgets) error {
+    if fb.Window != "" {
+        window, err := parseInterval(fb.Window)
+        if err != nil {
+            return fmt.Errorf("invalid window: %v", err)
+        }
+        b.Window = window
+    }
+    if fb.PerPod != nil {
+        b.PerPod = *fb.PerPod
+    }
+    if fb.PerWorkload != nil {
+        b.PerWorkload = *fb.PerWorkload
+    }
+    if fb.PerNamespace != nil {
+        b.PerNamespace = *fb.PerNamespace
+    }
+    if fb.Cluster != nil {
+        b.Cluster = *fb.Cluster
+    }
+    if fb.Cooldown != "" {
+        cooldown, err := parseInterval(fb.Cooldown)
+        if err != nil {
+            return fmt.Errorf("invalid cooldown: %v", err)
+        }
+        b.Cooldown = cooldown
+    }
+    for name, value := range fb.Cooldowns {
+        cooldown, err := parseInterval(value)
+        if err != nil {
+            return fmt.Errorf("invalid cooldown for %q: %v", name, err)
+        }
+        b.Cooldowns[name] = cooldown
+    }
+    return nil
+}
+
+func (b *BudgetConfig) Validate() error {
+    if b.Window < time.Minute {
+        return fmt.Errorf("action budget window %v too short: minimum is 1m", b.Window)
+    }
+    if b.PerPod < 0 || b.PerWorkload < 0 || b.PerNamespace < 0 || b.Cluster < 0 {
+        return fmt.Errorf("action budget limits must not be negative")
+    }
+    if b.Cooldown < 0 {
+        return fmt.Errorf("action cooldown must not be negative")
+    }
+    for name, cooldown := range b.Cooldowns {
+        if cooldown < 0 {
+            return fmt.Errorf("action cooldown for %q must not be negative", name)
+        }
+    }
+    return nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+    "fmt"
+    "time"
+)
+
+// CheckPoolConfig bounds the exec round trips of a diagnostics sweep. Workers
+// containers are checked at once, at most PerNode on one node and at most
+// PerNamespace in one namespace (0 disables that cap). Containers not checked
+// within SweepTimeout are left for the next sweep, and a single exec command
+// is given up after ExecTimeout.
+type CheckPoolConfig struct {
+    Workers      int
+    PerNode      int
+    PerNamespace int
+    SweepTimeout time.Duration
+    ExecTimeout  time.Duration
+}
+
+type fileCheckPool struct {
+    Workers      *int   `json:"workers"`
+    PerNode      *int   `json:"perNode"`
+    PerNamespace *int   `json:"perNamespace"`
+    SweepTimeout string `json:"sweepTimeout"`
+    ExecTimeout  string `json:"execTimeout"`
+}
+
+func DefaultCheckPool() CheckPoolConfig {
+    return CheckPoolConfig{
+        Workers:      10,
+        PerNode:      4,
+        PerNamespace: 5,
+        SweepTimeout: 25 * time.Second,
+        ExecTimeout:  10 * time.Second,
+    }
+}
+
+func (p *CheckPoolConfig) apply(fp *fileCheckPool) error {
+    if fp.Workers != nil {
+        p.Workers = *fp.Workers
+    }
+    if fp.PerNode != nil {
+        p.PerNode = *fp.PerNode
+    }
+    if fp.PerNamespace != nil {
+        p.PerNamespace = *fp.PerNamespace
+    }
+    if fp.SweepTimeout != "" {
+        timeout, err := parseInterval(fp.SweepTimeout)
+        if err != nil {
+            return fmt.Errorf("invalid sweepTimeout: %v", err)
+        }
+        p.SweepTimeout = timeout
+    }
+    if fp.ExecTimeout != "" {
+        timeout, err := parseInterval(fp.ExecTimeout)
+        if err != nil {
+            return fmt.Errorf("invalid execTimeout: %v", err)
+        }
+        p.ExecTimeout = timeout
+    }
+    return nil
+}
+
+func (p *CheckPoolConfig) Validate() error {
+    if p.Workers < 1 {
+        return fmt.Errorf("check workers must be at least 1, got %d", p.Workers)
+    }
+    if p.PerNode < 0 || p.PerNamespace < 0 {
+        return fmt.Errorf("per-node and per-namespace check limits must not be negative")
+    }
+    if p.SweepTimeout < time.Second {
+        return fmt.Errorf("check sweep timeout %v too short: minimum is 1s", p.SweepTimeout)
+    }
+    if p.ExecTimeout < time.Second {
+        return fmt.Errorf("exec timeout %v too short: minimum is 1s", p.ExecTimeout)
+    }
+    return nil
+}
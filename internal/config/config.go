@@ -0,0 +1,603 @@
+package config
+
+import (
+    "flag"
+    "fmt"
+    "os"
+    "strconv"
+    "strings"
+    "time"
+
+    "k8s.io/apimachinery/pkg/api/resource"
+    "sigs.k8s.io/yaml"
+)
+
+// Config holds the runtime settings of the healer.
+// Precedence (lowest to highest): defaults, YAML file, environment, CLI flags.
+type Config struct {
+    Port                string
+    DryRun              bool
+    LogLevel            string
+    CheckInterval       time.Duration
+    ConfigFile          string
+    EventDriven         bool
+    EventWorkers        int
+    CheckPool           CheckPoolConfig
+    DiagnosticsMode     string
+    DebugImage          string
+    Checks              []CustomCheck
+    AgentToken          string
+    HPAOverrideDuration time.Duration
+    ScaleUpHold         time.Duration
+    ScaleDownWindow     time.Duration
+    RollbackNamespaces  []string
+    MemoryRightsizing   string
+    MemoryHeadroom      int
+    MaxMemoryLimit      resource.Quantity
+    Budgets             BudgetConfig
+    VerifySettle        time.Duration
+    Escalation          map[string][]string
+    Thresholds          ThresholdConfig
+}
+
+// fileConfig mirrors the YAML file layout; durations are kept as strings
+// so both "30" (seconds) and "30s" are accepted.
+type fileConfig struct {
+    Port                string              `json:"port"`
+    DryRun              *bool               `json:"dryRun"`
+    LogLevel            string              `json:"logLevel"`
+    CheckInterval       string              `json:"checkInterval"`
+    EventDriven         *bool               `json:"eventDriven"`
+    EventWorkers        int                 `json:"eventWorkers"`
+    CheckPool           *fileCheckPool      `json:"checkPool"`
+    DiagnosticsMode     string              `json:"diagnosticsMode"`
+    DebugImage          string              `json:"debugImage"`
+    Checks              []fileCustomCheck   `json:"checks"`
+    HPAOverrideDuration string              `json:"hpaOverrideDuration"`
+    ScaleUpHold         string              `json:"scaleUpHold"`
+    ScaleDownWindow     string              `json:"scaleDownWindow"`
+    RollbackNamespaces  []string            `json:"rollbackNamespaces"`
+    MemoryRightsizing   string              `json:"memoryRightsizing"`
+    MemoryHeadroom      int                 `json:"memoryHeadroomPercent"`
+    MaxMemoryLimit      string              `json:"maxMemoryLimit"`
+    Budgets             *fileBudgets        `json:"actionBudgets"`
+    VerifySettle        string              `json:"verifySettle"`
+    Escalation          map[string][]string `json:"escalation"`
+    Thresholds          *fileThresholds     `json:"thresholds"`
+}
+
+var validLogLevels = []string{"debug", "info", "warn", "error"}
+
+// How container checks reach a container: exec into it, run them from an
+// ephemeral debug container, or probe each container and pick one.
+const (
+    DiagnosticsAuto  = "auto"
+    DiagnosticsExec  = "exec"
+    DiagnosticsDebug = "debug"
+)
+
+// Memory right-sizing modes for OOMKilled containers.
+const (
+    RightsizingOff       = "off"
+    RightsizingRecommend = "recommend"
+    RightsizingApply     = "apply"
+)
+
+func Default() *Config {
+    return &Config{
+        Port:                "8080",
+        DryRun:              false,
+        LogLevel:            "info",
+        CheckInterval:       30 * time.Second,
+        EventDriven:         false,
+        EventWorkers:        2,
+        CheckPool:           DefaultCheckPool(),
+        DiagnosticsMode:     DiagnosticsExec,
+        DebugImage:          "busybox:1.36",
+        HPAOverrideDuration: 30 * time.Minute,
+        ScaleUpHold:         15 * time.Minute,
+        ScaleDownWindow:     10 * time.Minute,
+        MemoryRightsizing:   RightsizingRecommend,
+        MemoryHeadroom:      25,
+        MaxMemoryLimit:      resource.MustParse("4Gi"),
+        Budgets:             DefaultBudgets(),
+        VerifySettle:        time.Minute,
+        Escalation:          DefaultEscalation(),
+        Thresholds:          DefaultThresholds(),
+    }
+}
+
+// DefaultEscalation is the ladder of actions tried, one per settle period,
+// while a container check keeps failing after its remediation.
+func DefaultEscalation() map[string][]string {
+    return map[string][]string{
+        "CLEANUP_TMP":  {"RESTART_CONTAINER", "RESTART_POD", "NOTIFY"},
+        "CLEANUP_DISK": {"RESTART_CONTAINER", "RESTART_POD", "NOTIFY"},
+        "FIX_DNS":      {"RESTART_CONTAINER", "RESTART_POD", "NOTIFY"},
+        "FIX_NETWORK":  {"NOTIFY"},
+    }
+}
+
+// Load builds the configuration from defaults, an optional YAML file,
+// HEALER_* environment variables and command line flags.
+func Load(args []string) (*Config, error) {
+    cfg := Default()
+
+    fs := flag.NewFlagSet("healer", flag.ContinueOnError)
+    configFile := fs.String("config", "", "path to YAML config file (env: HEALER_CONFIG)")
+    port := fs.String("port", "", "API server port (env: HEALER_PORT)")
+    dryRun := fs.Bool("dry-run", false, "log actions without changing the cluster (env: HEALER_DRY_RUN)")
+    logLevel := fs.String("log-level", "", "log level: debug, info, warn, error (env: HEALER_LOG_LEVEL)")
+    checkInterval := fs.String("check-interval", "", "check interval, seconds or duration (env: HEALER_CHECK_INTERVAL)")
+    eventDriven := fs.Bool("event-driven", false, "diagnose pods as soon as their status changes (env: HEALER_EVENT_DRIVEN)")
+    eventWorkers := fs.Int("event-workers", 0, "number of event-driven diagnostic workers (env: HEALER_EVENT_WORKERS)")
+    checkWorkers := fs.Int("check-workers", 0, "number of containers checked in parallel per sweep (env: HEALER_CHECK_WORKERS)")
+    if err := fs.Parse(args); err != nil {
+        return nil, err
+    }
+
+    setFlags := make(map[string]bool)
+    fs.Visit(func(f *flag.Flag) {
+        setFlags[f.Name] = true
+    })
+
+    // 1. YAML file
+    cfg.ConfigFile = os.Getenv("HEALER_CONFIG")
+    if setFlags["config"] {
+        cfg.ConfigFile = *configFile
+    }
+    if cfg.ConfigFile != "" {
+        if err := cfg.loadFile(cfg.ConfigFile); err != nil {
+            return nil, err
+        }
+    }
+
+    // 2. Environment
+    if err := cfg.loadEnv(); err != nil {
+        return nil, err
+    }
+
+    // 3. Flags
+    if setFlags["port"] {
+        cfg.Port = *port
+    }
+    if setFlags["dry-run"] {
+        cfg.DryRun = *dryRun
+    }
+    if setFlags["log-level"] {
+        cfg.LogLevel = *logLevel
+    }
+    if setFlags["check-interval"] {
+        interval, err := parseInterval(*checkInterval)
+        if err != nil {
+            return nil, fmt.Errorf("invalid -check-interval: %v", err)
+        }
+        cfg.CheckInterval = interval
+    }
+    if setFlags["event-driven"] {
+        cfg.EventDriven = *eventDriven
+    }
+    if setFlags["event-workers"] {
+        cfg.EventWorkers = *eventWorkers
+    }
+    if setFlags["check-workers"] {
+        cfg.CheckPool.Workers = *checkWorkers
+    }
+
+    if err := cfg.Validate(); err != nil {
+        return nil, err
+    }
+
+    return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return fmt.Errorf("failed to read config file %s: %v", path, err)
+    }
+
+    var fc fileConfig
+    if err := yaml.UnmarshalStrict(data, &fc); err != nil {
+        return fmt.Errorf("failed to parse config file %s: %v", path, err)
+    }
+
+    if fc.Port != "" {
+        c.Port = fc.Port
+    }
+    if fc.DryRun != nil {
+        c.DryRun = *fc.DryRun
+    }
+    if fc.LogLevel != "" {
+        c.LogLevel = fc.LogLevel
+    }
+    if fc.CheckInterval != "" {
+        interval, err := parseInterval(fc.CheckInterval)
+        if err != nil {
+            return fmt.Errorf("invalid checkInterval in %s: %v", path, err)
+        }
+        c.CheckInterval = interval
+    }
+    if fc.EventDriven != nil {
+        c.EventDriven = *fc.EventDriven
+    }
+    if fc.EventWorkers != 0 {
+        c.EventWorkers = fc.EventWorkers
+    }
+    if fc.CheckPool != nil {
+        if err := c.CheckPool.apply(fc.CheckPool); err != nil {
+            return fmt.Errorf("invalid checkPool in %s: %v", path, err)
+        }
+    }
+    if fc.DiagnosticsMode != "" {
+        c.DiagnosticsMode = fc.DiagnosticsMode
+    }
+    if fc.DebugImage != "" {
+        c.DebugImage = fc.DebugImage
+    }
+    for i := range fc.Checks {
+        check, err := fc.Checks[i].toCheck()
+        if err != nil {
+            return fmt.Errorf("invalid check %q in %s: %v", fc.Checks[i].Name, path, err)
+        }
+        c.Checks = append(c.Checks, check)
+    }
+    if fc.HPAOverrideDuration != "" {
+        duration, err := parseInterval(fc.HPAOverrideDuration)
+        if err != nil {
+            return fmt.Errorf("invalid hpaOverrideDuration in %s: %v", path, err)
+        }
+        c.HPAOverrideDuration = duration
+    }
+    if fc.ScaleUpHold != "" {
+        duration, err := parseInterval(fc.ScaleUpHold)
+        if err != nil {
+            return fmt.Errorf("invalid scaleUpHold in %s: %v", path, err)
+        }
+        c.ScaleUpHold = duration
+    }
+    if fc.ScaleDownWindow != "" {
+        duration, err := parseInterval(fc.ScaleDownWindow)
+        if err != nil {
+            return fmt.Errorf("invalid scaleDownWindow in %s: %v", path, err)
+        }
+        c.ScaleDownWindow = duration
+    }
+    if fc.RollbackNamespaces != nil {
+        c.RollbackNamespaces = fc.RollbackNamespaces
+    }
+    if fc.MemoryRightsizing != "" {
+        c.MemoryRightsizing = fc.MemoryRightsizing
+    }
+    if fc.MemoryHeadroom != 0 {
+        c.MemoryHeadroom = fc.MemoryHeadroom
+    }
+    if fc.MaxMemoryLimit != "" {
+        limit, err := resource.ParseQuantity(fc.MaxMemoryLimit)
+        if err != nil {
+            return fmt.Errorf("invalid maxMemoryLimit in %s: %v", path, err)
+        }
+        c.MaxMemoryLimit = limit
+    }
+    if fc.Budgets != nil {
+        if err := c.Budgets.apply(fc.Budgets); err != nil {
+            return fmt.Errorf("invalid actionBudgets in %s: %v", path, err)
+        }
+    }
+    if fc.VerifySettle != "" {
+        settle, err := parseInterval(fc.VerifySettle)
+        if err != nil {
+            return fmt.Errorf("invalid verifySettle in %s: %v", path, err)
+        }
+        c.VerifySettle = settle
+    }
+    // Ladders listed in the file replace the default ladder of that action;
+    // an empty list disables escalation for it
+    for action, ladder := range fc.Escalation {
+        c.Escalation[action] = ladder
+    }
+    if fc.Thresholds != nil {
+        if err := c.Thresholds.apply(fc.Thresholds); err != nil {
+            return fmt.Errorf("invalid thresholds in %s: %v", path, err)
+        }
+    }
+
+    return nil
+}
+
+func (c *Config) loadEnv() error {
+    if v := os.Getenv("HEALER_PORT"); v != "" {
+        c.Port = v
+    }
+
+    // DRY_RUN is still honoured for older deployment manifests
+    dryRunEnv := os.Getenv("HEALER_DRY_RUN")
+    if dryRunEnv == "" {
+        dryRunEnv = os.Getenv("DRY_RUN")
+    }
+    if dryRunEnv != "" {
+        v, err := strconv.ParseBool(dryRunEnv)
+        if err != nil {
+            return fmt.Errorf("invalid HEALER_DRY_RUN %q: %v", dryRunEnv, err)
+        }
+        c.DryRun = v
+    }
+
+    if v := os.Getenv("HEALER_LOG_LEVEL"); v != "" {
+        c.LogLevel = v
+    }
+
+    if v := os.Getenv("HEALER_CHECK_INTERVAL"); v != "" {
+        interval, err := parseInterval(v)
+        if err != nil {
+            return fmt.Errorf("invalid HEALER_CHECK_INTERVAL %q: %v", v, err)
+        }
+        c.CheckInterval = interval
+    }
+
+    if v := os.Getenv("HEALER_EVENT_DRIVEN"); v != "" {
+        enabled, err := strconv.ParseBool(v)
+        if err != nil {
+            return fmt.Errorf("invalid HEALER_EVENT_DRIVEN %q: %v", v, err)
+        }
+        c.EventDriven = enabled
+    }
+
+    if v := os.Getenv("HEALER_EVENT_WORKERS"); v != "" {
+        workers, err := strconv.Atoi(v)
+        if err != nil {
+            return fmt.Errorf("invalid HEALER_EVENT_WORKERS %q: %v", v, err)
+        }
+        c.EventWorkers = workers
+    }
+
+    checkLimits := []struct {
+        env   string
+        limit *int
+    }{
+        {"HEALER_CHECK_WORKERS", &c.CheckPool.Workers},
+        {"HEALER_CHECK_PER_NODE", &c.CheckPool.PerNode},
+        {"HEALER_CHECK_PER_NAMESPACE", &c.CheckPool.PerNamespace},
+    }
+    for _, cl := range checkLimits {
+        if v := os.Getenv(cl.env); v != "" {
+            limit, err := strconv.Atoi(v)
+            if err != nil {
+                return fmt.Errorf("invalid %s %q: %v", cl.env, v, err)
+            }
+            *cl.limit = limit
+        }
+    }
+
+    if v := os.Getenv("HEALER_CHECK_SWEEP_TIMEOUT"); v != "" {
+        timeout, err := parseInterval(v)
+        if err != nil {
+            return fmt.Errorf("invalid HEALER_CHECK_SWEEP_TIMEOUT %q: %v", v, err)
+        }
+        c.CheckPool.SweepTimeout = timeout
+    }
+
+    if v := os.Getenv("HEALER_EXEC_TIMEOUT"); v != "" {
+        timeout, err := parseInterval(v)
+        if err != nil {
+            return fmt.Errorf("invalid HEALER_EXEC_TIMEOUT %q: %v", v, err)
+        }
+        c.CheckPool.ExecTimeout = timeout
+    }
+
+    if v := os.Getenv("HEALER_DIAGNOSTICS_MODE"); v != "" {
+        c.DiagnosticsMode = v
+    }
+
+    if v := os.Getenv("HEALER_DEBUG_IMAGE"); v != "" {
+        c.DebugImage = v
+    }
+
+    // Only read from the environment, so it can come from a Secret
+    c.AgentToken = os.Getenv("HEALER_AGENT_TOKEN")
+
+    if v := os.Getenv("HEALER_HPA_OVERRIDE_DURATION"); v != "" {
+        duration, err := parseInterval(v)
+        if err != nil {
+            return fmt.Errorf("invalid HEALER_HPA_OVERRIDE_DURATION %q: %v", v, err)
+        }
+        c.HPAOverrideDuration = duration
+    }
+
+    if v := os.Getenv("HEALER_SCALE_UP_HOLD"); v != "" {
+        duration, err := parseInterval(v)
+        if err != nil {
+            return fmt.Errorf("invalid HEALER_SCALE_UP_HOLD %q: %v", v, err)
+        }
+        c.ScaleUpHold = duration
+    }
+
+    if v := os.Getenv("HEALER_SCALE_DOWN_WINDOW"); v != "" {
+        duration, err := parseInterval(v)
+        if err != nil {
+            return fmt.Errorf("invalid HEALER_SCALE_DOWN_WINDOW %q: %v", v, err)
+        }
+        c.ScaleDownWindow = duration
+    }
+
+    if v, ok := os.LookupEnv("HEALER_ROLLBACK_NAMESPACES"); ok {
+        c.RollbackNamespaces = nil
+        for _, ns := range strings.Split(v, ",") {
+            if ns = strings.TrimSpace(ns); ns != "" {
+                c.RollbackNamespaces = append(c.RollbackNamespaces, ns)
+            }
+        }
+    }
+
+    if v := os.Getenv("HEALER_MEMORY_RIGHTSIZING"); v != "" {
+        c.MemoryRightsizing = v
+    }
+
+    if v := os.Getenv("HEALER_MEMORY_HEADROOM_PERCENT"); v != "" {
+        headroom, err := strconv.Atoi(v)
+        if err != nil {
+            return fmt.Errorf("invalid HEALER_MEMORY_HEADROOM_PERCENT %q: %v", v, err)
+        }
+        c.MemoryHeadroom = headroom
+    }
+
+    if v := os.Getenv("HEALER_MAX_MEMORY_LIMIT"); v != "" {
+        limit, err := resource.ParseQuantity(v)
+        if err != nil {
+            return fmt.Errorf("invalid HEALER_MAX_MEMORY_LIMIT %q: %v", v, err)
+        }
+        c.MaxMemoryLimit = limit
+    }
+
+    if v := os.Getenv("HEALER_VERIFY_SETTLE"); v != "" {
+        settle, err := parseInterval(v)
+        if err != nil {
+            return fmt.Errorf("invalid HEALER_VERIFY_SETTLE %q: %v", v, err)
+        }
+        c.VerifySettle = settle
+    }
+
+    if v := os.Getenv("HEALER_BUDGET_WINDOW"); v != "" {
+        window, err := parseInterval(v)
+        if err != nil {
+            return fmt.Errorf("invalid HEALER_BUDGET_WINDOW %q: %v", v, err)
+        }
+        c.Budgets.Window = window
+    }
+
+    budgetLimits := []struct {
+        env   string
+        limit *int
+    }{
+        {"HEALER_BUDGET_PER_POD", &c.Budgets.PerPod},
+        {"HEALER_BUDGET_PER_WORKLOAD", &c.Budgets.PerWorkload},
+        {"HEALER_BUDGET_PER_NAMESPACE", &c.Budgets.PerNamespace},
+        {"HEALER_BUDGET_CLUSTER", &c.Budgets.Cluster},
+    }
+    for _, bl := range budgetLimits {
+        if v := os.Getenv(bl.env); v != "" {
+            limit, err := strconv.Atoi(v)
+            if err != nil {
+                return fmt.Errorf("invalid %s %q: %v", bl.env, v, err)
+            }
+            *bl.limit = limit
+        }
+    }
+
+    if v := os.Getenv("HEALER_ACTION_COOLDOWN"); v != "" {
+        cooldown, err := parseInterval(v)
+        if err != nil {
+            return fmt.Errorf("invalid HEALER_ACTION_COOLDOWN %q: %v", v, err)
+        }
+        c.Budgets.Cooldown = cooldown
+    }
+
+    return nil
+}
+
+func (c *Config) Validate() error {
+    port, err := strconv.Atoi(c.Port)
+    if err != nil || port < 1 || port > 65535 {
+        return fmt.Errorf("invalid port %q: must be 1-65535", c.Port)
+    }
+
+    c.LogLevel = strings.ToLower(c.LogLevel)
+    validLevel := false
+    for _, level := range validLogLevels {
+        if c.LogLevel == level {
+            validLevel = true
+            break
+        }
+    }
+    if !validLevel {
+        return fmt.Errorf("invalid log level %q: must be one of %v", c.LogLevel, validLogLevels)
+    }
+
+    if c.CheckInterval < 5*time.Second {
+        return fmt.Errorf("check interval %v too short: minimum is 5s", c.CheckInterval)
+    }
+
+    if c.EventWorkers < 1 {
+        return fmt.Errorf("event workers must be at least 1, got %d", c.EventWorkers)
+    }
+
+    if err := c.CheckPool.Validate(); err != nil {
+        return err
+    }
+
+    c.DiagnosticsMode = strings.ToLower(c.DiagnosticsMode)
+    switch c.DiagnosticsMode {
+    case DiagnosticsAuto, DiagnosticsExec, DiagnosticsDebug:
+    default:
+        return fmt.Errorf("invalid diagnostics mode %q: must be auto, exec or debug", c.DiagnosticsMode)
+    }
+
+    if c.DiagnosticsMode != DiagnosticsExec && strings.TrimSpace(c.DebugImage) == "" {
+        return fmt.Errorf("debug image must be set in %s diagnostics mode", c.DiagnosticsMode)
+    }
+
+    checkNames := make(map[string]bool)
+    for i := range c.Checks {
+        if err := c.Checks[i].Validate(c.CheckPool.ExecTimeout); err != nil {
+            return err
+        }
+        if checkNames[c.Checks[i].Name] {
+            return fmt.Errorf("duplicate check name %q", c.Checks[i].Name)
+        }
+        checkNames[c.Checks[i].Name] = true
+    }
+
+    if c.HPAOverrideDuration < time.Minute {
+        return fmt.Errorf("HPA override duration %v too short: minimum is 1m", c.HPAOverrideDuration)
+    }
+
+    if c.ScaleUpHold < 0 || c.ScaleDownWindow < 0 {
+        return fmt.Errorf("scale-up hold and scale-down window must not be negative")
+    }
+
+    c.MemoryRightsizing = strings.ToLower(c.MemoryRightsizing)
+    switch c.MemoryRightsizing {
+    case RightsizingOff, RightsizingRecommend, RightsizingApply:
+    default:
+        return fmt.Errorf("invalid memory right-sizing mode %q: must be off, recommend or apply", c.MemoryRightsizing)
+    }
+
+    if c.MemoryHeadroom < 0 || c.MemoryHeadroom > 400 {
+        return fmt.Errorf("memory headroom %d%% out of range: must be 0-400", c.MemoryHeadroom)
+    }
+
+    if c.MaxMemoryLimit.Sign() <= 0 {
+        return fmt.Errorf("max memory limit must be positive, got %s", c.MaxMemoryLimit.String())
+    }
+
+    if err := c.Budgets.Validate(); err != nil {
+        return err
+    }
+
+    if c.VerifySettle < 5*time.Second {
+        return fmt.Errorf("verify settle period %v too short: minimum is 5s", c.VerifySettle)
+    }
+
+    for action, ladder := range c.Escalation {
+        for _, step := range ladder {
+            if strings.TrimSpace(step) == "" {
+                return fmt.Errorf("escalation ladder for %s contains an empty action", action)
+            }
+        }
+    }
+
+    if err := c.Thresholds.Validate(); err != nil {
+        return err
+    }
+
+    return nil
+}
+
+// parseInterval accepts plain seconds ("30") or a Go duration ("30s", "1m").
+func parseInterval(value string) (time.Duration, error) {
+    value = strings.TrimSpace(value)
+    if seconds, err := strconv.Atoi(value); err == nil {
+        return time.Duration(seconds) * time.Second, nil
+    }
+    return time.ParseDuration(value)
+}
@@ -0,0 +1,182 @@
+package config
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+// clearEnv unsets the variables these tests set, so the environment of the
+// test run does not leak in.
+func clearEnv(t *testing.T) {
+    for _, name := range []string{"HEALER_CONFIG", "HEALER_PORT", "HEALER_DRY_RUN", "DRY_RUN", "HEALER_LOG_LEVEL", "HEALER_CHECK_INTERVAL"} {
+        t.Setenv(name, "")
+    }
+}
+
+func writeConfig(t *testing.T, content string) string {
+    t.Helper()
+
+    path := filepath.Join(t.TempDir(), "healer.yaml")
+    if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+        t.Fatal(err)
+    }
+    return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+    file := "port: \"9000\"\nlogLevel: warn\ncheckInterval: 1m\ndryRun: true\n"
+
+    tests := []struct {
+        name         string
+        file         string
+        env          map[string]string
+        args         []string
+        wantPort     string
+        wantLogLevel string
+        wantInterval time.Duration
+        wantDryRun   bool
+    }{
+        {
+            name:         "defaults",
+            wantPort:     "8080",
+            wantLogLevel: "info",
+            wantInterval: 30 * time.Second,
+        },
+        {
+            name:         "file over defaults",
+            file:         file,
+            wantPort:     "9000",
+            wantLogLevel: "warn",
+            wantInterval: time.Minute,
+            wantDryRun:   true,
+        },
+        {
+            name:         "env over file",
+            file:         file,
+            env:          map[string]string{"HEALER_PORT": "9100", "HEALER_CHECK_INTERVAL": "45", "HEALER_DRY_RUN": "false"},
+            wantPort:     "9100",
+            wantLogLevel: "warn",
+            wantInterval: 45 * time.Second,
+        },
+        {
+            name:         "legacy DRY_RUN",
+            env:          map[string]string{"DRY_RUN": "true"},
+            wantPort:     "8080",
+            wantLogLevel: "info",
+            wantInterval: 30 * time.Second,
+            wantDryRun:   true,
+        },
+        {
+            name:         "flags over env and file",
+            file:         file,
+            env:          map[string]string{"HEALER_PORT": "9100", "HEALER_LOG_LEVEL": "error"},
+            args:         []string{"-port", "9200", "-check-interval", "2m", "-dry-run=false"},
+            wantPort:     "9200",
+            wantLogLevel: "error",
+            wantInterval: 2 * time.Minute,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            clearEnv(t)
+            if tt.file != "" {
+                t.Setenv("HEALER_CONFIG", writeConfig(t, tt.file))
+            }
+            for name, value := range tt.env {
+                t.Setenv(name, value)
+            }
+
+            cfg, err := Load(tt.args)
+            if err != nil {
+                t.Fatalf("Load() error: %v", err)
+            }
+            if cfg.Port != tt.wantPort {
+                t.Errorf("Port = %q, want %q", cfg.Port, tt.wantPort)
+            }
+            if cfg.LogLevel != tt.wantLogLevel {
+                t.Errorf("LogLevel = %q, want %q", cfg.LogLevel, tt.wantLogLevel)
+            }
+            if cfg.CheckInterval != tt.wantInterval {
+                t.Errorf("CheckInterval = %v, want %v", cfg.CheckInterval, tt.wantInterval)
+            }
+            if cfg.DryRun != tt.wantDryRun {
+                t.Errorf("DryRun = %v, want %v", cfg.DryRun, tt.wantDryRun)
+            }
+        })
+    }
+}
+
+func TestLoadConfigFlagOverridesEnv(t *testing.T) {
+    clearEnv(t)
+    t.Setenv("HEALER_CONFIG", writeConfig(t, "port: \"9000\"\n"))
+    flagFile := writeConfig(t, "port: \"9300\"\n")
+
+    cfg, err := Load([]string{"-config", flagFile})
+    if err != nil {
+        t.Fatalf("Load() error: %v", err)
+    }
+    if cfg.Port != "9300" {
+        t.Errorf("Port = %q, want the -config file's 9300", cfg.Port)
+    }
+}
+
+func TestLoadRejectsInvalidInput(t *testing.T) {
+    tests := []struct {
+        name string
+        file string
+        env  map[string]string
+        args []string
+    }{
+        {name: "unknown file key", file: "prot: \"9000\"\n"},
+        {name: "invalid env interval", env: map[string]string{"HEALER_CHECK_INTERVAL": "soon"}},
+        {name: "invalid env dry run", env: map[string]string{"HEALER_DRY_RUN": "maybe"}},
+        {name: "invalid flag interval", args: []string{"-check-interval", "soon"}},
+        {name: "invalid log level", args: []string{"-log-level", "verbose"}},
+        {name: "unknown flag", args: []string{"-verbose"}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            clearEnv(t)
+            if tt.file != "" {
+                t.Setenv("HEALER_CONFIG", writeConfig(t, tt.file))
+            }
+            for name, value := range tt.env {
+                t.Setenv(name, value)
+            }
+
+            if _, err := Load(tt.args); err == nil {
+                t.Error("Load() accepted invalid input")
+            }
+        })
+    }
+}
+
+func TestParseInterval(t *testing.T) {
+    tests := []struct {
+        value   string
+        want    time.Duration
+        wantErr bool
+    }{
+        {value: "30", want: 30 * time.Second},
+        {value: " 15 ", want: 15 * time.Second},
+        {value: "1m30s", want: 90 * time.Second},
+        {value: "500ms", want: 500 * time.Millisecond},
+        {value: "soon", wantErr: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.value, func(t *testing.T) {
+            got, err := parseInterval(tt.value)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("parseInterval(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+            }
+            if got != tt.want {
+                t.Errorf("parseInterval(%q) = %v, want %v", tt.value, got, tt.want)
+            }
+        })
+    }
+}
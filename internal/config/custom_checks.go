@@ -0,0 +1,184 @@
+package config
+
+import (
+    "fmt"
+    "net"
+    "net/url"
+    "strings"
+    "time"
+
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Kinds of container checks that can be declared in the config file.
+const (
+    CheckExec = "exec"
+    CheckHTTP = "http"
+    CheckTCP  = "tcp"
+)
+
+var validSeverities = []string{"LOW", "MEDIUM", "HIGH"}
+
+// CheckResponse is how urgent a failed check is and what fixes it. FixActions
+// are suggestions for the operator; Remediation is the registered action the
+// AutoHealer runs.
+type CheckResponse struct {
+    Severity    string   `json:"severity"`
+    FixActions  []string `json:"fixActions"`
+    Remediation string   `json:"remediation"`
+}
+
+// CustomCheck is a container check declared in the config file. Exec checks
+// run Command in the container and, like Nagios plugins, pass on exit code 0
+// and report WARNING on 1 and CRITICAL on 2. HTTP and TCP checks fetch URL or
+// connect to Address from inside the container within Timeout and report
+// FailureStatus when they cannot. The check runs on containers matching all
+// of Namespaces, PodSelector and Containers; empty ones match everything.
+type CustomCheck struct {
+    Name          string
+    Type          string
+    Command       string
+    URL           string
+    Address       string
+    Timeout       time.Duration
+    FailureStatus string
+    Namespaces    []string
+    PodSelector   *metav1.LabelSelector
+    Containers    []string
+    Warning       CheckResponse
+    Critical      CheckResponse
+}
+
+type fileCustomCheck struct {
+    Name          string                `json:"name"`
+    Type          string                `json:"type"`
+    Command       string                `json:"command"`
+    URL           string                `json:"url"`
+    Address       string                `json:"address"`
+    Timeout       string                `json:"timeout"`
+    FailureStatus string                `json:"failureStatus"`
+    Namespaces    []string              `json:"namespaces"`
+    PodSelector   *metav1.LabelSelector `json:"podSelector"`
+    Containers    []string              `json:"containers"`
+    Warning       *CheckResponse        `json:"warning"`
+    Critical      *CheckResponse        `json:"critical"`
+}
+
+func defaultCustomCheck() CustomCheck {
+    return CustomCheck{
+        Timeout:       5 * time.Second,
+        FailureStatus: "WARNING",
+        Warning:       CheckResponse{Severity: "MEDIUM"},
+        Critical:      CheckResponse{Severity: "HIGH"},
+    }
+}
+
+func (fc *fileCustomCheck) toCheck() (CustomCheck, error) {
+    check := defaultCustomCheck()
+    check.Name = strings.TrimSpace(fc.Name)
+    check.Type = strings.ToLower(fc.Type)
+    check.Command = fc.Command
+    check.URL = fc.URL
+    check.Address = fc.Address
+    check.Namespaces = fc.Namespaces
+    check.PodSelector = fc.PodSelector
+    check.Containers = fc.Containers
+
+    if fc.Timeout != "" {
+        if check.Type == CheckExec {
+            return check, fmt.Errorf("timeout only applies to http and tcp checks; exec checks use checkPool.execTimeout")
+        }
+        timeout, err := parseInterval(fc.Timeout)
+        if err != nil {
+            return check, fmt.Errorf("invalid timeout: %v", err)
+        }
+        check.Timeout = timeout
+    }
+    if fc.FailureStatus != "" {
+        check.FailureStatus = strings.ToUpper(fc.FailureStatus)
+    }
+    // A response only needs to list what differs from the default
+    if fc.Warning != nil {
+        check.Warning.merge(*fc.Warning)
+    }
+    if fc.Critical != nil {
+        check.Critical.merge(*fc.Critical)
+    }
+    return check, nil
+}
+
+func (r *CheckResponse) merge(other CheckResponse) {
+    if other.Severity != "" {
+        r.Severity = strings.ToUpper(other.Severity)
+    }
+    if other.FixActions != nil {
+        r.FixActions = other.FixActions
+    }
+    if other.Remediation != "" {
+        r.Remediation = other.Remediation
+    }
+}
+
+// Validate checks a declared check on its own; execTimeout bounds the
+// timeout of HTTP and TCP checks, which must give up before the exec does.
+func (c *CustomCheck) Validate(execTimeout time.Duration) error {
+    if c.Name == "" {
+        return fmt.Errorf("check without name")
+    }
+
+    switch c.Type {
+    case CheckExec:
+        if strings.TrimSpace(c.Command) == "" {
+            return fmt.Errorf("check %q: exec checks need a command", c.Name)
+        }
+    case CheckHTTP:
+        u, err := url.Parse(c.URL)
+        if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+            return fmt.Errorf("check %q: url %q must be an absolute http or https URL", c.Name, c.URL)
+        }
+    case CheckTCP:
+        if _, _, err := net.SplitHostPort(c.Address); err != nil {
+            return fmt.Errorf("check %q: address %q must be host:port: %v", c.Name, c.Address, err)
+        }
+    default:
+        return fmt.Errorf("check %q: invalid type %q: must be exec, http or tcp", c.Name, c.Type)
+    }
+
+    if c.Type != CheckExec && (c.Timeout < time.Second || c.Timeout >= execTimeout) {
+        return fmt.Errorf("check %q: timeout %v must be at least 1s and below the exec timeout %v", c.Name, c.Timeout, execTimeout)
+    }
+
+    if c.FailureStatus != "WARNING" && c.FailureStatus != "CRITICAL" {
+        return fmt.Errorf("check %q: invalid failureStatus %q: must be WARNING or CRITICAL", c.Name, c.FailureStatus)
+    }
+
+    if c.PodSelector != nil {
+        if _, err := metav1.LabelSelectorAsSelector(c.PodSelector); err != nil {
+            return fmt.Errorf("check %q: invalid podSelector: %v", c.Name, err)
+        }
+    }
+
+    responses := []struct {
+        status   string
+        response CheckResponse
+    }{
+        {"warning", c.Warning},
+        {"critical", c.Critical},
+    }
+    for _, r := range responses {
+        if !contains(validSeverities, r.response.Severity) {
+            return fmt.Errorf("check %q: invalid %s severity %q: must be one of %v", c.Name, r.status, r.response.Severity, validSeverities)
+        }
+    }
+
+    return nil
+}
+
+func contains(values []string, value string) bool {
+    for _, v := range values {
+        if v == value {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+    "reflect"
+    "testing"
+    "time"
+
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFileCustomCheckToCheck(t *testing.T) {
+    tests := []struct {
+        name    string
+        file    fileCustomCheck
+        want    CustomCheck
+        wantErr bool
+    }{
+        {
+            name: "defaults",
+            file: fileCustomCheck{Name: " queue ", Type: "EXEC", Command: "check_queue"},
+            want: CustomCheck{
+                Name:          "queue",
+                Type:          CheckExec,
+                Command:       "check_queue",
+                Timeout:       5 * time.Second,
+                FailureStatus: "WARNING",
+                Warning:       CheckResponse{Severity: "MEDIUM"},
+                Critical:      CheckResponse{Severity: "HIGH"},
+            },
+        },
+        {
+            name: "overrides merge into the defaults",
+            file: fileCustomCheck{
+                Name:          "health",
+                Type:          "http",
+                URL:           "http://localhost:8080/healthz",
+                Timeout:       "3",
+                FailureStatus: "critical",
+                Critical:      &CheckResponse{Remediation: "RESTART_CONTAINER"},
+                Warning:       &CheckResponse{Severity: "low", FixActions: []string{"CHECK_LOGS"}},
+            },
+            want: CustomCheck{
+                Name:          "health",
+                Type:          CheckHTTP,
+                URL:           "http://localhost:8080/healthz",
+                Timeout:       3 * time.Second,
+                FailureStatus: "CRITICAL",
+                Warning:       CheckResponse{Severity: "LOW", FixActions: []string{"CHECK_LOGS"}},
+                Critical:      CheckResponse{Severity: "HIGH", Remediation: "RESTART_CONTAINER"},
+            },
+        },
+        {name: "timeout on an exec check", file: fileCustomCheck{Name: "queue", Type: "exec", Command: "true", Timeout: "3s"}, wantErr: true},
+        {name: "invalid timeout", file: fileCustomCheck{Name: "db", Type: "tcp", Address: "db:5432", Timeout: "soon"}, wantErr: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := tt.file.toCheck()
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("toCheck() error = %v, wantErr %v", err, tt.wantErr)
+            }
+            if err == nil && !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("toCheck() = %+v, want %+v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestCustomCheckValidate(t *testing.T) {
+    valid := func(update func(c *CustomCheck)) CustomCheck {
+        c := defaultCustomCheck()
+        c.Name = "health"
+        c.Type = CheckHTTP
+        c.URL = "https://localhost:8443/healthz"
+        update(&c)
+        return c
+    }
+
+    tests := []struct {
+        name    string
+        check   CustomCheck
+        wantErr bool
+    }{
+        {name: "valid http", check: valid(func(c *CustomCheck) {})},
+        {name: "valid tcp", check: valid(func(c *CustomCheck) { c.Type, c.Address = CheckTCP, "db:5432" })},
+        {name: "valid exec", check: valid(func(c *CustomCheck) { c.Type, c.Command = CheckExec, "check_queue" })},
+        {name: "no name", check: valid(func(c *CustomCheck) { c.Name = "" }), wantErr: true},
+        {name: "unknown type", check: valid(func(c *CustomCheck) { c.Type = "grpc" }), wantErr: true},
+        {name: "exec without command", check: valid(func(c *CustomCheck) { c.Type, c.Command = CheckExec, " " }), wantErr: true},
+        {name: "relative url", check: valid(func(c *CustomCheck) { c.URL = "/healthz" }), wantErr: true},
+        {name: "non-http url", check: valid(func(c *CustomCheck) { c.URL = "ftp://host/file" }), wantErr: true},
+        {name: "tcp without port", check: valid(func(c *CustomCheck) { c.Type, c.Address = CheckTCP, "db" }), wantErr: true},
+        {name: "timeout below 1s", check: valid(func(c *CustomCheck) { c.Timeout = 500 * time.Millisecond }), wantErr: true},
+        {name: "timeout not below exec timeout", check: valid(func(c *CustomCheck) { c.Timeout = 10 * time.Second }), wantErr: true},
+        {name: "exec ignores timeout", check: valid(func(c *CustomCheck) { c.Type, c.Command, c.Timeout = CheckExec, "true", 0 })},
+        {name: "invalid failure status", check: valid(func(c *CustomCheck) { c.FailureStatus = "UNKNOWN" }), wantErr: true},
+        {name: "invalid severity", check: valid(func(c *CustomCheck) { c.Critical.Severity = "URGENT" }), wantErr: true},
+        {
+            name: "invalid pod selector",
+            check: valid(func(c *CustomCheck) {
+                c.PodSelector = &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "app", Operator: "Near"}}}
+            }),
+            wantErr: true,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            err := tt.check.Validate(10 * time.Second)
+            if (err != nil) != tt.wantErr {
+                t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+            }
+        })
+    }
+}
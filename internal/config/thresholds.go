@@ -0,0 +1,149 @@
+package config
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+)
+
+const DefaultProfileLabel = "healer.io/threshold-profile"
+
+// ThresholdProfile groups the limits used to judge a pod's health.
+// Percentages are 0-100, slopes are percentage points per hour.
+type ThresholdProfile struct {
+    Name                 string  `json:"-"`
+    CPUWarning           float64 `json:"cpuWarning"`
+    CPUCritical          float64 `json:"cpuCritical"`
+    MemWarning           float64 `json:"memWarning"`
+    MemCritical          float64 `json:"memCritical"`
+    CPUSlopeLimit        float64 `json:"cpuSlopeLimit"`
+    MemSlopeLimit        float64 `json:"memSlopeLimit"`
+    RestartWarning       int32   `json:"restartWarning"`
+    RestartCritical      int32   `json:"restartCritical"`
+    ForecastHorizonHours float64 `json:"forecastHorizonHours"`
+}
+
+// ThresholdConfig selects a profile for a pod: the pod label wins over
+// the namespace mapping, which wins over the default profile.
+type ThresholdConfig struct {
+    Default      ThresholdProfile
+    Profiles     map[string]ThresholdProfile
+    Namespaces   map[string]string
+    ProfileLabel string
+}
+
+type fileThresholds struct {
+    Default      json.RawMessage            `json:"default"`
+    Profiles     map[string]json.RawMessage `json:"profiles"`
+    Namespaces   map[string]string          `json:"namespaces"`
+    ProfileLabel string                     `json:"profileLabel"`
+}
+
+func DefaultThresholdProfile() ThresholdProfile {
+    return ThresholdProfile{
+        Name:                 "default",
+        CPUWarning:           10,
+        CPUCritical:          15,
+        MemWarning:           10,
+        MemCritical:          15,
+        CPUSlopeLimit:        2,
+        MemSlopeLimit:        1,
+        RestartWarning:       3,
+        RestartCritical:      5,
+        ForecastHorizonHours: 72,
+    }
+}
+
+func DefaultThresholds() ThresholdConfig {
+    return ThresholdConfig{
+        Default:      DefaultThresholdProfile(),
+        Profiles:     make(map[string]ThresholdProfile),
+        Namespaces:   make(map[string]string),
+        ProfileLabel: DefaultProfileLabel,
+    }
+}
+
+func (t *ThresholdConfig) ProfileFor(namespace string, labels map[string]string) ThresholdProfile {
+    if name, ok := labels[t.ProfileLabel]; ok && t.ProfileLabel != "" {
+        if profile, exists := t.Profiles[name]; exists {
+            return profile
+        }
+    }
+    if name, ok := t.Namespaces[namespace]; ok {
+        if profile, exists := t.Profiles[name]; exists {
+            return profile
+        }
+    }
+    return t.Default
+}
+
+// apply merges thresholds from the config file; named profiles start from
+// the (possibly overridden) default so they only need to list what differs.
+func (t *ThresholdConfig) apply(ft *fileThresholds) error {
+    if len(ft.Default) > 0 {
+        if err := decodeStrict(ft.Default, &t.Default); err != nil {
+            return fmt.Errorf("invalid default threshold profile: %v", err)
+        }
+    }
+
+    for name, raw := range ft.Profiles {
+        profile := t.Default
+        if err := decodeStrict(raw, &profile); err != nil {
+            return fmt.Errorf("invalid threshold profile %q: %v", name, err)
+        }
+        profile.Name = name
+        t.Profiles[name] = profile
+    }
+
+    for namespace, name := range ft.Namespaces {
+        t.Namespaces[namespace] = name
+    }
+
+    if ft.ProfileLabel != "" {
+        t.ProfileLabel = ft.ProfileLabel
+    }
+
+    return nil
+}
+
+func (t *ThresholdConfig) Validate() error {
+    if err := t.Default.Validate(); err != nil {
+        return err
+    }
+    for _, profile := range t.Profiles {
+        if err := profile.Validate(); err != nil {
+            return err
+        }
+    }
+    for namespace, name := range t.Namespaces {
+        if _, exists := t.Profiles[name]; !exists {
+            return fmt.Errorf("namespace %q references unknown threshold profile %q", namespace, name)
+        }
+    }
+    return nil
+}
+
+func (p ThresholdProfile) Validate() error {
+    if p.CPUWarning <= 0 || p.CPUWarning >= p.CPUCritical {
+        return fmt.Errorf("threshold profile %q: cpuWarning must be > 0 and below cpuCritical", p.Name)
+    }
+    if p.MemWarning <= 0 || p.MemWarning >= p.MemCritical {
+        return fmt.Errorf("threshold profile %q: memWarning must be > 0 and below memCritical", p.Name)
+    }
+    if p.CPUSlopeLimit <= 0 || p.MemSlopeLimit <= 0 {
+        return fmt.Errorf("threshold profile %q: slope limits must be > 0", p.Name)
+    }
+    if p.RestartWarning <= 0 || p.RestartWarning > p.RestartCritical {
+        return fmt.Errorf("threshold profile %q: restartWarning must be > 0 and not above restartCritical", p.Name)
+    }
+    if p.ForecastHorizonHours <= 0 {
+        return fmt.Errorf("threshold profile %q: forecastHorizonHours must be > 0", p.Name)
+    }
+    return nil
+}
+
+func decodeStrict(data []byte, out interface{}) error {
+    decoder := json.NewDecoder(bytes.NewReader(data))
+    decoder.DisallowUnknownFields()
+    return decoder.Decode(out)
+}
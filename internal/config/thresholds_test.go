@@ -0,0 +1,82 @@
+package config
+
+import (
+    "encoding/json"
+    "testing"
+)
+
+func TestProfileFor(t *testing.T) {
+    thresholds := DefaultThresholds()
+    thresholds.Profiles["batch"] = ThresholdProfile{Name: "batch", CPUCritical: 95}
+    thresholds.Profiles["latency"] = ThresholdProfile{Name: "latency", CPUCritical: 50}
+    thresholds.Namespaces["jobs"] = "batch"
+    thresholds.Namespaces["legacy"] = "missing"
+
+    tests := []struct {
+        name      string
+        cfg       ThresholdConfig
+        namespace string
+        labels    map[string]string
+        want      string
+    }{
+        {name: "default", cfg: thresholds, namespace: "shop", want: "default"},
+        {name: "namespace mapping", cfg: thresholds, namespace: "jobs", want: "batch"},
+        {name: "label over namespace", cfg: thresholds, namespace: "jobs", labels: map[string]string{DefaultProfileLabel: "latency"}, want: "latency"},
+        {name: "unknown label profile falls back to namespace", cfg: thresholds, namespace: "jobs", labels: map[string]string{DefaultProfileLabel: "missing"}, want: "batch"},
+        {name: "unknown namespace profile falls back to default", cfg: thresholds, namespace: "legacy", want: "default"},
+        {name: "other labels are ignored", cfg: thresholds, namespace: "shop", labels: map[string]string{"app": "latency"}, want: "default"},
+        {
+            name: "label lookup disabled",
+            cfg: func() ThresholdConfig {
+                c := thresholds
+                c.ProfileLabel = ""
+                return c
+            }(),
+            namespace: "jobs",
+            labels:    map[string]string{"": "latency"},
+            want:      "batch",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := tt.cfg.ProfileFor(tt.namespace, tt.labels); got.Name != tt.want {
+                t.Errorf("ProfileFor() = %q, want %q", got.Name, tt.want)
+            }
+        })
+    }
+}
+
+func TestThresholdsApply(t *testing.T) {
+    thresholds := DefaultThresholds()
+    err := thresholds.apply(&fileThresholds{
+        Default:  json.RawMessage(`{"cpuCritical": 20}`),
+        Profiles: map[string]json.RawMessage{"batch": json.RawMessage(`{"memCritical": 90}`)},
+    })
+    if err != nil {
+        t.Fatalf("apply() error: %v", err)
+    }
+
+    batch := thresholds.Profiles["batch"]
+    tests := []struct {
+        name string
+        got  float64
+        want float64
+    }{
+        {name: "default override", got: thresholds.Default.CPUCritical, want: 20},
+        {name: "profile inherits overridden default", got: batch.CPUCritical, want: 20},
+        {name: "profile override", got: batch.MemCritical, want: 90},
+        {name: "profile inherits builtin default", got: batch.MemWarning, want: DefaultThresholdProfile().MemWarning},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if tt.got != tt.want {
+                t.Errorf("got %v, want %v", tt.got, tt.want)
+            }
+        })
+    }
+
+    if err := thresholds.apply(&fileThresholds{Default: json.RawMessage(`{"cpuCritcal": 20}`)}); err == nil {
+        t.Error("apply() accepted an unknown threshold field")
+    }
+}
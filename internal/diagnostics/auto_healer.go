@@ -2,36 +2,115 @@ package diagnostics
 
 import (
     "context"
+    "errors"
     "fmt"
     "strings"
+    "sync"
     "time"
     
-    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s-healer/internal/config"
+    "k8s-healer/internal/eviction"
+    "k8s-healer/internal/policy"
+    "k8s-healer/internal/remediation"
 )
 
+// HealingAction states. An action starts PENDING and either never runs
+// (SKIPPED, DRY_RUN, or FAILED when it could not be planned) or goes through
+// EXECUTING to SUCCEEDED, PARTIAL or FAILED.
+const (
+    StatusPending   = "PENDING"
+    StatusExecuting = "EXECUTING"
+    StatusSucceeded = "SUCCEEDED"
+    StatusPartial   = "PARTIAL"
+    StatusFailed    = "FAILED"
+    StatusSkipped   = "SKIPPED"
+    StatusDryRun    = "DRY_RUN"
+)
+
+var healingTransitions = map[string][]string{
+    StatusPending:   {StatusExecuting, StatusSkipped, StatusDryRun, StatusFailed},
+    StatusExecuting: {StatusSucceeded, StatusPartial, StatusFailed, StatusSkipped},
+}
+
+// Outcome is the raw remediation result (e.g. BLOCKED_BY_PDB or
+// RATE_LIMITED) behind Status. Verification is PENDING while the settle
+// period runs, then VERIFIED or UNRESOLVED once the originating check has
+// been re-run.
 type HealingAction struct {
+    ID            int64
     ActionType    string
     PodName       string
     Namespace     string
     ContainerName string
+    Check         string
     Description   string
     Status        string
+    Outcome       string
     Timestamp     time.Time
+    Finished      time.Time
     Result        string
+    Error         string
+    Steps         []remediation.StepResult
+    Verification  string
+    VerifyDetails string
+    EscalatedFrom string
+}
+
+func (a *HealingAction) transition(to string) error {
+    for _, allowed := range healingTransitions[a.Status] {
+        if allowed == to {
+            a.Status = to
+            return nil
+        }
+    }
+    return fmt.Errorf("invalid healing action transition %s -> %s", a.Status, to)
+}
+
+// healingStatus maps a remediation result status to the state it ends in.
+func healingStatus(resultStatus string) string {
+    switch resultStatus {
+    case remediation.StatusCompleted:
+        return StatusSucceeded
+    case remediation.StatusPartial, remediation.StatusBlockedByPDB:
+        return StatusPartial
+    case remediation.StatusSkipped, remediation.StatusRateLimited:
+        return StatusSkipped
+    case remediation.StatusDryRun:
+        return StatusDryRun
+    }
+    return StatusFailed
 }
 
 type AutoHealer struct {
     diagEngine *DiagnosticsEngine
+    evictor    *eviction.Evictor
+    registry   *remediation.Registry
+    policies   *policy.Store
     history    []HealingAction
     dryRun     bool
+    settle     time.Duration
+    escalation map[string][]string
+    verifying  map[string]bool
+    deferred   map[string][]deferredVerification
+    nextID     int64
+    mu         sync.Mutex
 }
 
-func NewAutoHealer(diagEngine *DiagnosticsEngine, dryRun bool) *AutoHealer {
-    return &AutoHealer{
+func NewAutoHealer(diagEngine *DiagnosticsEngine, evictor *eviction.Evictor, registry *remediation.Registry, policies *policy.Store, cfg *config.Config) *AutoHealer {
+    h := &AutoHealer{
         diagEngine: diagEngine,
+        evictor:    evictor,
+        registry:   registry,
+        policies:   policies,
         history:    make([]HealingAction, 0),
-        dryRun:     dryRun,
+        dryRun:     cfg.DryRun,
+        settle:     cfg.VerifySettle,
+        escalation: cfg.Escalation,
+        verifying:  make(map[string]bool),
+        deferred:   make(map[string][]deferredVerification),
     }
+    h.registerRemediations()
+    return h
 }
 
 func (h *AutoHealer) HealContainerIssues(ctx context.Context, containerChecks []ContainerCheckResult) []HealingAction {
@@ -43,231 +122,318 @@ func (h *AutoHealer) HealContainerIssues(ctx context.Context, containerChecks []
         }
         
         for _, check := range checkResult.Checks {
-            if check.Status == "OK" {
+            if check.Status == "OK" || check.Status == "UNKNOWN" {
+                continue
+            }
+            
+            decision, ok := h.policies.Resolve(policy.Detection{
+                Kind:      policy.KindCheck,
+                Name:      check.CheckName,
+                Status:    check.Status,
+                Suggested: check.Remediation,
+                Namespace: checkResult.Namespace,
+                PodName:   checkResult.PodName,
+                Labels:    h.podLabels(checkResult.Namespace, checkResult.PodName),
+            })
+            if !ok {
                 continue
             }
             
-            // Execute healing actions based on check type
-            switch check.CheckName {
-            case "/tmp Directory":
-                if contains(check.FixActions, "CLEANUP_TMP") {
-                    action := h.cleanupTmpDirectory(ctx, checkResult, check)
-                    actions = append(actions, action)
-                }
-            case "Disk Space":
-                if contains(check.FixActions, "CLEANUP_DISK") {
-                    action := h.cleanupDiskSpace(ctx, checkResult, check)
-                    actions = append(actions, action)
-                }
-            case "Network Connectivity":
-                if contains(check.FixActions, "CHECK_NETWORK") {
-                    action := h.fixNetworkConnectivity(ctx, checkResult, check)
-                    actions = append(actions, action)
-                }
-            case "DNS Resolution":
-                if contains(check.FixActions, "RESTART_DNS") {
-                    action := h.fixDNSResolution(ctx, checkResult, check)
-                    actions = append(actions, action)
-                }
+            key := fmt.Sprintf("%s/%s/%s", checkResult.Namespace, checkResult.PodName, checkResult.ContainerName)
+            if h.isVerifying(verifyKey(checkResult, check.CheckName)) {
+                fmt.Printf("⏳ %s check on %s awaiting verification of an earlier fix\n", check.CheckName, key)
+                continue
             }
+            if allowed, reason := h.policies.Allow(decision, key); !allowed {
+                fmt.Printf("⚠️  Skipping %s for %s - %s\n", decision.Remediation, key, reason)
+                continue
+            }
+            
+            action := h.heal(ctx, checkResult, check, decision)
+            if action.Outcome == remediation.StatusRateLimited {
+                fmt.Printf("⏳ %s for %s held back: %s\n", action.ActionType, key, action.Result)
+                continue
+            }
+            if action.Status != StatusSkipped {
+                h.policies.Record(decision, key)
+            }
+            v := verification{
+                checkResult: checkResult,
+                check:       check,
+                action:      action,
+                ladder:      h.escalation[decision.Remediation],
+            }
+            if action.Verification == VerificationPending {
+                h.startVerification(ctx, v)
+            } else if action.Outcome == remediation.StatusBlockedByPDB {
+                h.deferVerification(ctx, v)
+            }
+            actions = append(actions, action)
         }
     }
     
-    // Store actions in history
-    h.history = append(h.history, actions...)
-    
-    // Keep only last 100 actions
-    if len(h.history) > 100 {
-        h.history = h.history[len(h.history)-100:]
-    }
-    
     return actions
 }
 
-func (h *AutoHealer) cleanupTmpDirectory(ctx context.Context, checkResult ContainerCheckResult, check ContainerCheck) HealingAction {
+func (h *AutoHealer) heal(ctx context.Context, checkResult ContainerCheckResult, check ContainerCheck, decision policy.Decision) HealingAction {
+    // The action is in the history from the start so /status shows fixes
+    // that are still running
     action := HealingAction{
-        ActionType:    "CLEANUP_TMP",
+        ID:            h.newID(),
+        ActionType:    decision.Remediation,
         PodName:       checkResult.PodName,
         Namespace:     checkResult.Namespace,
         ContainerName: checkResult.ContainerName,
-        Description:   "Cleaning up /tmp directory",
-        Status:        "EXECUTING",
+        Check:         check.CheckName,
+        Description:   fmt.Sprintf("%s for failed %s check", decision.Remediation, check.CheckName),
+        Status:        StatusPending,
         Timestamp:     time.Now(),
     }
+    h.appendHistory(action)
     
-    if h.dryRun {
-        action.Status = "DRY_RUN"
-        action.Result = "Would cleanup /tmp directory"
-        return action
+    req := remediation.Request{
+        Action:        decision.Remediation,
+        Namespace:     checkResult.Namespace,
+        PodName:       checkResult.PodName,
+        ContainerName: checkResult.ContainerName,
+        Risk:          check.Severity,
+        Reason:        fmt.Sprintf("%s: %s", check.CheckName, check.Details),
+        DryRun:        h.dryRun || decision.DryRun,
+        Requested:     action.Timestamp,
+        Origin:        checkResult,
+        Started: func() {
+            h.updateAction(action.ID, func(a *HealingAction) error {
+                return a.transition(StatusExecuting)
+            })
+        },
     }
+    // Remediations of other engines, e.g. RESTART_POD, report a deferred
+    // eviction through Done so its verification is resumed as well
+    req.Done = h.deferredEvictionRecorder(req, decision.Remediation, check.CheckName,
+        fmt.Sprintf("Deferred %s for failed %s check", decision.Remediation, check.CheckName))
+    
+    plan, result, err := h.registry.Dispatch(ctx, req)
     
-    // Commands to safely cleanup /tmp
-    cleanupCommands := []string{
-        "find /tmp -type f -atime +1 -delete 2>/dev/null || true",
-        "find /tmp -type f -size +10M -delete 2>/dev/null || true",
-        "find /tmp -name '*.log' -mtime +1 -delete 2>/dev/null || true",
-        "find /tmp -name 'core.*' -delete 2>/dev/null || true",
-        "find /tmp -name '*.tmp' -mtime +1 -delete 2>/dev/null || true",
+    if result.Status == remediation.StatusRateLimited {
+        // Dropped, or a held-back fix would fill the history every cycle
+        h.removeAction(action.ID)
+        action.Outcome = result.Status
+        action.Result = result.Message
+        return action
     }
     
-    var results []string
-    for _, cmd := range cleanupCommands {
-        _, err := h.diagEngine.execInContainer(ctx, checkResult.Namespace, checkResult.PodName, checkResult.ContainerName, cmd)
+    return h.updateAction(action.ID, func(a *HealingAction) error {
+        a.ActionType = result.Action
+        if plan.Description != "" {
+            a.Description = plan.Description
+        }
+        a.Outcome = result.Status
+        a.Result = result.Message
+        a.Steps = result.Steps
+        a.Finished = time.Now()
         if err != nil {
-            results = append(results, fmt.Sprintf("Failed: %s", cmd))
-        } else {
-            results = append(results, "Cleanup executed")
+            a.Error = err.Error()
+        } else if result.Status == remediation.StatusFailed {
+            a.Error = result.Message
         }
-    }
-    
-    action.Status = "COMPLETED"
-    action.Result = strings.Join(results, "; ")
-    
-    return action
+        if result.Status == remediation.StatusDryRun {
+            a.Result = "Would run: " + strings.Join(plan.Steps, "; ")
+        }
+        
+        if err := a.transition(healingStatus(result.Status)); err != nil {
+            a.Status = StatusFailed
+            a.Error = err.Error()
+        }
+        // A pod restart waiting for a PDB is verified once it went through
+        if (a.Status == StatusSucceeded || a.Status == StatusPartial) && result.Status != remediation.StatusBlockedByPDB {
+            a.Verification = VerificationPending
+        }
+        return nil
+    })
 }
 
-func (h *AutoHealer) cleanupDiskSpace(ctx context.Context, checkResult ContainerCheckResult, check ContainerCheck) HealingAction {
-    action := HealingAction{
-        ActionType:    "CLEANUP_DISK",
-        PodName:       checkResult.PodName,
-        Namespace:     checkResult.Namespace,
-        ContainerName: checkResult.ContainerName,
-        Description:   "Cleaning up disk space",
-        Status:        "EXECUTING",
-        Timestamp:     time.Now(),
-    }
+// updateAction changes an action in the history and returns a copy of it.
+func (h *AutoHealer) updateAction(id int64, update func(a *HealingAction) error) HealingAction {
+    h.mu.Lock()
+    defer h.mu.Unlock()
     
-    if h.dryRun {
-        action.Status = "DRY_RUN"
-        action.Result = "Would cleanup disk space"
-        return action
+    for i := len(h.history) - 1; i >= 0; i-- {
+        if h.history[i].ID != id {
+            continue
+        }
+        if err := update(&h.history[i]); err != nil {
+            fmt.Printf("⚠️  Action %d: %v\n", id, err)
+        }
+        return h.history[i]
     }
+    return HealingAction{ID: id}
+}
+
+func (h *AutoHealer) removeAction(id int64) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
     
-    // Safe disk cleanup commands
-    cleanupCommands := []string{
-        "find /var/log -name '*.log' -size +50M -exec truncate -s 10M {} + 2>/dev/null || true",
-        "find /var/log -name '*.log.*' -mtime +7 -delete 2>/dev/null || true",
-        "find / -name '*.core' -delete 2>/dev/null || true",
-        "find /var/tmp -type f -mtime +3 -delete 2>/dev/null || true",
+    for i := len(h.history) - 1; i >= 0; i-- {
+        if h.history[i].ID == id {
+            h.history = append(h.history[:i], h.history[i+1:]...)
+            return
+        }
     }
-    
-    var results []string
-    for _, cmd := range cleanupCommands {
-        _, err := h.diagEngine.execInContainer(ctx, checkResult.Namespace, checkResult.PodName, checkResult.ContainerName, cmd)
-        if err != nil {
-            results = append(results, fmt.Sprintf("Failed: %v", err))
-        } else {
-            results = append(results, "Cleanup executed")
+}
+
+// deferredEvictionRecorder returns the callback of an eviction a
+// PodDisruptionBudget deferred: it records the final outcome as a new action
+// and starts the verification that waited for it.
+func (h *AutoHealer) deferredEvictionRecorder(req remediation.Request, actionType, check, description string) func(err error) {
+    return func(err error) {
+        retried := HealingAction{
+            ID:            h.newID(),
+            ActionType:    actionType,
+            PodName:       req.PodName,
+            Namespace:     req.Namespace,
+            ContainerName: req.ContainerName,
+            Check:         check,
+            Description:   description,
+            Status:        StatusSucceeded,
+            Outcome:       remediation.StatusCompleted,
+            Timestamp:     time.Now(),
+            Finished:      time.Now(),
+            Result:        "Pod evicted after PodDisruptionBudget allowed it",
+        }
+        switch {
+        case errors.Is(err, eviction.ErrPodNotFound):
+            retried.Status = StatusSkipped
+            retried.Outcome = remediation.StatusSkipped
+            retried.Result = "Pod was already gone"
+        case err != nil:
+            retried.Status = StatusFailed
+            retried.Outcome = remediation.StatusFailed
+            retried.Result = "Deferred pod eviction failed"
+            retried.Error = err.Error()
         }
+        h.appendHistory(retried)
+        h.resumeVerification(req, retried)
     }
+}
+
+func (h *AutoHealer) newID() int64 {
+    h.mu.Lock()
+    defer h.mu.Unlock()
     
-    action.Status = "COMPLETED"
-    action.Result = strings.Join(results, "; ")
-    
-    return action
+    h.nextID++
+    return h.nextID
 }
 
-func (h *AutoHealer) fixNetworkConnectivity(ctx context.Context, checkResult ContainerCheckResult, check ContainerCheck) HealingAction {
-    action := HealingAction{
-        ActionType:    "FIX_NETWORK",
-        PodName:       checkResult.PodName,
-        Namespace:     checkResult.Namespace,
-        ContainerName: checkResult.ContainerName,
-        Description:   "Fixing network connectivity",
-        Status:        "EXECUTING",
-        Timestamp:     time.Now(),
+func (h *AutoHealer) podLabels(namespace, name string) map[string]string {
+    pod, err := h.diagEngine.cache.Pods.Pods(namespace).Get(name)
+    if err != nil {
+        return nil
     }
+    return pod.Labels
+}
+
+func (h *AutoHealer) appendHistory(actions ...HealingAction) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
     
-    if h.dryRun {
-        action.Status = "DRY_RUN"
-        action.Result = "Would restart network services and pod if needed"
-        return action
-    }
+    h.history = append(h.history, actions...)
     
-    // First try network fixes
-    networkCommands := []string{
-        "ip route flush cache 2>/dev/null || true",
-        "ping -c 1 kubernetes.default.svc.cluster.local 2>/dev/null && echo 'Network OK' || echo 'Network FAIL'",
+    // Keep only last 100 actions
+    if len(h.history) > 100 {
+        h.history = h.history[len(h.history)-100:]
     }
-    
-    var results []string
-    networkFailed := false
-    
-    for _, cmd := range networkCommands {
-        output, err := h.diagEngine.execInContainer(ctx, checkResult.Namespace, checkResult.PodName, checkResult.ContainerName, cmd)
-        if err != nil {
-            results = append(results, fmt.Sprintf("Command failed: %v", err))
-            networkFailed = true
-        } else {
-            results = append(results, strings.TrimSpace(output))
-            if strings.Contains(output, "Network FAIL") {
-                networkFailed = true
-            }
+}
+
+// runCommands execs each command in the container and records one step per
+// command. A step only fails when the exec itself fails or its output
+// contains failMarker.
+func (h *AutoHealer) runCommands(ctx context.Context, req remediation.Request, commands []string, failMarker string) []remediation.StepResult {
+    steps := make([]remediation.StepResult, 0, len(commands))
+    for _, cmd := range commands {
+        output, err := h.diagEngine.execInContainer(ctx, req.Namespace, req.PodName, req.ContainerName, cmd)
+        step := remediation.StepResult{
+            Name:   cmd,
+            Status: remediation.StepSucceeded,
+            Output: strings.TrimSpace(output),
         }
-    }
-    
-    // If network is still failing, try more aggressive fixes
-    if networkFailed {
-        results = append(results, "Network still failing - attempting pod restart")
-        
-        // Delete the pod to force restart
-        err := h.diagEngine.clientset.CoreV1().Pods(checkResult.Namespace).Delete(ctx, checkResult.PodName, metav1.DeleteOptions{})
         if err != nil {
-            results = append(results, fmt.Sprintf("Pod restart failed: %v", err))
-            action.Status = "FAILED"
-        } else {
-            results = append(results, "Pod restarted successfully")
-            action.ActionType = "RESTART_POD_NETWORK"
-            action.Description = "Restarted pod due to network failure"
+            step.Status = remediation.StepFailed
+            step.Error = err.Error()
+        } else if failMarker != "" && strings.Contains(output, failMarker) {
+            step.Status = remediation.StepFailed
+            step.Error = fmt.Sprintf("output reports %s", failMarker)
         }
+        steps = append(steps, step)
     }
-    
-    action.Status = "COMPLETED"
-    action.Result = strings.Join(results, "; ")
-    
-    return action
+    return steps
 }
 
-func (h *AutoHealer) fixDNSResolution(ctx context.Context, checkResult ContainerCheckResult, check ContainerCheck) HealingAction {
-    action := HealingAction{
-        ActionType:    "FIX_DNS",
-        PodName:       checkResult.PodName,
-        Namespace:     checkResult.Namespace,
-        ContainerName: checkResult.ContainerName,
-        Description:   "Fixing DNS resolution",
-        Status:        "EXECUTING",
-        Timestamp:     time.Now(),
-    }
-    
-    if h.dryRun {
-        action.Status = "DRY_RUN"
-        action.Result = "Would flush DNS cache and restart DNS"
-        return action
-    }
-    
-    // DNS fix commands
-    dnsCommands := []string{
-        "nslookup kubernetes.default.svc.cluster.local 2>/dev/null && echo 'DNS OK' || echo 'DNS FAIL'",
-    }
+func (h *AutoHealer) cleanupTmpDirectory(ctx context.Context, req remediation.Request) (remediation.Result, error) {
+    return remediation.ResultFromSteps(h.runCommands(ctx, req, tmpCleanupCommands, "")), nil
+}
+
+func (h *AutoHealer) cleanupDiskSpace(ctx context.Context, req remediation.Request) (remediation.Result, error) {
+    return remediation.ResultFromSteps(h.runCommands(ctx, req, diskCleanupCommands, "")), nil
+}
+
+func (h *AutoHealer) fixNetworkConnectivity(ctx context.Context, req remediation.Request) (remediation.Result, error) {
+    steps := h.runCommands(ctx, req, networkFixCommands, "Network FAIL")
     
-    var results []string
-    for _, cmd := range dnsCommands {
-        output, err := h.diagEngine.execInContainer(ctx, checkResult.Namespace, checkResult.PodName, checkResult.ContainerName, cmd)
-        if err != nil {
-            results = append(results, fmt.Sprintf("DNS command failed: %v", err))
-        } else {
-            results = append(results, strings.TrimSpace(output))
+    networkFailed := false
+    for _, step := range steps {
+        if step.Status == remediation.StepFailed {
+            networkFailed = true
         }
     }
+    if !networkFailed {
+        result := remediation.ResultFromSteps(steps)
+        result.Message = "Network OK"
+        return result, nil
+    }
     
-    action.Status = "COMPLETED"
-    action.Result = strings.Join(results, "; ")
+    // Network is still failing: evict the pod to force a restart, respecting
+    // PodDisruptionBudgets
+    evict := remediation.StepResult{Name: "evict pod", Status: remediation.StepSucceeded}
+    err := h.evictor.Evict(ctx, req.Namespace, req.PodName, h.deferredEvictionRecorder(req,
+        "RESTART_POD_NETWORK", "Network Connectivity", "Deferred pod restart due to network failure"))
     
-    return action
+    result := remediation.Result{Action: "FIX_NETWORK"}
+    switch {
+    case errors.Is(err, eviction.ErrBlockedByPDB):
+        evict.Status = remediation.StepSkipped
+        evict.Error = err.Error()
+        result.Status = remediation.StatusBlockedByPDB
+        result.Message = "Network still failing; pod restart blocked by PodDisruptionBudget - will retry"
+    case errors.Is(err, eviction.ErrPodNotFound):
+        evict.Status = remediation.StepSkipped
+        evict.Error = err.Error()
+        result.Status = remediation.StatusSkipped
+        result.Message = "Network still failing, but the pod is already gone"
+    case err != nil:
+        evict.Status = remediation.StepFailed
+        evict.Error = err.Error()
+        result.Status = remediation.StatusFailed
+        result.Message = fmt.Sprintf("Network still failing; pod restart failed: %v", err)
+    default:
+        result.Action = "RESTART_POD_NETWORK"
+        result.Status = remediation.StatusCompleted
+        result.Message = "Network still failing; pod restarted"
+    }
+    result.Steps = append(steps, evict)
+    return result, nil
+}
+
+func (h *AutoHealer) fixDNSResolution(ctx context.Context, req remediation.Request) (remediation.Result, error) {
+    return remediation.ResultFromSteps(h.runCommands(ctx, req, dnsFixCommands, "DNS FAIL")), nil
 }
 
 func (h *AutoHealer) GetHealingHistory() []HealingAction {
-    return h.history
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    
+    history := make([]HealingAction, len(h.history))
+    copy(history, h.history)
+    return history
 }
 
 func (h *AutoHealer) PrintHealingActions(actions []HealingAction) {
@@ -278,28 +444,40 @@ func (h *AutoHealer) PrintHealingActions(actions []HealingAction) {
     fmt.Printf("🛠️  === AUTO-HEALING ACTIONS ===\n")
     for _, action := range actions {
         statusIcon := "✅"
-        if action.Status == "DRY_RUN" {
+        switch action.Status {
+        case StatusDryRun:
             statusIcon = "🔄"
-        } else if action.Status == "FAILED" {
+        case StatusFailed:
             statusIcon = "❌"
+        case StatusPartial:
+            statusIcon = "⚠️"
+        case StatusSkipped:
+            statusIcon = "⏭️"
+        case StatusPending, StatusExecuting:
+            statusIcon = "⏳"
         }
         
         fmt.Printf("%s %s: %s/%s/%s\n", 
             statusIcon, action.ActionType, action.Namespace, action.PodName, action.ContainerName)
         fmt.Printf("  📝 %s\n", action.Description)
+        if action.EscalatedFrom != "" {
+            fmt.Printf("  ⬆️  Escalated from %s (%s check)\n", action.EscalatedFrom, action.Check)
+        }
         if action.Result != "" {
-            fmt.Printf("  📊 Result: %s\n", action.Result)
+            fmt.Printf("  📊 Result: %s (%s)\n", action.Result, action.Status)
+        }
+        if action.Error != "" {
+            fmt.Printf("  ❗ Error: %s\n", action.Error)
+        }
+        for _, step := range action.Steps {
+            if step.Status != remediation.StepSucceeded {
+                fmt.Printf("    - %s: %s %s\n", step.Status, step.Name, step.Error)
+            }
+        }
+        if action.Verification == VerificationPending {
+            fmt.Printf("  🔎 Verifying in %v\n", h.settle)
         }
         fmt.Printf("  🕐 %s\n\n", action.Timestamp.Format("15:04:05"))
     }
     fmt.Printf("=================================\n\n")
 }
-
-func contains(slice []string, item string) bool {
-    for _, s := range slice {
-        if s == item {
-            return true
-        }
-    }
-    return false
-}
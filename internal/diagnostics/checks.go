@@ -0,0 +1,172 @@
+package diagnostics
+
+import (
+    "context"
+    "fmt"
+    "sync"
+
+    corev1 "k8s.io/api/core/v1"
+)
+
+// Check is one container health check. Run reports what the check found and
+// Response maps a failed outcome to its severity and fixes, so one check can
+// be healed differently per status or cause.
+type Check interface {
+    Name() string
+    Applies(pod *corev1.Pod, containerName string) bool
+    Run(ctx context.Context, target CheckTarget) CheckOutcome
+    Response(outcome CheckOutcome) CheckResponse
+}
+
+// CheckOutcome is what a check found. Status is OK, WARNING or CRITICAL, or
+// UNKNOWN when the check could not run; Cause tells apart failures with the
+// same status, e.g. internal and external DNS.
+type CheckOutcome struct {
+    Status  string
+    Details string
+    Cause   string
+}
+
+// causeNotRun is the Cause of a check that could not run at all, e.g.
+// because a tool is missing. Its status is UNKNOWN: it neither fails the
+// container nor is healed.
+const causeNotRun = "not run"
+
+// CheckResponse is how urgent a failed check is and what fixes it.
+// FixActions are suggestions for the operator; Remediation is the registered
+// action the AutoHealer runs, if any.
+type CheckResponse struct {
+    Severity    string
+    FixActions  []string
+    Remediation string
+}
+
+// CheckTarget is the container a check runs against. Commands run through
+// the container's check runner, which may be a debug container next to it.
+type CheckTarget struct {
+    Pod           *corev1.Pod
+    ContainerName string
+    runner        commandRunner
+}
+
+// commandRunner runs the commands of the checks for one container;
+// checkRunner is the implementation for real containers.
+type commandRunner interface {
+    exec(ctx context.Context, command string) (string, error)
+    path(p string) string
+}
+
+// Exec runs command through /bin/sh and returns its stdout. Errors are
+// *ExecError.
+func (t CheckTarget) Exec(ctx context.Context, command string) (string, error) {
+    return t.runner.exec(ctx, command)
+}
+
+// Path returns where a path of the container's filesystem is found by the
+// commands of Exec.
+func (t CheckTarget) Path(p string) string {
+    return t.runner.path(p)
+}
+
+// CheckRegistry holds the container checks in the order they run.
+type CheckRegistry struct {
+    mu     sync.RWMutex
+    checks []Check
+}
+
+func NewCheckRegistry() *CheckRegistry {
+    return &CheckRegistry{}
+}
+
+// Register adds a check after the registered ones. Names must be unique, as
+// verification and HealingPolicy rules find checks by name.
+func (r *CheckRegistry) Register(check Check) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    for _, existing := range r.checks {
+        if existing.Name() == check.Name() {
+            return fmt.Errorf("container check %q already registered", check.Name())
+        }
+    }
+    r.checks = append(r.checks, check)
+    return nil
+}
+
+// MustRegister is Register for built-in checks, where a conflict is a
+// programming error.
+func (r *CheckRegistry) MustRegister(check Check) {
+    if err := r.Register(check); err != nil {
+        panic(err)
+    }
+}
+
+func (r *CheckRegistry) Lookup(name string) (Check, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    for _, check := range r.checks {
+        if check.Name() == name {
+            return check, nil
+        }
+    }
+    return nil, fmt.Errorf("unknown container check %q", name)
+}
+
+// For returns the checks that apply to a container, in registration order.
+func (r *CheckRegistry) For(pod *corev1.Pod, containerName string) []Check {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    var checks []Check
+    for _, check := range r.checks {
+        if check.Applies(pod, containerName) {
+            checks = append(checks, check)
+        }
+    }
+    return checks
+}
+
+// Checks returns the registry of container checks, so custom checks can be
+// registered before the main loop starts.
+func (d *DiagnosticsEngine) Checks() *CheckRegistry {
+    return d.checks
+}
+
+func (d *DiagnosticsEngine) checkTarget(ctx context.Context, pod *corev1.Pod, containerName string) CheckTarget {
+    return CheckTarget{
+        Pod:           pod,
+        ContainerName: containerName,
+        runner:        d.runnerFor(ctx, pod.Namespace, pod.Name, containerName),
+    }
+}
+
+// runCheck runs a check and turns its outcome into a ContainerCheck. Passed
+// checks are LOW severity and need no fix.
+func runCheck(ctx context.Context, check Check, target CheckTarget) ContainerCheck {
+    outcome := check.Run(ctx, target)
+
+    response := CheckResponse{Severity: "LOW"}
+    if outcome.Status != "OK" {
+        response = check.Response(outcome)
+    }
+    if response.FixActions == nil {
+        response.FixActions = []string{}
+    }
+
+    return ContainerCheck{
+        CheckName:   check.Name(),
+        Status:      outcome.Status,
+        Details:     outcome.Details,
+        Severity:    response.Severity,
+        FixActions:  response.FixActions,
+        Remediation: response.Remediation,
+    }
+}
+
+// everyContainer is embedded by checks that apply to all containers.
+type everyContainer struct{}
+
+func (everyContainer) Applies(pod *corev1.Pod, containerName string) bool {
+    return true
+}
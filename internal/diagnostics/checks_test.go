@@ -0,0 +1,115 @@
+package diagnostics
+
+import (
+    "context"
+    "reflect"
+    "testing"
+    "time"
+
+    "k8s-healer/internal/config"
+
+    corev1 "k8s.io/api/core/v1"
+)
+
+// staticCheck always reports status and applies to the containers listed,
+// or to every container when none are.
+type staticCheck struct {
+    name       string
+    status     string
+    containers []string
+}
+
+func (c staticCheck) Name() string {
+    return c.name
+}
+
+func (c staticCheck) Applies(pod *corev1.Pod, containerName string) bool {
+    return len(c.containers) == 0 || containsString(c.containers, containerName)
+}
+
+func (c staticCheck) Run(ctx context.Context, target CheckTarget) CheckOutcome {
+    outcome := CheckOutcome{Status: c.status, Details: c.name + " " + c.status}
+    if c.status == "UNKNOWN" {
+        outcome.Cause = causeNotRun
+    }
+    return outcome
+}
+
+func (c staticCheck) Response(outcome CheckOutcome) CheckResponse {
+    if outcome.Cause == causeNotRun {
+        return CheckResponse{Severity: "LOW"}
+    }
+    return CheckResponse{Severity: "HIGH", Remediation: "RESTART_CONTAINER"}
+}
+
+func TestCheckRegistry(t *testing.T) {
+    r := NewCheckRegistry()
+    r.MustRegister(staticCheck{name: "first", status: "OK"})
+    r.MustRegister(staticCheck{name: "sidecar only", status: "OK", containers: []string{"sidecar"}})
+    r.MustRegister(staticCheck{name: "last", status: "OK"})
+
+    if err := r.Register(staticCheck{name: "first", status: "WARNING"}); err == nil {
+        t.Error("Register() accepted a duplicate name")
+    }
+
+    if check, err := r.Lookup("sidecar only"); err != nil || check.Name() != "sidecar only" {
+        t.Errorf("Lookup() = %v, %v", check, err)
+    }
+    if _, err := r.Lookup("missing"); err == nil {
+        t.Error("Lookup() found an unregistered check")
+    }
+
+    tests := []struct {
+        container string
+        want      []string
+    }{
+        {container: "app", want: []string{"first", "last"}},
+        {container: "sidecar", want: []string{"first", "sidecar only", "last"}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.container, func(t *testing.T) {
+            var got []string
+            for _, check := range r.For(&corev1.Pod{}, tt.container) {
+                got = append(got, check.Name())
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("For() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestCheckContainerStatus(t *testing.T) {
+    tests := []struct {
+        name        string
+        statuses    []string
+        wantOverall string
+        wantAction  bool
+    }{
+        {name: "all OK", statuses: []string{"OK", "OK"}, wantOverall: "OK"},
+        {name: "checks that could not run", statuses: []string{"OK", "UNKNOWN", "UNKNOWN"}, wantOverall: "OK"},
+        {name: "warning", statuses: []string{"UNKNOWN", "WARNING"}, wantOverall: "WARNING", wantAction: true},
+        {name: "critical wins", statuses: []string{"CRITICAL", "WARNING", "UNKNOWN"}, wantOverall: "CRITICAL", wantAction: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            d := &DiagnosticsEngine{checks: NewCheckRegistry(), mode: config.DiagnosticsExec}
+            for i, status := range tt.statuses {
+                d.checks.MustRegister(staticCheck{name: string(rune('a' + i)), status: status})
+            }
+
+            pod := testPod("web-0", time.Now())
+            result := d.checkContainer(context.Background(), pod, "app")
+            if result.OverallStatus != tt.wantOverall || result.NeedsAction != tt.wantAction {
+                t.Errorf("checkContainer() = %s (needs action %v), want %s (%v)",
+                    result.OverallStatus, result.NeedsAction, tt.wantOverall, tt.wantAction)
+            }
+            for _, check := range result.Checks {
+                if check.Status == "UNKNOWN" && (check.Severity != "LOW" || check.Remediation != "") {
+                    t.Errorf("check that could not run has severity %s and remediation %q", check.Severity, check.Remediation)
+                }
+            }
+        })
+    }
+}
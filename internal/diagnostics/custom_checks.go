@@ -0,0 +1,183 @@
+package diagnostics
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net"
+    "strings"
+
+    "k8s-healer/internal/config"
+    "k8s-healer/internal/remediation"
+
+    corev1 "k8s.io/api/core/v1"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/labels"
+)
+
+// Longest first line of a command's output kept as check details
+const maxCheckDetails = 200
+
+// customCheck is an exec, HTTP or TCP check declared in the config file.
+// HTTP and TCP checks run wget and nc in the container, so they see the
+// network the way the application does.
+type customCheck struct {
+    cfg      config.CustomCheck
+    selector labels.Selector
+}
+
+// RegisterCustomChecks registers the checks declared in the config file
+// after the built-in ones. Remediations they name must be registered in
+// registry, otherwise the healer refuses to start.
+func (d *DiagnosticsEngine) RegisterCustomChecks(checks []config.CustomCheck, registry *remediation.Registry) error {
+    for _, cfg := range checks {
+        for _, response := range []config.CheckResponse{cfg.Warning, cfg.Critical} {
+            if response.Remediation == "" {
+                continue
+            }
+            if _, err := registry.Lookup(response.Remediation); err != nil {
+                return fmt.Errorf("check %q: %v", cfg.Name, err)
+            }
+        }
+
+        check := &customCheck{cfg: cfg, selector: labels.Everything()}
+        if cfg.PodSelector != nil {
+            selector, err := metav1.LabelSelectorAsSelector(cfg.PodSelector)
+            if err != nil {
+                return fmt.Errorf("check %q: invalid podSelector: %v", cfg.Name, err)
+            }
+            check.selector = selector
+        }
+
+        if err := d.checks.Register(check); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func (c *customCheck) Name() string {
+    return c.cfg.Name
+}
+
+func (c *customCheck) Applies(pod *corev1.Pod, containerName string) bool {
+    if len(c.cfg.Namespaces) > 0 && !containsString(c.cfg.Namespaces, pod.Namespace) {
+        return false
+    }
+    if len(c.cfg.Containers) > 0 && !containsString(c.cfg.Containers, containerName) {
+        return false
+    }
+    return c.selector.Matches(labels.Set(pod.Labels))
+}
+
+func (c *customCheck) Run(ctx context.Context, target CheckTarget) CheckOutcome {
+    seconds := int(c.cfg.Timeout.Seconds())
+
+    switch c.cfg.Type {
+    case config.CheckHTTP:
+        cmd := fmt.Sprintf("wget -q --timeout=%d --tries=1 -O /dev/null %s", seconds, shellQuote(c.cfg.URL))
+        return c.probe(ctx, target, cmd, "GET "+c.cfg.URL)
+    case config.CheckTCP:
+        host, port, _ := net.SplitHostPort(c.cfg.Address)
+        cmd := fmt.Sprintf("nc -z -w %d %s %s", seconds, shellQuote(host), shellQuote(port))
+        return c.probe(ctx, target, cmd, "connect to "+c.cfg.Address)
+    }
+
+    // HEALER_ROOT lets the command find the container's files when it runs
+    // from a debug container
+    cmd := "export HEALER_ROOT=" + shellQuote(target.Path("")) + "\n" + c.cfg.Command
+    output, err := target.Exec(ctx, cmd)
+    details := firstLine(output)
+
+    exitCode := 0
+    if err != nil {
+        var execErr *ExecError
+        if !errors.As(err, &execErr) || execErr.Kind != ExecNonZeroExit {
+            return checkNotRun(err)
+        }
+        exitCode = execErr.ExitCode
+    }
+
+    status := ""
+    switch exitCode {
+    case 0:
+        status = "OK"
+    case 1:
+        status = "WARNING"
+    case 2:
+        status = "CRITICAL"
+    default:
+        return checkNotRun(err)
+    }
+    if details == "" {
+        details = fmt.Sprintf("Command exited with code %d", exitCode)
+    }
+    return CheckOutcome{Status: status, Details: details}
+}
+
+// probe runs a wget or nc command; any exit code but a missing or
+// unusable tool means the target could not be reached.
+func (c *customCheck) probe(ctx context.Context, target CheckTarget, cmd, what string) CheckOutcome {
+    _, err := target.Exec(ctx, cmd)
+    if err == nil {
+        return CheckOutcome{Status: "OK", Details: what + " succeeded"}
+    }
+
+    var execErr *ExecError
+    if errors.As(err, &execErr) && execErr.Kind == ExecNonZeroExit && execErr.ExitCode != 126 && execErr.ExitCode != 127 {
+        return CheckOutcome{Status: c.cfg.FailureStatus, Details: "Could not " + what}
+    }
+    return checkNotRun(err)
+}
+
+func (c *customCheck) Response(outcome CheckOutcome) CheckResponse {
+    switch {
+    case outcome.Cause == causeNotRun:
+        return CheckResponse{Severity: "LOW"}
+    case outcome.Status == "CRITICAL":
+        return CheckResponse(c.cfg.Critical)
+    }
+    return CheckResponse(c.cfg.Warning)
+}
+
+// checkNotRun is the outcome of a check whose command could not run to a
+// meaningful exit, e.g. because the tool is missing from the image.
+func checkNotRun(err error) CheckOutcome {
+    details := fmt.Sprintf("Could not run check: %v", err)
+
+    var execErr *ExecError
+    if errors.As(err, &execErr) {
+        details = fmt.Sprintf("Could not run check (%s)", execErr.Kind)
+        if execErr.Kind == ExecNonZeroExit {
+            details = fmt.Sprintf("Could not run check (exit code %d)", execErr.ExitCode)
+        }
+        if execErr.Stderr != "" {
+            details += ": " + firstLine(execErr.Stderr)
+        }
+    }
+    return CheckOutcome{Status: "UNKNOWN", Details: details, Cause: causeNotRun}
+}
+
+func firstLine(output string) string {
+    line := strings.TrimSpace(output)
+    if i := strings.IndexByte(line, '\n'); i >= 0 {
+        line = strings.TrimSpace(line[:i])
+    }
+    if len(line) > maxCheckDetails {
+        line = line[:maxCheckDetails] + "..."
+    }
+    return line
+}
+
+func shellQuote(s string) string {
+    return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func containsString(values []string, value string) bool {
+    for _, v := range values {
+        if v == value {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,94 @@
+package diagnostics
+
+import (
+    "context"
+    "errors"
+    "testing"
+
+    "k8s-healer/internal/config"
+)
+
+// fakeRunner answers every command with the same output and error.
+type fakeRunner struct {
+    output string
+    err    error
+}
+
+func (r fakeRunner) exec(ctx context.Context, command string) (string, error) {
+    return r.output, r.err
+}
+
+func (r fakeRunner) path(p string) string {
+    return p
+}
+
+func exitCode(code int) error {
+    return &ExecError{Kind: ExecNonZeroExit, Command: "check", ExitCode: code}
+}
+
+func TestCustomCheckRun(t *testing.T) {
+    exec := config.CustomCheck{Name: "queue", Type: config.CheckExec, Command: "check_queue", FailureStatus: "WARNING"}
+    http := config.CustomCheck{Name: "health", Type: config.CheckHTTP, URL: "http://localhost:8080/healthz", FailureStatus: "CRITICAL"}
+    tcp := config.CustomCheck{Name: "db", Type: config.CheckTCP, Address: "db:5432", FailureStatus: "WARNING"}
+
+    tests := []struct {
+        name        string
+        cfg         config.CustomCheck
+        runner      fakeRunner
+        wantStatus  string
+        wantDetails string
+    }{
+        {name: "exec exit 0", cfg: exec, runner: fakeRunner{output: "queue ok\nmore"}, wantStatus: "OK", wantDetails: "queue ok"},
+        {name: "exec exit 1", cfg: exec, runner: fakeRunner{output: "queue slow", err: exitCode(1)}, wantStatus: "WARNING", wantDetails: "queue slow"},
+        {name: "exec exit 2 without output", cfg: exec, runner: fakeRunner{err: exitCode(2)}, wantStatus: "CRITICAL", wantDetails: "Command exited with code 2"},
+        {name: "exec unknown exit code", cfg: exec, runner: fakeRunner{err: exitCode(3)}, wantStatus: "UNKNOWN", wantDetails: "Could not run check (exit code 3)"},
+        {name: "exec command not found", cfg: exec, runner: fakeRunner{err: exitCode(127)}, wantStatus: "UNKNOWN", wantDetails: "Could not run check (exit code 127)"},
+        {name: "exec timeout", cfg: exec, runner: fakeRunner{err: &ExecError{Kind: ExecTimeout}}, wantStatus: "UNKNOWN", wantDetails: "Could not run check (TIMEOUT)"},
+        {name: "exec plain error", cfg: exec, runner: fakeRunner{err: errors.New("boom")}, wantStatus: "UNKNOWN", wantDetails: "Could not run check: boom"},
+        {name: "http reachable", cfg: http, runner: fakeRunner{}, wantStatus: "OK", wantDetails: "GET http://localhost:8080/healthz succeeded"},
+        {name: "http unreachable", cfg: http, runner: fakeRunner{err: exitCode(1)}, wantStatus: "CRITICAL", wantDetails: "Could not GET http://localhost:8080/healthz"},
+        {name: "wget missing", cfg: http, runner: fakeRunner{err: exitCode(127)}, wantStatus: "UNKNOWN", wantDetails: "Could not run check (exit code 127)"},
+        {name: "tcp unreachable", cfg: tcp, runner: fakeRunner{err: exitCode(1)}, wantStatus: "WARNING", wantDetails: "Could not connect to db:5432"},
+        {name: "nc not executable", cfg: tcp, runner: fakeRunner{err: exitCode(126)}, wantStatus: "UNKNOWN", wantDetails: "Could not run check (exit code 126)"},
+        {name: "kubelet unreachable", cfg: tcp, runner: fakeRunner{err: &ExecError{Kind: ExecConnectionRefused, Stderr: "dial failed"}}, wantStatus: "UNKNOWN", wantDetails: "Could not run check (CONNECTION_REFUSED): dial failed"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            check := &customCheck{cfg: tt.cfg}
+            outcome := check.Run(context.Background(), CheckTarget{runner: tt.runner})
+            if outcome.Status != tt.wantStatus || outcome.Details != tt.wantDetails {
+                t.Errorf("Run() = %s %q, want %s %q", outcome.Status, outcome.Details, tt.wantStatus, tt.wantDetails)
+            }
+            if (outcome.Status == "UNKNOWN") != (outcome.Cause == causeNotRun) {
+                t.Errorf("Cause = %q for status %s", outcome.Cause, outcome.Status)
+            }
+        })
+    }
+}
+
+func TestCustomCheckResponse(t *testing.T) {
+    check := &customCheck{cfg: config.CustomCheck{
+        Warning:  config.CheckResponse{Severity: "MEDIUM", Remediation: "CLEANUP_TMP"},
+        Critical: config.CheckResponse{Severity: "HIGH", Remediation: "RESTART_CONTAINER"},
+    }}
+
+    tests := []struct {
+        name    string
+        outcome CheckOutcome
+        want    CheckResponse
+    }{
+        {name: "warning", outcome: CheckOutcome{Status: "WARNING"}, want: CheckResponse{Severity: "MEDIUM", Remediation: "CLEANUP_TMP"}},
+        {name: "critical", outcome: CheckOutcome{Status: "CRITICAL"}, want: CheckResponse{Severity: "HIGH", Remediation: "RESTART_CONTAINER"}},
+        {name: "not run", outcome: CheckOutcome{Status: "UNKNOWN", Cause: causeNotRun}, want: CheckResponse{Severity: "LOW"}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := check.Response(tt.outcome)
+            if got.Severity != tt.want.Severity || got.Remediation != tt.want.Remediation {
+                t.Errorf("Response() = %+v, want %+v", got, tt.want)
+            }
+        })
+    }
+}
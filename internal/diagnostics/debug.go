@@ -0,0 +1,298 @@
+package diagnostics
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "k8s-healer/internal/config"
+
+    corev1 "k8s.io/api/core/v1"
+    apierrors "k8s.io/apimachinery/pkg/api/errors"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+    debugContainerPrefix = "healer-debug-"
+    debugStartTimeout    = 20 * time.Second
+    debugPollInterval    = 2 * time.Second
+
+    // With TargetContainerName set, PID 1 of the debug container's process
+    // namespace is the target's entrypoint, and its filesystem is reachable
+    // through /proc/1/root
+    targetRoot = "/proc/1/root"
+
+    // toolsProbe succeeds when the image has every tool the checks rely on
+    toolsProbe = "for t in nslookup wget df awk sed ps find wc; do command -v $t >/dev/null || exit 1; done"
+
+    // rootProbe fails when the debug container may not read the target's
+    // filesystem, e.g. a root debug container without CAP_SYS_PTRACE next to
+    // a target running as another user
+    rootProbe = "ls " + targetRoot + "/ >/dev/null"
+
+    // Counts the processes sharing the target's mount namespace, leaving out
+    // the debug container's own shell and sleep
+    targetProcessCount = `ns=$(readlink /proc/1/ns/mnt); n=0; for p in /proc/[0-9]*; do [ "$(readlink $p/ns/mnt 2>/dev/null)" = "$ns" ] && n=$((n+1)); done; echo $n`
+)
+
+// errDebugNotReady means the debug container may still come up, so the mode
+// of the container is worked out again on the next check.
+var errDebugNotReady = errors.New("debug container not running yet")
+
+// checkRunner runs the commands of the container checks for one target
+// container, either in the container itself or in a debug container next to
+// it that shares its process and network namespaces.
+type checkRunner struct {
+    engine    *DiagnosticsEngine
+    namespace string
+    podName   string
+    container string // container the commands run in
+    mode      string
+    root      string // where the target's filesystem is visible
+}
+
+func (r checkRunner) exec(ctx context.Context, command string) (string, error) {
+    return r.engine.execInContainer(ctx, r.namespace, r.podName, r.container, command)
+}
+
+// path returns where a path of the target's filesystem is found.
+func (r checkRunner) path(p string) string {
+    return r.root + p
+}
+
+func (r checkRunner) processCountCommand() string {
+    if r.mode == config.DiagnosticsDebug {
+        return targetProcessCount
+    }
+    return "ps aux 2>/dev/null | wc -l || echo 0"
+}
+
+// runnerFor picks how a container is checked. In auto mode the container is
+// probed once for the tools the checks need, and a debug container is only
+// injected when they are missing. Whenever a debug container cannot be used
+// the container is checked through exec.
+func (d *DiagnosticsEngine) runnerFor(ctx context.Context, namespace, podName, containerName string) checkRunner {
+    r := checkRunner{
+        engine:    d,
+        namespace: namespace,
+        podName:   podName,
+        container: containerName,
+        mode:      config.DiagnosticsExec,
+    }
+    if d.mode == config.DiagnosticsExec {
+        return r
+    }
+
+    pod, err := d.cache.Pods.Pods(namespace).Get(podName)
+    if err != nil {
+        return r
+    }
+
+    key := modeKey(pod, containerName)
+    d.mu.Lock()
+    mode, known := d.modes[key]
+    retry := d.retry[key]
+    d.mu.Unlock()
+
+    // A container whose mode could not be worked out, e.g. because its debug
+    // container is still starting, is checked through exec until the next
+    // sweep instead of waiting for it again on every check
+    if !known && !retry {
+        mode, known = d.detectMode(ctx, pod, containerName)
+        d.mu.Lock()
+        if known {
+            d.modes[key] = mode
+        } else {
+            d.retry[key] = true
+        }
+        d.mu.Unlock()
+    }
+
+    if mode == config.DiagnosticsDebug {
+        r.container = debugContainerName(containerName)
+        r.mode = config.DiagnosticsDebug
+        r.root = targetRoot
+    }
+    return r
+}
+
+// detectMode works out how a container can be checked. known is false when
+// the outcome may change on a later try, so it is not remembered.
+func (d *DiagnosticsEngine) detectMode(ctx context.Context, pod *corev1.Pod, containerName string) (mode string, known bool) {
+    if d.mode == config.DiagnosticsAuto {
+        _, err := d.execInContainer(ctx, pod.Namespace, pod.Name, containerName, toolsProbe)
+        switch execErrorKind(err) {
+        case "":
+            return config.DiagnosticsExec, true
+        case ExecTimeout, ExecConnectionRefused, ExecCanceled:
+            return config.DiagnosticsExec, false
+        }
+        // A non-zero exit means tools are missing; other failures usually
+        // mean there is no shell at all
+    }
+
+    err := d.ensureDebugContainer(ctx, pod, containerName)
+    if errors.Is(err, errDebugNotReady) {
+        return config.DiagnosticsExec, false
+    }
+    if err != nil {
+        fmt.Printf("⚠️  Cannot use a debug container for %s/%s/%s, checking through exec: %v\n",
+            pod.Namespace, pod.Name, containerName, err)
+        return config.DiagnosticsExec, true
+    }
+
+    if _, err := d.execInContainer(ctx, pod.Namespace, pod.Name, debugContainerName(containerName), rootProbe); err != nil {
+        fmt.Printf("⚠️  Debug container of %s/%s/%s cannot read the target's filesystem, checking through exec: %v\n",
+            pod.Namespace, pod.Name, containerName, err)
+        return config.DiagnosticsExec, execErrorKind(err) == ExecNonZeroExit
+    }
+
+    fmt.Printf("🧰 Checking %s/%s/%s through debug container %s (%s)\n",
+        pod.Namespace, pod.Name, containerName, debugContainerName(containerName), d.debugImage)
+    return config.DiagnosticsDebug, true
+}
+
+// ensureDebugContainer adds the debug container for a target container unless
+// the pod already has it, and waits for it to run. Ephemeral containers cannot
+// be removed, so it stays for the lifetime of the pod and is reused. In
+// dry-run mode no debug container is added.
+func (d *DiagnosticsEngine) ensureDebugContainer(ctx context.Context, pod *corev1.Pod, containerName string) error {
+    name := debugContainerName(containerName)
+
+    if !hasEphemeralContainer(pod, name) {
+        if d.dryRun {
+            return fmt.Errorf("dry run: would add debug container %s", name)
+        }
+        securityContext, err := debugSecurityContext(pod, containerName)
+        if err != nil {
+            return err
+        }
+        latest, err := d.clientset.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+        if err != nil {
+            return fmt.Errorf("failed to get pod: %v", err)
+        }
+        if !hasEphemeralContainer(latest, name) {
+            latest.Spec.EphemeralContainers = append(latest.Spec.EphemeralContainers, corev1.EphemeralContainer{
+                EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+                    Name:            name,
+                    Image:           d.debugImage,
+                    ImagePullPolicy: corev1.PullIfNotPresent,
+                    Command:         []string{"sh", "-c", "trap 'exit 0' TERM; while true; do sleep 3600 & wait; done"},
+                    SecurityContext: securityContext,
+                },
+                TargetContainerName: containerName,
+            })
+            _, err := d.clientset.CoreV1().Pods(pod.Namespace).UpdateEphemeralContainers(ctx, pod.Name, latest, metav1.UpdateOptions{})
+            if apierrors.IsConflict(err) {
+                return errDebugNotReady
+            }
+            if err != nil {
+                return fmt.Errorf("failed to add debug container: %v", err)
+            }
+        }
+    }
+
+    return d.waitForDebugContainer(ctx, pod.Namespace, pod.Name, name)
+}
+
+func (d *DiagnosticsEngine) waitForDebugContainer(ctx context.Context, namespace, podName, name string) error {
+    err := wait.PollUntilContextTimeout(ctx, debugPollInterval, debugStartTimeout, true, func(ctx context.Context) (bool, error) {
+        pod, err := d.cache.Pods.Pods(namespace).Get(podName)
+        if err != nil {
+            return false, fmt.Errorf("pod disappeared while starting debug container")
+        }
+        for _, status := range pod.Status.EphemeralContainerStatuses {
+            if status.Name != name {
+                continue
+            }
+            if status.State.Terminated != nil {
+                return false, fmt.Errorf("debug container exited: %s", status.State.Terminated.Reason)
+            }
+            return status.State.Running != nil, nil
+        }
+        return false, nil
+    })
+    if err != nil && wait.Interrupted(err) {
+        return errDebugNotReady
+    }
+    return err
+}
+
+// debugSecurityContext runs the debug container as the user of its target, so
+// it may read the target's filesystem through /proc without CAP_SYS_PTRACE.
+// A target that must run as non-root with a UID only known from its image
+// cannot be matched.
+func debugSecurityContext(pod *corev1.Pod, containerName string) (*corev1.SecurityContext, error) {
+    var runAsUser, runAsGroup *int64
+    var runAsNonRoot *bool
+    if psc := pod.Spec.SecurityContext; psc != nil {
+        runAsUser, runAsGroup, runAsNonRoot = psc.RunAsUser, psc.RunAsGroup, psc.RunAsNonRoot
+    }
+    for _, c := range pod.Spec.Containers {
+        if c.Name != containerName || c.SecurityContext == nil {
+            continue
+        }
+        if c.SecurityContext.RunAsUser != nil {
+            runAsUser = c.SecurityContext.RunAsUser
+        }
+        if c.SecurityContext.RunAsGroup != nil {
+            runAsGroup = c.SecurityContext.RunAsGroup
+        }
+        if c.SecurityContext.RunAsNonRoot != nil {
+            runAsNonRoot = c.SecurityContext.RunAsNonRoot
+        }
+    }
+
+    if runAsUser == nil {
+        if runAsNonRoot != nil && *runAsNonRoot {
+            return nil, fmt.Errorf("target runs as non-root with a UID set by its image")
+        }
+        return nil, nil
+    }
+    return &corev1.SecurityContext{RunAsUser: runAsUser, RunAsGroup: runAsGroup}, nil
+}
+
+// pruneModes forgets the mode of containers whose pod is gone. It runs at the
+// start of every full sweep, so containers left for a retry are tried again.
+func (d *DiagnosticsEngine) pruneModes(pods []*corev1.Pod) {
+    live := make(map[string]bool, len(pods))
+    for _, pod := range pods {
+        live[string(pod.UID)] = true
+    }
+
+    d.mu.Lock()
+    defer d.mu.Unlock()
+
+    d.retry = make(map[string]bool)
+
+    for key := range d.modes {
+        uid, _, _ := strings.Cut(key, "/")
+        if !live[uid] {
+            delete(d.modes, key)
+        }
+    }
+}
+
+func modeKey(pod *corev1.Pod, containerName string) string {
+    return fmt.Sprintf("%s/%s", pod.UID, containerName)
+}
+
+func debugContainerName(containerName string) string {
+    name := debugContainerPrefix + containerName
+    if len(name) > 63 {
+        name = name[:63]
+    }
+    return name
+}
+
+func hasEphemeralContainer(pod *corev1.Pod, name string) bool {
+    for _, c := range pod.Spec.EphemeralContainers {
+        if c.Name == name {
+            return true
+        }
+    }
+    return false
+}
@@ -5,31 +5,56 @@ import (
     "fmt"
     "strconv"
     "strings"
+    "sync"
     "time"
 
+    "k8s-healer/internal/agent"
+    "k8s-healer/internal/cache"
+    "k8s-healer/internal/config"
+
     "k8s.io/client-go/kubernetes"
-    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-    "k8s.io/client-go/kubernetes/scheme"
-    "k8s.io/client-go/tools/remotecommand"
     "k8s.io/client-go/rest"
-    corev1 "k8s.io/api/core/v1"
 )
 
 type DiagnosticsEngine struct {
-    clientset *kubernetes.Clientset
-    config    *rest.Config
-    history   map[string][]ContainerStats
+    clientset  *kubernetes.Clientset
+    config     *rest.Config
+    cache      *cache.Cache
+    pool       *execPool
+    mode       string
+    debugImage string
+    dryRun     bool
+    agents     *agent.Receiver
+    checks     *CheckRegistry
+
+    mu         sync.Mutex
+    history    map[string][]ContainerStats
+    modes      map[string]string
+    retry      map[string]bool // containers whose mode is worked out again next sweep
 }
 
+// statsFromAgent is the Mode of stats a node agent reported.
+const statsFromAgent = "agent"
+
+// Agents report a container as stalled on IO when its PSI avg10 (percent of
+// the last 10s spent waiting) stays at or above this.
+const ioPressureHigh = 50
+
+// CPUIOwait is the exec load estimate (load average * 100); IOPressure is
+// the PSI figure reported by node agents.
 type ContainerStats struct {
     Timestamp    time.Time
     CPUIOwait    float64
+    IOPressure   float64
     DiskReadMB   float64
     DiskWriteMB  float64
     NetworkRxMB  float64
     NetworkTxMB  float64
     ProcessCount int
+    Zombies      int
     IsStuck      bool
+    ExecError    string // kind of exec failure of the process count, if any
+    Mode         string // how the sample was taken: exec, debug or agent
 }
 
 type DiagnosticResult struct {
@@ -43,47 +68,64 @@ type DiagnosticResult struct {
     Actions      []string
 }
 
-func New(clientset *kubernetes.Clientset, config *rest.Config) *DiagnosticsEngine {
-    return &DiagnosticsEngine{
-        clientset: clientset,
-        config:    config,
-        history:   make(map[string][]ContainerStats),
+func New(clientset *kubernetes.Clientset, restConfig *rest.Config, kubeCache *cache.Cache, cfg *config.Config) *DiagnosticsEngine {
+    d := &DiagnosticsEngine{
+        clientset:  clientset,
+        config:     restConfig,
+        cache:      kubeCache,
+        pool:       newExecPool(cfg.CheckPool),
+        mode:       cfg.DiagnosticsMode,
+        debugImage: cfg.DebugImage,
+        dryRun:     cfg.DryRun,
+        history:    make(map[string][]ContainerStats),
+        checks:     NewCheckRegistry(),
+        modes:      make(map[string]string),
+        retry:      make(map[string]bool),
     }
+    d.registerBuiltinChecks()
+    return d
+}
+
+// SetAgents makes the engine prefer node agent reports over exec for
+// container stats.
+func (d *DiagnosticsEngine) SetAgents(agents *agent.Receiver) {
+    d.agents = agents
 }
 
 func (d *DiagnosticsEngine) DiagnoseStuckContainers(ctx context.Context, namespace string) ([]DiagnosticResult, error) {
     var results []DiagnosticResult
     
-    listOptions := metav1.ListOptions{}
-    if namespace == "" {
-        namespace = metav1.NamespaceAll
-    }
-    
-    pods, err := d.clientset.CoreV1().Pods(namespace).List(ctx, listOptions)
+    pods, err := d.sweepPods(namespace)
     if err != nil {
-        return nil, fmt.Errorf("failed to list pods: %v", err)
+        return nil, err
     }
     
-    for _, pod := range pods.Items {
-        if pod.Status.Phase != "Running" {
-            continue
-        }
-        
-        // Skip system pods
-        if strings.Contains(pod.Namespace, "kube-") || 
-           strings.Contains(pod.Namespace, "healer-") {
-            continue
-        }
-        
+    var mu sync.Mutex
+    var tasks []execTask
+    for _, pod := range pods {
         for _, container := range pod.Spec.Containers {
-            result := d.analyzeContainer(ctx, pod.Namespace, pod.Name, container.Name)
-            if result.IsStuck {
-                results = append(results, result)
-            }
+            namespace, podName, containerName := pod.Namespace, pod.Name, container.Name
+            tasks = append(tasks, execTask{
+                namespace: namespace,
+                node:      pod.Spec.NodeName,
+                run: func(ctx context.Context) {
+                    result := d.analyzeContainer(ctx, namespace, podName, containerName)
+                    if result.IsStuck {
+                        mu.Lock()
+                        results = append(results, result)
+                        mu.Unlock()
+                    }
+                },
+            })
         }
     }
     
-    return results, nil
+    unfinished := d.pool.run(ctx, tasks)
+    d.reportUnfinished("Stuck container", unfinished, len(tasks))
+    
+    mu.Lock()
+    defer mu.Unlock()
+    return append([]DiagnosticResult(nil), results...), nil
 }
 
 func (d *DiagnosticsEngine) analyzeContainer(ctx context.Context, namespace, podName, containerName string) DiagnosticResult {
@@ -105,6 +147,7 @@ func (d *DiagnosticsEngine) analyzeContainer(ctx context.Context, namespace, pod
     
     // Store in history
     key := fmt.Sprintf("%s/%s/%s", namespace, podName, containerName)
+    d.mu.Lock()
     if d.history[key] == nil {
         d.history[key] = make([]ContainerStats, 0)
     }
@@ -114,10 +157,12 @@ func (d *DiagnosticsEngine) analyzeContainer(ctx context.Context, namespace, pod
     if len(d.history[key]) > 10 {
         d.history[key] = d.history[key][1:]
     }
+    history := append([]ContainerStats(nil), d.history[key]...)
+    d.mu.Unlock()
     
     // Analyze for stuck patterns
-    if len(d.history[key]) >= 3 {
-        isStuck, reason := d.detectStuckContainer(d.history[key])
+    if len(history) >= 3 {
+        isStuck, reason := d.detectStuckContainer(history)
         if isStuck {
             result.IsStuck = true
             result.StuckReason = reason
@@ -134,19 +179,48 @@ func (d *DiagnosticsEngine) getContainerStats(ctx context.Context, namespace, po
         Timestamp: time.Now(),
     }
     
+    // A node agent reads the container's cgroup directly, without exec
+    if sample, ok := d.agentSample(namespace, podName, containerName); ok {
+        stats.Mode = statsFromAgent
+        stats.IOPressure = sample.IOPressure
+        stats.DiskReadMB = sample.DiskReadMB
+        stats.DiskWriteMB = sample.DiskWriteMB
+        stats.NetworkRxMB = sample.NetworkRxMB
+        stats.NetworkTxMB = sample.NetworkTxMB
+        stats.ProcessCount = sample.ProcessCount
+        stats.Zombies = sample.Zombies
+        return stats, nil
+    }
+    
+    runner := d.runnerFor(ctx, namespace, podName, containerName)
+    stats.Mode = runner.mode
+    
     // Simple commands that work in most containers
     commands := map[string]string{
-        "proc_count": "ps aux 2>/dev/null | wc -l || echo 0",
+        "proc_count": runner.processCountCommand(),
         "uptime":     "uptime 2>/dev/null || echo '0.0 0.0 0.0'",
-        "disk_usage": "df / 2>/dev/null | tail -1 | awk '{print $5}' || echo '0%'",
+        "disk_usage": "df " + runner.path("/") + " 2>/dev/null | tail -1 | awk '{print $5}' || echo '0%'",
     }
     
     for metric, cmd := range commands {
-        output, err := d.execInContainer(ctx, namespace, podName, containerName, cmd)
+        output, err := runner.exec(ctx, cmd)
         if err != nil {
-            // If exec fails, container might be stuck
-            if metric == "proc_count" {
+            if metric != "proc_count" {
+                continue
+            }
+            
+            // Only a command that never returns means the container may be
+            // stuck; a non-zero exit still proves it is responsive. Other
+            // failures say nothing about the container, so the sample is
+            // dropped rather than recorded as zero processes.
+            switch kind := execErrorKind(err); kind {
+            case ExecTimeout:
                 stats.IsStuck = true
+                stats.ExecError = kind
+            case ExecNonZeroExit:
+                stats.ExecError = kind
+            default:
+                return stats, err
             }
             continue
         }
@@ -170,39 +244,26 @@ func (d *DiagnosticsEngine) getContainerStats(ctx context.Context, namespace, po
     return stats, nil
 }
 
-func (d *DiagnosticsEngine) execInContainer(ctx context.Context, namespace, podName, containerName, command string) (string, error) {
-    req := d.clientset.CoreV1().RESTClient().Post().
-        Resource("pods").
-        Name(podName).
-        Namespace(namespace).
-        SubResource("exec")
-    
-    // Correct way to set exec parameters
-    execOptions := &corev1.PodExecOptions{
-        Container: containerName,
-        Command:   []string{"/bin/sh", "-c", command},
-        Stdout:    true,
-        Stderr:    true,
+// agentSample returns the node agent's latest sample of a container.
+func (d *DiagnosticsEngine) agentSample(namespace, podName, containerName string) (agent.ContainerSample, bool) {
+    if d.agents == nil {
+        return agent.ContainerSample{}, false
     }
-    
-    req.VersionedParams(execOptions, scheme.ParameterCodec)
-    
-    exec, err := remotecommand.NewSPDYExecutor(d.config, "POST", req.URL())
+    pod, err := d.cache.Pods.Pods(namespace).Get(podName)
     if err != nil {
-        return "", err
+        return agent.ContainerSample{}, false
     }
-    
-    var stdout, stderr strings.Builder
-    err = exec.Stream(remotecommand.StreamOptions{
-        Stdout: &stdout,
-        Stderr: &stderr,
-    })
-    
-    if err != nil {
-        return "", err
+    status, ok := containerStatus(pod, containerName)
+    if !ok || status.ContainerID == "" {
+        return agent.ContainerSample{}, false
     }
     
-    return stdout.String(), nil
+    // containerd://<id>
+    id := status.ContainerID
+    if i := strings.Index(id, "://"); i >= 0 {
+        id = id[i+3:]
+    }
+    return d.agents.Container(id)
 }
 
 func (d *DiagnosticsEngine) detectStuckContainer(history []ContainerStats) (bool, string) {
@@ -215,26 +276,48 @@ func (d *DiagnosticsEngine) detectStuckContainer(history []ContainerStats) (bool
     // Check if any stats show container is stuck
     for _, stat := range recent {
         if stat.IsStuck {
-            return true, "Container exec commands failing - container may be unresponsive"
+            return true, "Container exec commands timing out - container may be unresponsive"
         }
     }
     
-    // Check for consistently high load
-    highLoad := true
+    // Check for zombies piling up, which means PID 1 does not reap its
+    // children. Only node agents report zombies.
+    if recent[0].Zombies > 0 && recent[1].Zombies > recent[0].Zombies && recent[2].Zombies > recent[1].Zombies {
+        return true, fmt.Sprintf("%d zombie processes and growing - init process is not reaping children", recent[2].Zombies)
+    }
+    
+    // Check for consistently high load, or IO pressure where agents report
+    highLoad, highPressure := true, true
     for _, stat := range recent {
-        if stat.CPUIOwait < 80 {
+        if stat.Mode == statsFromAgent || stat.CPUIOwait < 80 {
             highLoad = false
-            break
+        }
+        if stat.Mode != statsFromAgent || stat.IOPressure < ioPressureHigh {
+            highPressure = false
         }
     }
     if highLoad {
         return true, "Consistently high system load - container may be stuck"
     }
+    if highPressure {
+        return true, fmt.Sprintf("Consistently high IO pressure (%.0f%%) - container may be stuck", recent[2].IOPressure)
+    }
     
-    // Check for zero or very low process count
+    // The process count checks need a count from every sample; a command
+    // that exited non-zero did not report one
+    for _, stat := range recent {
+        if stat.ExecError != "" {
+            return false, ""
+        }
+    }
+    
+    // Check for zero or very low process count. Through exec the count
+    // includes the ps header and the shell, ps and wc running it; debug
+    // containers and node agents count only the container's own processes,
+    // and a single-process app is healthy.
     lowProcesses := true
     for _, stat := range recent {
-        if stat.ProcessCount > 3 {
+        if stat.Mode != config.DiagnosticsExec || stat.ProcessCount > 3 {
             lowProcesses = false
             break
         }
@@ -267,6 +350,9 @@ func (d *DiagnosticsEngine) generateActions(reason string) []string {
     if strings.Contains(reason, "process count") {
         actions = append(actions, "RESTART_POD", "INVESTIGATE_APP")
     }
+    if strings.Contains(reason, "reaping") {
+        actions = append(actions, "RESTART_CONTAINER", "CHECK_INIT_PROCESS")
+    }
     if strings.Contains(reason, "minimal state") {
         actions = append(actions, "CHECK_HEALTH", "MONITOR_CLOSELY")
     }
@@ -0,0 +1,90 @@
+package diagnostics
+
+import (
+    "strings"
+    "testing"
+
+    "k8s-healer/internal/config"
+)
+
+func samples(mode string, counts ...int) []ContainerStats {
+    stats := make([]ContainerStats, 0, len(counts))
+    for _, count := range counts {
+        stats = append(stats, ContainerStats{Mode: mode, ProcessCount: count})
+    }
+    return stats
+}
+
+func TestDetectStuckContainer(t *testing.T) {
+    tests := []struct {
+        name       string
+        history    []ContainerStats
+        wantStuck  bool
+        wantReason string
+    }{
+        {name: "healthy exec counts", history: samples(config.DiagnosticsExec, 8, 8, 9)},
+        {name: "low exec count", history: samples(config.DiagnosticsExec, 2, 3, 2), wantStuck: true, wantReason: "minimal state"},
+        {name: "single-process app through a debug container", history: samples(config.DiagnosticsDebug, 1, 1, 1)},
+        {name: "falling debug count", history: samples(config.DiagnosticsDebug, 12, 9, 5), wantStuck: true, wantReason: "decreasing"},
+        {
+            name: "exec timeout",
+            history: []ContainerStats{
+                {Mode: config.DiagnosticsExec, ProcessCount: 8},
+                {Mode: config.DiagnosticsExec, ProcessCount: 8},
+                {Mode: config.DiagnosticsExec, IsStuck: true, ExecError: ExecTimeout},
+            },
+            wantStuck:  true,
+            wantReason: "unresponsive",
+        },
+        {
+            name: "non-zero exit reports no count",
+            history: []ContainerStats{
+                {Mode: config.DiagnosticsExec, ProcessCount: 8},
+                {Mode: config.DiagnosticsExec, ExecError: ExecNonZeroExit},
+                {Mode: config.DiagnosticsExec, ProcessCount: 8},
+            },
+        },
+        {
+            name: "high load",
+            history: []ContainerStats{
+                {Mode: config.DiagnosticsExec, ProcessCount: 8, CPUIOwait: 120},
+                {Mode: config.DiagnosticsExec, ProcessCount: 8, CPUIOwait: 95},
+                {Mode: config.DiagnosticsExec, ProcessCount: 8, CPUIOwait: 150},
+            },
+            wantStuck:  true,
+            wantReason: "high system load",
+        },
+        {name: "single-process app reported by an agent", history: samples(statsFromAgent, 1, 1, 1)},
+        {
+            name: "IO pressure not sustained",
+            history: []ContainerStats{
+                {Mode: statsFromAgent, ProcessCount: 1, IOPressure: 90},
+                {Mode: statsFromAgent, ProcessCount: 1, IOPressure: 85},
+                {Mode: statsFromAgent, ProcessCount: 1, IOPressure: 20},
+            },
+        },
+        {
+            name: "high IO pressure",
+            history: []ContainerStats{
+                {Mode: statsFromAgent, ProcessCount: 1, IOPressure: 90},
+                {Mode: statsFromAgent, ProcessCount: 1, IOPressure: 85},
+                {Mode: statsFromAgent, ProcessCount: 1, IOPressure: 70},
+            },
+            wantStuck:  true,
+            wantReason: "IO pressure",
+        },
+    }
+
+    d := &DiagnosticsEngine{}
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            stuck, reason := d.detectStuckContainer(tt.history)
+            if stuck != tt.wantStuck {
+                t.Fatalf("detectStuckContainer() = %v (%q), want %v", stuck, reason, tt.wantStuck)
+            }
+            if !strings.Contains(reason, tt.wantReason) {
+                t.Errorf("reason = %q, want it to mention %q", reason, tt.wantReason)
+            }
+        })
+    }
+}
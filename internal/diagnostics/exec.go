@@ -0,0 +1,124 @@
+package diagnostics
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "syscall"
+
+    corev1 "k8s.io/api/core/v1"
+    "k8s.io/client-go/kubernetes/scheme"
+    "k8s.io/client-go/tools/remotecommand"
+    utilexec "k8s.io/client-go/util/exec"
+)
+
+// Kinds of exec failure. Only a timeout says something about the container
+// itself: a refused connection points at the kubelet or the network, and a
+// non-zero exit means the container did run the command.
+const (
+    ExecTimeout           = "TIMEOUT"
+    ExecConnectionRefused = "CONNECTION_REFUSED"
+    ExecNonZeroExit       = "NON_ZERO_EXIT"
+    ExecCanceled          = "CANCELED"
+    ExecFailed            = "FAILED"
+)
+
+// ExecError is returned by execInContainer when a command could not be run
+// to a successful exit.
+type ExecError struct {
+    Kind     string
+    Command  string
+    ExitCode int
+    Stderr   string
+    Err      error
+}
+
+func (e *ExecError) Error() string {
+    switch e.Kind {
+    case ExecTimeout:
+        return fmt.Sprintf("exec %q timed out: %v", e.Command, e.Err)
+    case ExecNonZeroExit:
+        if e.Stderr != "" {
+            return fmt.Sprintf("exec %q exited with code %d: %s", e.Command, e.ExitCode, e.Stderr)
+        }
+        return fmt.Sprintf("exec %q exited with code %d", e.Command, e.ExitCode)
+    case ExecConnectionRefused:
+        return fmt.Sprintf("exec %q connection refused: %v", e.Command, e.Err)
+    }
+    return fmt.Sprintf("exec %q failed: %v", e.Command, e.Err)
+}
+
+func (e *ExecError) Unwrap() error {
+    return e.Err
+}
+
+// execErrorKind returns the kind of an exec error, or "" when err is not one.
+func execErrorKind(err error) string {
+    var execErr *ExecError
+    if errors.As(err, &execErr) {
+        return execErr.Kind
+    }
+    return ""
+}
+
+// execInContainer runs command through /bin/sh in the container and returns
+// its stdout. Each command gets at most the configured exec timeout.
+func (d *DiagnosticsEngine) execInContainer(ctx context.Context, namespace, podName, containerName, command string) (string, error) {
+    req := d.clientset.CoreV1().RESTClient().Post().
+        Resource("pods").
+        Name(podName).
+        Namespace(namespace).
+        SubResource("exec")
+
+    // Correct way to set exec parameters
+    execOptions := &corev1.PodExecOptions{
+        Container: containerName,
+        Command:   []string{"/bin/sh", "-c", command},
+        Stdout:    true,
+        Stderr:    true,
+    }
+
+    req.VersionedParams(execOptions, scheme.ParameterCodec)
+
+    exec, err := remotecommand.NewSPDYExecutor(d.config, "POST", req.URL())
+    if err != nil {
+        return "", &ExecError{Kind: ExecFailed, Command: command, Err: err}
+    }
+
+    execCtx, cancel := context.WithTimeout(ctx, d.pool.cfg.ExecTimeout)
+    defer cancel()
+
+    var stdout, stderr strings.Builder
+    err = exec.StreamWithContext(execCtx, remotecommand.StreamOptions{
+        Stdout: &stdout,
+        Stderr: &stderr,
+    })
+
+    if err != nil {
+        return stdout.String(), classifyExecError(ctx, execCtx, command, strings.TrimSpace(stderr.String()), err)
+    }
+
+    return stdout.String(), nil
+}
+
+func classifyExecError(ctx, execCtx context.Context, command, stderr string, err error) *ExecError {
+    execErr := &ExecError{Kind: ExecFailed, Command: command, Stderr: stderr, Err: err}
+
+    var exitErr utilexec.ExitError
+    switch {
+    case ctx.Err() != nil:
+        // The sweep or the healer was stopped, not the command
+        execErr.Kind = ExecCanceled
+    case errors.Is(execCtx.Err(), context.DeadlineExceeded) || errors.Is(err, context.DeadlineExceeded):
+        execErr.Kind = ExecTimeout
+    case errors.As(err, &exitErr) && exitErr.Exited():
+        execErr.Kind = ExecNonZeroExit
+        execErr.ExitCode = exitErr.ExitStatus()
+    case errors.Is(err, syscall.ECONNREFUSED) ||
+         strings.Contains(err.Error(), "connection refused") ||
+         strings.Contains(err.Error(), "error dialing backend"):
+        execErr.Kind = ExecConnectionRefused
+    }
+    return execErr
+}
@@ -0,0 +1,56 @@
+package diagnostics
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "syscall"
+    "testing"
+
+    utilexec "k8s.io/client-go/util/exec"
+)
+
+func TestClassifyExecError(t *testing.T) {
+    canceled, cancel := context.WithCancel(context.Background())
+    cancel()
+    expired, cancelExpired := context.WithTimeout(context.Background(), 0)
+    defer cancelExpired()
+    live := context.Background()
+
+    tests := []struct {
+        name         string
+        ctx          context.Context
+        execCtx      context.Context
+        err          error
+        wantKind     string
+        wantExitCode int
+    }{
+        {name: "sweep canceled", ctx: canceled, execCtx: canceled, err: errors.New("stream closed"), wantKind: ExecCanceled},
+        {name: "canceled wins over exit code", ctx: canceled, execCtx: canceled, err: utilexec.CodeExitError{Err: errors.New("exit"), Code: 1}, wantKind: ExecCanceled},
+        {name: "exec deadline", ctx: live, execCtx: expired, err: errors.New("stream closed"), wantKind: ExecTimeout},
+        {name: "wrapped deadline", ctx: live, execCtx: live, err: fmt.Errorf("stream: %w", context.DeadlineExceeded), wantKind: ExecTimeout},
+        {name: "non-zero exit", ctx: live, execCtx: live, err: utilexec.CodeExitError{Err: errors.New("command terminated with exit code 2"), Code: 2}, wantKind: ExecNonZeroExit, wantExitCode: 2},
+        {name: "connection refused errno", ctx: live, execCtx: live, err: fmt.Errorf("dial: %w", syscall.ECONNREFUSED), wantKind: ExecConnectionRefused},
+        {name: "connection refused message", ctx: live, execCtx: live, err: errors.New("dial tcp 10.0.0.1:10250: connect: connection refused"), wantKind: ExecConnectionRefused},
+        {name: "kubelet unreachable", ctx: live, execCtx: live, err: errors.New("error dialing backend: EOF"), wantKind: ExecConnectionRefused},
+        {name: "anything else", ctx: live, execCtx: live, err: errors.New("unable to upgrade connection"), wantKind: ExecFailed},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := classifyExecError(tt.ctx, tt.execCtx, "cat /etc/resolv.conf", "stderr", tt.err)
+            if got.Kind != tt.wantKind {
+                t.Errorf("Kind = %s, want %s", got.Kind, tt.wantKind)
+            }
+            if got.ExitCode != tt.wantExitCode {
+                t.Errorf("ExitCode = %d, want %d", got.ExitCode, tt.wantExitCode)
+            }
+            if got.Command != "cat /etc/resolv.conf" || got.Stderr != "stderr" || got.Err != tt.err {
+                t.Errorf("ExecError does not keep command, stderr and cause: %+v", got)
+            }
+            if execErrorKind(got) != tt.wantKind {
+                t.Errorf("execErrorKind() = %s, want %s", execErrorKind(got), tt.wantKind)
+            }
+        })
+    }
+}
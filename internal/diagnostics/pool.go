@@ -0,0 +1,155 @@
+package diagnostics
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "sync"
+
+    "k8s-healer/internal/config"
+
+    corev1 "k8s.io/api/core/v1"
+)
+
+// execPool runs per-container diagnostics concurrently. Every task makes exec
+// round trips through the API server to the kubelet, so the tasks in flight
+// are capped overall, per node and per namespace, and a sweep stops waiting
+// for tasks once its deadline has passed. The caps are shared by everything
+// running through the pool, e.g. a sweep and event-driven checks.
+type execPool struct {
+    cfg config.CheckPoolConfig
+
+    mu           sync.Mutex
+    active       int
+    perNode      map[string]int
+    perNamespace map[string]int
+    freed        chan struct{} // closed and replaced whenever a task finishes
+}
+
+func newExecPool(cfg config.CheckPoolConfig) *execPool {
+    return &execPool{
+        cfg:          cfg,
+        perNode:      make(map[string]int),
+        perNamespace: make(map[string]int),
+        freed:        make(chan struct{}),
+    }
+}
+
+type execTask struct {
+    namespace string
+    node      string
+    run       func(ctx context.Context)
+}
+
+// run starts tasks as the caps allow and returns once all of them have
+// finished or the sweep deadline has passed. It returns the number of tasks
+// that did not finish; those still running see their context cancelled.
+func (p *execPool) run(ctx context.Context, tasks []execTask) int {
+    ctx, cancel := context.WithTimeout(ctx, p.cfg.SweepTimeout)
+    defer cancel()
+
+    // Buffered so tasks abandoned at the deadline never block
+    done := make(chan struct{}, len(tasks))
+    running := 0
+    pending := tasks
+
+    for len(pending) > 0 || running > 0 {
+        var waiting []execTask
+        p.mu.Lock()
+        for _, t := range pending {
+            if !p.acquire(t) {
+                waiting = append(waiting, t)
+                continue
+            }
+            running++
+            go func(t execTask) {
+                // Abandoned tasks hold their slot until they really return
+                defer p.release(t)
+                t.run(ctx)
+                done <- struct{}{}
+            }(t)
+        }
+        freed := p.freed
+        p.mu.Unlock()
+        pending = waiting
+
+        select {
+        case <-done:
+            running--
+        case <-freed:
+            // A task of another caller finished, try the waiting ones again
+        case <-ctx.Done():
+            return len(pending) + running
+        }
+    }
+    return 0
+}
+
+// acquire takes a slot for t if the caps allow it. p.mu must be held.
+func (p *execPool) acquire(t execTask) bool {
+    if p.active >= p.cfg.Workers {
+        return false
+    }
+    if p.cfg.PerNode > 0 && p.perNode[t.node] >= p.cfg.PerNode {
+        return false
+    }
+    if p.cfg.PerNamespace > 0 && p.perNamespace[t.namespace] >= p.cfg.PerNamespace {
+        return false
+    }
+    p.active++
+    p.perNode[t.node]++
+    p.perNamespace[t.namespace]++
+    return true
+}
+
+func (p *execPool) release(t execTask) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    p.active--
+    p.perNode[t.node]--
+    if p.perNode[t.node] == 0 {
+        delete(p.perNode, t.node)
+    }
+    p.perNamespace[t.namespace]--
+    if p.perNamespace[t.namespace] == 0 {
+        delete(p.perNamespace, t.namespace)
+    }
+    close(p.freed)
+    p.freed = make(chan struct{})
+}
+
+// sweepPods lists the running, non-system pods a diagnostics sweep covers.
+func (d *DiagnosticsEngine) sweepPods(namespace string) ([]*corev1.Pod, error) {
+    pods, err := d.cache.ListPods(namespace)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list pods: %v", err)
+    }
+
+    var running []*corev1.Pod
+    for _, pod := range pods {
+        if pod.Status.Phase != "Running" {
+            continue
+        }
+
+        // Skip system pods
+        if strings.Contains(pod.Namespace, "kube-") ||
+           strings.Contains(pod.Namespace, "healer-") {
+            continue
+        }
+
+        running = append(running, pod)
+    }
+
+    if namespace == "" {
+        d.pruneModes(pods)
+    }
+    return running, nil
+}
+
+func (d *DiagnosticsEngine) reportUnfinished(sweep string, unfinished, total int) {
+    if unfinished > 0 {
+        fmt.Printf("⏱️  %s sweep hit its %v deadline: %d of %d containers not checked\n",
+            sweep, d.pool.cfg.SweepTimeout, unfinished, total)
+    }
+}
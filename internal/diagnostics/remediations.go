@@ -0,0 +1,184 @@
+package diagnostics
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "k8s-healer/internal/remediation"
+
+    corev1 "k8s.io/api/core/v1"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Commands to safely cleanup /tmp
+var tmpCleanupCommands = []string{
+    "find /tmp -type f -atime +1 -delete 2>/dev/null || true",
+    "find /tmp -type f -size +10M -delete 2>/dev/null || true",
+    "find /tmp -name '*.log' -mtime +1 -delete 2>/dev/null || true",
+    "find /tmp -name 'core.*' -delete 2>/dev/null || true",
+    "find /tmp -name '*.tmp' -mtime +1 -delete 2>/dev/null || true",
+}
+
+// Safe disk cleanup commands
+var diskCleanupCommands = []string{
+    "find /var/log -name '*.log' -size +50M -exec truncate -s 10M {} + 2>/dev/null || true",
+    "find /var/log -name '*.log.*' -mtime +7 -delete 2>/dev/null || true",
+    "find / -name '*.core' -delete 2>/dev/null || true",
+    "find /var/tmp -type f -mtime +3 -delete 2>/dev/null || true",
+}
+
+var networkFixCommands = []string{
+    "ip route flush cache 2>/dev/null || true",
+    "ping -c 1 kubernetes.default.svc.cluster.local 2>/dev/null && echo 'Network OK' || echo 'Network FAIL'",
+}
+
+var dnsFixCommands = []string{
+    "nslookup kubernetes.default.svc.cluster.local 2>/dev/null && echo 'DNS OK' || echo 'DNS FAIL'",
+}
+
+func (h *AutoHealer) registerRemediations() {
+    h.registry.MustRegister(&execRemediation{
+        name:        "cleanup-tmp",
+        description: "Cleaning up /tmp directory",
+        commands:    tmpCleanupCommands,
+        execute:     h.cleanupTmpDirectory,
+        check:       "/tmp Directory",
+        healer:      h,
+    }, "CLEANUP_TMP")
+    h.registry.MustRegister(&execRemediation{
+        name:        "cleanup-disk",
+        description: "Cleaning up disk space",
+        commands:    diskCleanupCommands,
+        execute:     h.cleanupDiskSpace,
+        check:       "Disk Space",
+        healer:      h,
+    }, "CLEANUP_DISK")
+    h.registry.MustRegister(&execRemediation{
+        name:        "fix-network",
+        description: "Fixing network connectivity",
+        commands:    append(append([]string{}, networkFixCommands...), "evict pod if the network is still failing"),
+        execute:     h.fixNetworkConnectivity,
+        check:       "Network Connectivity",
+        healer:      h,
+    }, "FIX_NETWORK")
+    h.registry.MustRegister(&execRemediation{
+        name:        "fix-dns",
+        description: "Fixing DNS resolution",
+        commands:    dnsFixCommands,
+        execute:     h.fixDNSResolution,
+        check:       "DNS Resolution",
+        healer:      h,
+    }, "FIX_DNS")
+    h.registry.MustRegister(&restartContainerRemediation{h}, "RESTART_CONTAINER")
+    h.registry.MustRegister(&notifyRemediation{h}, "NOTIFY")
+}
+
+// execRemediation is a container fix made of shell commands run through exec.
+// Verify re-runs the container check that triggered it.
+type execRemediation struct {
+    name        string
+    description string
+    commands    []string
+    execute     func(ctx context.Context, req remediation.Request) (remediation.Result, error)
+    check       string // name of the container check
+    healer      *AutoHealer
+}
+
+func (r *execRemediation) Name() string {
+    return r.name
+}
+
+func (r *execRemediation) Applies(req remediation.Request) bool {
+    if req.ContainerName == "" {
+        return false
+    }
+    _, err := r.healer.diagEngine.cache.Pods.Pods(req.Namespace).Get(req.PodName)
+    return err == nil
+}
+
+func (r *execRemediation) Plan(ctx context.Context, req remediation.Request) (remediation.Plan, error) {
+    return remediation.Plan{
+        Description: r.description,
+        Steps:       r.commands,
+    }, nil
+}
+
+func (r *execRemediation) Execute(ctx context.Context, req remediation.Request) (remediation.Result, error) {
+    return r.execute(ctx, req)
+}
+
+func (r *execRemediation) Verify(ctx context.Context, req remediation.Request) (bool, error) {
+    check, err := r.healer.diagEngine.rerunCheck(ctx, req.Namespace, req.PodName, req.ContainerName, r.check)
+    if err != nil {
+        return false, fmt.Errorf("%s check failed: %v", r.check, err)
+    }
+    return check.Status == "OK", nil
+}
+
+// notifyRemediation is the last rung of an escalation ladder: it records a
+// Warning event on the pod so the issue shows up in kubectl describe and in
+// event-based alerting.
+type notifyRemediation struct {
+    healer *AutoHealer
+}
+
+func (r *notifyRemediation) Name() string {
+    return "notify"
+}
+
+func (r *notifyRemediation) Applies(req remediation.Request) bool {
+    return true
+}
+
+func (r *notifyRemediation) Plan(ctx context.Context, req remediation.Request) (remediation.Plan, error) {
+    return remediation.Plan{
+        Description: fmt.Sprintf("Notifying operators about %s", req.Key()),
+        Steps:       []string{"create HealingUnresolved Warning event on the pod"},
+    }, nil
+}
+
+func (r *notifyRemediation) Execute(ctx context.Context, req remediation.Request) (remediation.Result, error) {
+    now := metav1.NewTime(time.Now())
+    event := &corev1.Event{
+        ObjectMeta: metav1.ObjectMeta{
+            GenerateName: req.PodName + ".",
+            Namespace:    req.Namespace,
+        },
+        InvolvedObject: corev1.ObjectReference{
+            Kind:      "Pod",
+            Namespace: req.Namespace,
+            Name:      req.PodName,
+            FieldPath: containerFieldPath(req.ContainerName),
+        },
+        Reason:         "HealingUnresolved",
+        Message:        req.Reason,
+        Type:           corev1.EventTypeWarning,
+        Source:         corev1.EventSource{Component: "k8s-healer"},
+        FirstTimestamp: now,
+        LastTimestamp:  now,
+        Count:          1,
+    }
+    if pod, err := r.healer.diagEngine.cache.Pods.Pods(req.Namespace).Get(req.PodName); err == nil {
+        event.InvolvedObject.UID = pod.UID
+    }
+
+    _, err := r.healer.diagEngine.clientset.CoreV1().Events(req.Namespace).Create(ctx, event, metav1.CreateOptions{})
+    if err != nil {
+        return remediation.Result{}, fmt.Errorf("failed to create event for %s: %v", req.Key(), err)
+    }
+
+    fmt.Printf("📣 NOTIFIED: %s - %s\n", req.Key(), req.Reason)
+    return remediation.Result{Status: remediation.StatusCompleted, Message: "Warning event HealingUnresolved created"}, nil
+}
+
+func (r *notifyRemediation) Verify(ctx context.Context, req remediation.Request) (bool, error) {
+    return true, nil
+}
+
+func containerFieldPath(containerName string) string {
+    if containerName == "" {
+        return ""
+    }
+    return fmt.Sprintf("spec.containers{%s}", containerName)
+}
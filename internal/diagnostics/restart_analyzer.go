@@ -6,7 +6,6 @@ import (
     "strings"
     "time"
     
-    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
     corev1 "k8s.io/api/core/v1"
 )
 
@@ -24,17 +23,12 @@ type RestartPattern struct {
 func (d *DiagnosticsEngine) AnalyzeRestartPatterns(ctx context.Context, namespace string) ([]RestartPattern, error) {
     var patterns []RestartPattern
     
-    listOptions := metav1.ListOptions{}
-    if namespace == "" {
-        namespace = metav1.NamespaceAll
-    }
-    
-    pods, err := d.clientset.CoreV1().Pods(namespace).List(ctx, listOptions)
+    pods, err := d.cache.ListPods(namespace)
     if err != nil {
         return nil, fmt.Errorf("failed to list pods: %v", err)
     }
     
-    for _, pod := range pods.Items {
+    for _, pod := range pods {
         // Skip system pods
         if strings.Contains(pod.Namespace, "kube-") || 
            strings.Contains(pod.Namespace, "healer-") {
@@ -50,7 +44,12 @@ func (d *DiagnosticsEngine) AnalyzeRestartPatterns(ctx context.Context, namespac
     return patterns, nil
 }
 
-func (d *DiagnosticsEngine) analyzeRestartPattern(pod corev1.Pod) RestartPattern {
+func (d *DiagnosticsEngine) AnalyzePodRestarts(pod *corev1.Pod) (RestartPattern, bool) {
+    pattern := d.analyzeRestartPattern(pod)
+    return pattern, pattern.RestartCount > 0
+}
+
+func (d *DiagnosticsEngine) analyzeRestartPattern(pod *corev1.Pod) RestartPattern {
     pattern := RestartPattern{
         PodName:      pod.Name,
         Namespace:    pod.Namespace,
@@ -0,0 +1,193 @@
+package diagnostics
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "k8s-healer/internal/eviction"
+    "k8s-healer/internal/remediation"
+
+    corev1 "k8s.io/api/core/v1"
+    "k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+    restartPollInterval = 2 * time.Second
+    restartTimeout      = 60 * time.Second
+
+    // How long to look for a restart when the kill exec itself failed. The
+    // exec stream usually breaks when PID 1 dies, so a failed exec does not
+    // mean the kill did not happen.
+    restartExecErrorGrace = 10 * time.Second
+
+    killPID1Command = "kill -TERM 1"
+)
+
+// restartContainerRemediation restarts a single container by terminating its
+// PID 1, so the kubelet restarts it in place and the pod keeps its node and
+// emptyDir volumes. When exec is not possible the pod is evicted instead.
+type restartContainerRemediation struct {
+    healer *AutoHealer
+}
+
+func (r *restartContainerRemediation) Name() string {
+    return "restart-container"
+}
+
+func (r *restartContainerRemediation) Applies(req remediation.Request) bool {
+    if req.ContainerName == "" {
+        return false
+    }
+    pod, err := r.healer.diagEngine.cache.Pods.Pods(req.Namespace).Get(req.PodName)
+    if err != nil {
+        return false
+    }
+    _, ok := containerStatus(pod, req.ContainerName)
+    return ok
+}
+
+func (r *restartContainerRemediation) Plan(ctx context.Context, req remediation.Request) (remediation.Plan, error) {
+    return remediation.Plan{
+        Description: fmt.Sprintf("Restarting container %s", req.Key()),
+        Steps: []string{
+            "send SIGTERM to PID 1 in the container",
+            "wait for the container's restart count to increase",
+            "evict the pod if exec is unavailable",
+        },
+    }, nil
+}
+
+func (r *restartContainerRemediation) Execute(ctx context.Context, req remediation.Request) (remediation.Result, error) {
+    pod, err := r.healer.diagEngine.cache.Pods.Pods(req.Namespace).Get(req.PodName)
+    if err != nil {
+        return remediation.Result{}, fmt.Errorf("pod %s/%s not found: %v", req.Namespace, req.PodName, err)
+    }
+    status, _ := containerStatus(pod, req.ContainerName)
+    before := status.RestartCount
+
+    // With a shared process namespace PID 1 is the pause container, and
+    // killing it would take the whole pod down
+    if pod.Spec.ShareProcessNamespace != nil && *pod.Spec.ShareProcessNamespace {
+        step := remediation.StepResult{
+            Name:   killPID1Command,
+            Status: remediation.StepSkipped,
+            Error:  "pod shares its process namespace; PID 1 is not the container's process",
+        }
+        return r.evictFallback(ctx, req, step)
+    }
+
+    kill := remediation.StepResult{Name: killPID1Command, Status: remediation.StepSucceeded}
+    output, execErr := r.healer.diagEngine.execInContainer(ctx, req.Namespace, req.PodName, req.ContainerName, killPID1Command)
+    kill.Output = output
+    timeout := restartTimeout
+    if execErr != nil {
+        kill.Error = execErr.Error()
+        timeout = restartExecErrorGrace
+    }
+
+    restarts, waitErr := r.waitForRestart(ctx, req, before, timeout)
+    restart := remediation.StepResult{Name: "wait for container restart", Status: remediation.StepSucceeded}
+    if waitErr == nil {
+        restart.Output = fmt.Sprintf("restart count %d → %d", before, restarts)
+        fmt.Printf("🔁 RESTARTED container %s (restart count %d → %d)\n", req.Key(), before, restarts)
+        return remediation.Result{
+            Action:  "RESTART_CONTAINER",
+            Status:  remediation.StatusCompleted,
+            Message: restart.Output,
+            Steps:   []remediation.StepResult{kill, restart},
+        }, nil
+    }
+    restart.Status = remediation.StepFailed
+    restart.Error = waitErr.Error()
+
+    if execErr != nil {
+        kill.Status = remediation.StepFailed
+        return r.evictFallback(ctx, req, kill, restart)
+    }
+
+    // The signal was delivered but PID 1 kept running, most likely because
+    // it has no SIGTERM handler. Leave eviction to the escalation ladder.
+    return remediation.Result{
+        Action:  "RESTART_CONTAINER",
+        Status:  remediation.StatusFailed,
+        Message: fmt.Sprintf("PID 1 of %s did not exit after SIGTERM", req.Key()),
+        Steps:   []remediation.StepResult{kill, restart},
+    }, nil
+}
+
+// Verify succeeds once the container is running again, started after the
+// request, or when the pod itself has been replaced.
+func (r *restartContainerRemediation) Verify(ctx context.Context, req remediation.Request) (bool, error) {
+    pod, err := r.healer.diagEngine.cache.Pods.Pods(req.Namespace).Get(req.PodName)
+    if err != nil {
+        return true, nil
+    }
+    status, ok := containerStatus(pod, req.ContainerName)
+    if !ok || status.State.Running == nil {
+        return false, nil
+    }
+    return status.State.Running.StartedAt.Time.After(req.Requested), nil
+}
+
+func (r *restartContainerRemediation) waitForRestart(ctx context.Context, req remediation.Request, before int32, timeout time.Duration) (int32, error) {
+    var restarts int32
+    err := wait.PollUntilContextTimeout(ctx, restartPollInterval, timeout, false, func(ctx context.Context) (bool, error) {
+        pod, err := r.healer.diagEngine.cache.Pods.Pods(req.Namespace).Get(req.PodName)
+        if err != nil {
+            return false, fmt.Errorf("pod disappeared while waiting for container restart")
+        }
+        status, ok := containerStatus(pod, req.ContainerName)
+        if !ok {
+            return false, nil
+        }
+        restarts = status.RestartCount
+        return restarts > before, nil
+    })
+    if err != nil && wait.Interrupted(err) {
+        return restarts, fmt.Errorf("restart count still %d after %v", before, timeout)
+    }
+    return restarts, err
+}
+
+func (r *restartContainerRemediation) evictFallback(ctx context.Context, req remediation.Request, steps ...remediation.StepResult) (remediation.Result, error) {
+    fmt.Printf("⚠️  Cannot restart container %s in place - evicting pod\n", req.Key())
+
+    evict := remediation.StepResult{Name: "evict pod", Status: remediation.StepSucceeded}
+    err := r.healer.evictor.Evict(ctx, req.Namespace, req.PodName, r.healer.deferredEvictionRecorder(req,
+        "RESTART_POD_FALLBACK", "", "Deferred pod eviction after in-place container restart was not possible"))
+
+    result := remediation.Result{Action: "RESTART_POD_FALLBACK"}
+    switch {
+    case errors.Is(err, eviction.ErrBlockedByPDB):
+        evict.Status = remediation.StepSkipped
+        evict.Error = err.Error()
+        result.Status = remediation.StatusBlockedByPDB
+        result.Message = "Pod eviction blocked by PodDisruptionBudget - will retry"
+    case errors.Is(err, eviction.ErrPodNotFound):
+        evict.Status = remediation.StepSkipped
+        evict.Error = err.Error()
+        result.Status = remediation.StatusSkipped
+        result.Message = "Pod is already gone"
+    case err != nil:
+        evict.Status = remediation.StepFailed
+        evict.Error = err.Error()
+        result.Status = remediation.StatusFailed
+        result.Message = fmt.Sprintf("Pod eviction failed: %v", err)
+    default:
+        result.Status = remediation.StatusCompleted
+        result.Message = "Exec unavailable; pod evicted instead"
+    }
+    result.Steps = append(steps, evict)
+    return result, nil
+}
+
+func containerStatus(pod *corev1.Pod, containerName string) (corev1.ContainerStatus, bool) {
+    for _, status := range pod.Status.ContainerStatuses {
+        if status.Name == containerName {
+            return status, true
+        }
+    }
+    return corev1.ContainerStatus{}, false
+}
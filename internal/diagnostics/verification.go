@@ -0,0 +1,273 @@
+package diagnostics
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "time"
+
+    "k8s-healer/internal/policy"
+    "k8s-healer/internal/remediation"
+
+    corev1 "k8s.io/api/core/v1"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+    VerificationPending    = "PENDING"
+    VerificationVerified   = "VERIFIED"
+    VerificationUnresolved = "UNRESOLVED"
+    // The pod was replaced and no ready replacement could be checked
+    VerificationReplaced = "REPLACED"
+)
+
+// verification follows one completed healing action: after the settle period
+// the originating check is re-run and, while it keeps failing, the ladder is
+// climbed one rung at a time.
+type verification struct {
+    checkResult ContainerCheckResult
+    check       ContainerCheck
+    action      HealingAction
+    ladder      []string
+    owner       *metav1.OwnerReference // controller that replaces the pod
+}
+
+// deferredVerification is a verification whose fix is a pod eviction that a
+// PodDisruptionBudget refused; it starts once the deferred eviction is done.
+type deferredVerification struct {
+    ctx context.Context
+    v   verification
+}
+
+// ValidateEscalation checks that every action of the escalation ladders is
+// registered. Call it once all engines have registered their remediations.
+func (h *AutoHealer) ValidateEscalation() error {
+    actions := make([]string, 0, len(h.escalation))
+    for action := range h.escalation {
+        actions = append(actions, action)
+    }
+    sort.Strings(actions)
+
+    for _, action := range actions {
+        for _, step := range h.escalation[action] {
+            if _, err := h.registry.Lookup(step); err != nil {
+                return fmt.Errorf("escalation ladder for %s: %v", action, err)
+            }
+        }
+    }
+    return nil
+}
+
+func verifyKey(checkResult ContainerCheckResult, checkName string) string {
+    return fmt.Sprintf("%s/%s/%s|%s", checkResult.Namespace, checkResult.PodName, checkResult.ContainerName, checkName)
+}
+
+func (h *AutoHealer) isVerifying(key string) bool {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    return h.verifying[key]
+}
+
+func (h *AutoHealer) startVerification(ctx context.Context, v verification) {
+    key := verifyKey(v.checkResult, v.check.CheckName)
+
+    h.mu.Lock()
+    h.verifying[key] = true
+    h.mu.Unlock()
+
+    // The pod may be gone by the time the check is re-run, so remember who
+    // replaces it
+    if pod, err := h.diagEngine.cache.Pods.Pods(v.action.Namespace).Get(v.action.PodName); err == nil {
+        v.owner = metav1.GetControllerOf(pod)
+    }
+
+    go func() {
+        if h.runVerification(ctx, v) {
+            return
+        }
+        h.mu.Lock()
+        delete(h.verifying, key)
+        h.mu.Unlock()
+    }()
+}
+
+// deferVerification holds the verification of a PDB-blocked fix back until
+// its eviction goes through. Meanwhile the check does not trigger new fixes.
+func (h *AutoHealer) deferVerification(ctx context.Context, v verification) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    key := containerKey(v.checkResult)
+    h.verifying[verifyKey(v.checkResult, v.check.CheckName)] = true
+    h.deferred[key] = append(h.deferred[key], deferredVerification{ctx: ctx, v: v})
+}
+
+// resumeVerification starts the deferred verifications of the container once
+// its eviction, recorded as action, is done. A failed or dropped eviction
+// ends them, so their checks can trigger fixes again.
+func (h *AutoHealer) resumeVerification(req remediation.Request, action HealingAction) {
+    h.mu.Lock()
+    deferred := h.deferred[req.Key()]
+    delete(h.deferred, req.Key())
+    for _, d := range deferred {
+        delete(h.verifying, verifyKey(d.v.checkResult, d.v.check.CheckName))
+    }
+    h.mu.Unlock()
+
+    if action.Status != StatusSucceeded {
+        return
+    }
+    for _, d := range deferred {
+        d.v.action = h.updateAction(action.ID, func(a *HealingAction) error {
+            if a.Check == "" {
+                a.Check = d.v.check.CheckName
+            }
+            a.Verification = VerificationPending
+            return nil
+        })
+        h.startVerification(d.ctx, d.v)
+    }
+}
+
+func containerKey(checkResult ContainerCheckResult) string {
+    return fmt.Sprintf("%s/%s/%s", checkResult.Namespace, checkResult.PodName, checkResult.ContainerName)
+}
+
+// runVerification follows the fix until it is verified, unresolved or
+// replaced. It returns true when it handed over to a deferred verification.
+func (h *AutoHealer) runVerification(ctx context.Context, v verification) bool {
+    key := verifyKey(v.checkResult, v.check.CheckName)
+    action := v.action
+    step := 0
+
+    for {
+        select {
+        case <-ctx.Done():
+            return false
+        case <-time.After(h.settle):
+        }
+
+        status, details := h.verifyCheck(ctx, &v, action)
+        switch status {
+        case VerificationVerified:
+            h.setVerification(action.ID, VerificationVerified, details)
+            fmt.Printf("✅ VERIFIED %s on %s/%s/%s: %s\n",
+                action.ActionType, action.Namespace, action.PodName, action.ContainerName, details)
+            return false
+        case VerificationReplaced:
+            // Nothing left to escalate against
+            h.setVerification(action.ID, VerificationReplaced, details)
+            fmt.Printf("🔁 REPLACED %s/%s after %s: %s\n",
+                action.Namespace, action.PodName, action.ActionType, details)
+            return false
+        }
+
+        h.setVerification(action.ID, VerificationUnresolved, details)
+        fmt.Printf("⚠️  UNRESOLVED %s check on %s/%s/%s after %s: %s\n",
+            v.check.CheckName, action.Namespace, action.PodName, action.ContainerName, action.ActionType, details)
+
+        // Climb the ladder until a rung actually runs
+        var next HealingAction
+        escalated := false
+        for step < len(v.ladder) {
+            check := v.check
+            check.Details = fmt.Sprintf("%s (unresolved after %s)", details, action.ActionType)
+            next = h.heal(ctx, v.checkResult, check, policy.Decision{Remediation: v.ladder[step]})
+            step++
+            if next.Outcome == remediation.StatusRateLimited {
+                fmt.Printf("⏳ Escalation to %s held back: %s\n", next.ActionType, next.Result)
+                continue
+            }
+
+            next = h.updateAction(next.ID, func(a *HealingAction) error {
+                a.EscalatedFrom = action.ActionType
+                return nil
+            })
+            h.PrintHealingActions([]HealingAction{next})
+
+            if next.Verification == VerificationPending {
+                escalated = true
+                break
+            }
+            if next.Outcome == remediation.StatusBlockedByPDB {
+                // Carry on from the next rung once the eviction went through,
+                // possibly on a replacement pod
+                h.mu.Lock()
+                delete(h.verifying, key)
+                h.mu.Unlock()
+                h.deferVerification(ctx, verification{
+                    checkResult: v.checkResult,
+                    check:       v.check,
+                    action:      next,
+                    ladder:      v.ladder[step:],
+                })
+                return true
+            }
+            if next.Status == StatusDryRun {
+                return false
+            }
+        }
+        if !escalated {
+            if len(v.ladder) > 0 {
+                fmt.Printf("🚨 Escalation exhausted for %s check on %s/%s/%s\n",
+                    v.check.CheckName, action.Namespace, action.PodName, action.ContainerName)
+            }
+            return false
+        }
+        action = next
+    }
+}
+
+// verifyCheck re-runs the check that triggered the action and returns the
+// verification status. When the pod was replaced, the check runs on the
+// replacement its controller created, once that is ready, and escalation
+// moves on to the replacement.
+func (h *AutoHealer) verifyCheck(ctx context.Context, v *verification, action HealingAction) (string, string) {
+    pod, err := h.diagEngine.cache.Pods.Pods(action.Namespace).Get(action.PodName)
+    if err != nil || pod.CreationTimestamp.Time.After(action.Timestamp) {
+        pod, err = h.replacement(v, action)
+        if err != nil {
+            return VerificationReplaced, err.Error()
+        }
+    }
+
+    check, err := h.diagEngine.rerunCheckOn(ctx, pod, action.ContainerName, v.check.CheckName)
+    if err != nil {
+        return VerificationUnresolved, err.Error()
+    }
+
+    on := ""
+    if pod.Name != action.PodName || pod.CreationTimestamp.Time.After(action.Timestamp) {
+        on = fmt.Sprintf(" on replacement %s", pod.Name)
+        v.checkResult.PodName = pod.Name
+    }
+    if check.Status == "OK" {
+        return VerificationVerified, fmt.Sprintf("%s check OK%s: %s", check.CheckName, on, check.Details)
+    }
+    return VerificationUnresolved, fmt.Sprintf("%s check %s%s: %s", check.CheckName, check.Status, on, check.Details)
+}
+
+// replacement finds the ready pod that replaced the healed one.
+func (h *AutoHealer) replacement(v *verification, action HealingAction) (*corev1.Pod, error) {
+    if v.owner == nil {
+        return nil, fmt.Errorf("pod %s/%s was replaced and has no controller to find its replacement", action.Namespace, action.PodName)
+    }
+    pod, err := h.diagEngine.replacementPod(v.owner, action.Namespace, action.ContainerName, action.Timestamp)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find replacement of pod %s/%s: %v", action.Namespace, action.PodName, err)
+    }
+    if pod == nil {
+        return nil, fmt.Errorf("pod %s/%s was replaced, but %s %s has no ready replacement yet",
+            action.Namespace, action.PodName, v.owner.Kind, v.owner.Name)
+    }
+    return pod, nil
+}
+
+func (h *AutoHealer) setVerification(id int64, status, details string) {
+    h.updateAction(id, func(a *HealingAction) error {
+        a.Verification = status
+        a.VerifyDetails = details
+        return nil
+    })
+}
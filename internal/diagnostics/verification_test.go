@@ -0,0 +1,530 @@
+package diagnostics
+
+import (
+    "context"
+    "errors"
+    "reflect"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "k8s-healer/internal/cache"
+    "k8s-healer/internal/config"
+    "k8s-healer/internal/eviction"
+    "k8s-healer/internal/policy"
+    "k8s-healer/internal/remediation"
+
+    corev1 "k8s.io/api/core/v1"
+    apierrors "k8s.io/apimachinery/pkg/api/errors"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/runtime"
+    "k8s.io/client-go/kubernetes/fake"
+    corelisters "k8s.io/client-go/listers/core/v1"
+    k8stesting "k8s.io/client-go/testing"
+    toolscache "k8s.io/client-go/tools/cache"
+)
+
+const testSettle = 10 * time.Millisecond
+
+// fakeCheck reports whatever status the test set last.
+type fakeCheck struct {
+    everyContainer
+    mu     sync.Mutex
+    status string
+}
+
+func (c *fakeCheck) Name() string {
+    return "Fake"
+}
+
+func (c *fakeCheck) set(status string) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.status = status
+}
+
+func (c *fakeCheck) Run(ctx context.Context, target CheckTarget) CheckOutcome {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return CheckOutcome{Status: c.status, Details: "fake check " + c.status}
+}
+
+func (c *fakeCheck) Response(outcome CheckOutcome) CheckResponse {
+    return CheckResponse{Severity: "HIGH", Remediation: "FIX_FAKE"}
+}
+
+// fakeRemediation runs execute, or completes when execute is nil.
+type fakeRemediation struct {
+    name    string
+    execute func(ctx context.Context, req remediation.Request) (remediation.Result, error)
+}
+
+func (r *fakeRemediation) Name() string                          { return r.name }
+func (r *fakeRemediation) Applies(req remediation.Request) bool { return true }
+func (r *fakeRemediation) Plan(ctx context.Context, req remediation.Request) (remediation.Plan, error) {
+    return remediation.Plan{Description: r.name, Steps: []string{r.name}}, nil
+}
+func (r *fakeRemediation) Execute(ctx context.Context, req remediation.Request) (remediation.Result, error) {
+    if r.execute == nil {
+        return remediation.Result{Status: remediation.StatusCompleted}, nil
+    }
+    return r.execute(ctx, req)
+}
+func (r *fakeRemediation) Verify(ctx context.Context, req remediation.Request) (bool, error) {
+    return true, nil
+}
+
+// evictRemediation evicts the pod like the ActionEngine's RESTART_POD does,
+// reporting a deferred eviction through the request's Done hook.
+func evictRemediation(evictor *eviction.Evictor) *fakeRemediation {
+    return &fakeRemediation{
+        name: "restart-pod",
+        execute: func(ctx context.Context, req remediation.Request) (remediation.Result, error) {
+            err := evictor.Evict(ctx, req.Namespace, req.PodName, req.Done)
+            if errors.Is(err, eviction.ErrBlockedByPDB) {
+                return remediation.Result{Status: remediation.StatusBlockedByPDB, Message: err.Error()}, nil
+            }
+            return remediation.Result{}, err
+        },
+    }
+}
+
+// evictionClient is a fake clientset whose evictions a PDB refuses while
+// blocked is set.
+func evictionClient(blocked *atomic.Bool) *fake.Clientset {
+    clientset := fake.NewSimpleClientset()
+    clientset.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+        if action.GetSubresource() != "eviction" {
+            return false, nil, nil
+        }
+        if blocked.Load() {
+            return true, nil, apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+        }
+        return true, nil, nil
+    })
+    return clientset
+}
+
+func testPod(name string, created time.Time) *corev1.Pod {
+    return &corev1.Pod{
+        ObjectMeta: metav1.ObjectMeta{
+            Namespace:         "shop",
+            Name:              name,
+            CreationTimestamp: metav1.NewTime(created),
+        },
+        Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+        Status: corev1.PodStatus{
+            ContainerStatuses: []corev1.ContainerStatus{{Name: "app", Ready: true}},
+        },
+    }
+}
+
+// podIndexer holds the pods of the healer's pod cache; tests replace pods in
+// it while a verification runs.
+func podIndexer(t *testing.T, pods ...*corev1.Pod) toolscache.Indexer {
+    t.Helper()
+
+    indexer := toolscache.NewIndexer(toolscache.MetaNamespaceKeyFunc, toolscache.Indexers{toolscache.NamespaceIndex: toolscache.MetaNamespaceIndexFunc})
+    for _, pod := range pods {
+        if err := indexer.Add(pod); err != nil {
+            t.Fatal(err)
+        }
+    }
+    return indexer
+}
+
+// testHealer builds an AutoHealer over a pod cache backed by pods and a
+// registry holding the given remediations, checking containers with check.
+func testHealer(t *testing.T, evictor *eviction.Evictor, check Check, escalation map[string][]string, remediations map[string]remediation.Remediation, pods toolscache.Indexer) *AutoHealer {
+    t.Helper()
+
+    engine := &DiagnosticsEngine{
+        cache:  &cache.Cache{Pods: corelisters.NewPodLister(pods)},
+        checks: NewCheckRegistry(),
+        mode:   config.DiagnosticsExec,
+    }
+    engine.checks.MustRegister(check)
+
+    registry := remediation.NewRegistry()
+    for action, rem := range remediations {
+        registry.MustRegister(rem, action)
+    }
+
+    return &AutoHealer{
+        diagEngine: engine,
+        evictor:    evictor,
+        registry:   registry,
+        policies:   policy.New(nil, nil, registry, 0, time.Hour),
+        history:    make([]HealingAction, 0),
+        settle:     testSettle,
+        escalation: escalation,
+        verifying:  make(map[string]bool),
+        deferred:   make(map[string][]deferredVerification),
+    }
+}
+
+func failedCheck(pod *corev1.Pod) ContainerCheckResult {
+    return ContainerCheckResult{
+        PodName:       pod.Name,
+        Namespace:     pod.Namespace,
+        ContainerName: "app",
+        Checks: []ContainerCheck{{
+            CheckName:   "Fake",
+            Status:      "CRITICAL",
+            Severity:    "HIGH",
+            Remediation: "FIX_FAKE",
+        }},
+        OverallStatus: "CRITICAL",
+        NeedsAction:   true,
+    }
+}
+
+func eventually(t *testing.T, what string, cond func() bool) {
+    t.Helper()
+
+    deadline := time.Now().Add(5 * time.Second)
+    for !cond() {
+        if time.Now().After(deadline) {
+            t.Fatalf("timed out waiting for %s", what)
+        }
+        time.Sleep(time.Millisecond)
+    }
+}
+
+func (h *AutoHealer) lastAction() HealingAction {
+    history := h.GetHealingHistory()
+    if len(history) == 0 {
+        return HealingAction{}
+    }
+    return history[len(history)-1]
+}
+
+func TestPDBBlockedRungResumesAfterDeferredEviction(t *testing.T) {
+    tests := []struct {
+        name             string
+        evicted          bool // whether the retried eviction goes through
+        wantStatus       string
+        wantVerification string
+    }{
+        {name: "eviction succeeds", evicted: true, wantStatus: StatusSucceeded, wantVerification: VerificationVerified},
+        {name: "evictor gives up", evicted: false, wantStatus: StatusFailed, wantVerification: ""},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            ctx, cancel := context.WithCancel(context.Background())
+            defer cancel()
+
+            var blocked atomic.Bool
+            blocked.Store(true)
+            evictor := eviction.NewWithBackoff(evictionClient(&blocked), eviction.Backoff{Attempts: 1})
+
+            pod := testPod("web-0", time.Now().Add(-time.Hour))
+            check := &fakeCheck{status: "CRITICAL"}
+            h := testHealer(t, evictor, check,
+                map[string][]string{"FIX_FAKE": {"RESTART_POD"}},
+                map[string]remediation.Remediation{
+                    "FIX_FAKE":    &fakeRemediation{name: "fix-fake"},
+                    "RESTART_POD": evictRemediation(evictor),
+                },
+                podIndexer(t, pod))
+
+            checkResult := failedCheck(pod)
+            key := verifyKey(checkResult, "Fake")
+            h.HealContainerIssues(ctx, []ContainerCheckResult{checkResult})
+
+            // FIX_FAKE leaves the check failing, so the ladder climbs to
+            // RESTART_POD, whose eviction the PDB refuses
+            eventually(t, "the blocked eviction to be deferred", func() bool {
+                return evictor.PendingCount() == 1
+            })
+            eventually(t, "the verification to be deferred", func() bool {
+                h.mu.Lock()
+                defer h.mu.Unlock()
+                return len(h.deferred[containerKey(checkResult)]) == 1
+            })
+            if !h.isVerifying(key) {
+                t.Fatal("check is not held back while its eviction is deferred")
+            }
+
+            if tt.evicted {
+                blocked.Store(false)
+                check.set("OK")
+            }
+            evictor.RetryDeferred(ctx)
+
+            eventually(t, "the verification to end", func() bool {
+                return !h.isVerifying(key)
+            })
+            if evictor.PendingCount() != 0 {
+                t.Errorf("PendingCount() = %d, want 0", evictor.PendingCount())
+            }
+            h.mu.Lock()
+            deferred := len(h.deferred)
+            h.mu.Unlock()
+            if deferred != 0 {
+                t.Errorf("%d deferred verifications left", deferred)
+            }
+
+            retried := h.lastAction()
+            if retried.ActionType != "RESTART_POD" || retried.Status != tt.wantStatus {
+                t.Errorf("retried eviction recorded as %s %s, want RESTART_POD %s", retried.ActionType, retried.Status, tt.wantStatus)
+            }
+            if retried.Verification != tt.wantVerification {
+                t.Errorf("Verification = %q, want %q", retried.Verification, tt.wantVerification)
+            }
+        })
+    }
+}
+
+// refusingLimiter refuses every slot for one remediation.
+type refusingLimiter struct {
+    refuse string
+}
+
+func (l refusingLimiter) Allow(ctx context.Context, name string, req remediation.Request) (bool, string) {
+    if name == l.refuse {
+        return false, "budget exhausted"
+    }
+    return true, ""
+}
+
+func (l refusingLimiter) Release(ctx context.Context, name string, req remediation.Request) {}
+
+func TestVerificationEscalation(t *testing.T) {
+    type wantAction struct {
+        action        string
+        status        string
+        verification  string
+        escalatedFrom string
+    }
+
+    tests := []struct {
+        name         string
+        fixedBy      string // the action that makes the check pass
+        limited      string // the remediation the budget refuses
+        blocked      string // the action whose eviction a PDB refuses
+        dryRun       bool   // rungs after the first fix run in dry-run
+        want         []wantAction
+        wantDeferred []string // ladder left to the deferred verification
+    }{
+        {
+            name:    "verified",
+            fixedBy: "FIX_FAKE",
+            want:    []wantAction{{"FIX_FAKE", StatusSucceeded, VerificationVerified, ""}},
+        },
+        {
+            name:    "climbs the ladder",
+            fixedBy: "RUNG_B",
+            want: []wantAction{
+                {"FIX_FAKE", StatusSucceeded, VerificationUnresolved, ""},
+                {"RUNG_A", StatusSucceeded, VerificationUnresolved, "FIX_FAKE"},
+                {"RUNG_B", StatusSucceeded, VerificationVerified, "RUNG_A"},
+            },
+        },
+        {
+            name:    "skips a rate-limited rung",
+            fixedBy: "RUNG_B",
+            limited: "rung-a",
+            want: []wantAction{
+                {"FIX_FAKE", StatusSucceeded, VerificationUnresolved, ""},
+                {"RUNG_B", StatusSucceeded, VerificationVerified, "FIX_FAKE"},
+            },
+        },
+        {
+            name: "ladder exhausted",
+            want: []wantAction{
+                {"FIX_FAKE", StatusSucceeded, VerificationUnresolved, ""},
+                {"RUNG_A", StatusSucceeded, VerificationUnresolved, "FIX_FAKE"},
+                {"RUNG_B", StatusSucceeded, VerificationUnresolved, "RUNG_A"},
+            },
+        },
+        {
+            name:   "dry-run rung stops",
+            dryRun: true,
+            want: []wantAction{
+                {"FIX_FAKE", StatusSucceeded, VerificationUnresolved, ""},
+                {"RUNG_A", StatusDryRun, "", "FIX_FAKE"},
+            },
+        },
+        {
+            name:    "PDB-blocked rung defers the rest",
+            blocked: "RUNG_A",
+            want: []wantAction{
+                {"FIX_FAKE", StatusSucceeded, VerificationUnresolved, ""},
+                {"RUNG_A", StatusPartial, "", "FIX_FAKE"},
+            },
+            wantDeferred: []string{"RUNG_B"},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            ctx, cancel := context.WithCancel(context.Background())
+            defer cancel()
+
+            pod := testPod("web-0", time.Now().Add(-time.Hour))
+            check := &fakeCheck{status: "CRITICAL"}
+
+            var h *AutoHealer
+            remediations := make(map[string]remediation.Remediation)
+            for _, action := range []string{"FIX_FAKE", "RUNG_A", "RUNG_B"} {
+                action := action
+                remediations[action] = &fakeRemediation{
+                    name: strings.ToLower(strings.ReplaceAll(action, "_", "-")),
+                    execute: func(ctx context.Context, req remediation.Request) (remediation.Result, error) {
+                        if action == tt.fixedBy {
+                            check.set("OK")
+                        }
+                        if action == "FIX_FAKE" && tt.dryRun {
+                            h.dryRun = true
+                        }
+                        if action == tt.blocked {
+                            return remediation.Result{Status: remediation.StatusBlockedByPDB}, nil
+                        }
+                        return remediation.Result{Status: remediation.StatusCompleted}, nil
+                    },
+                }
+            }
+            h = testHealer(t, nil, check,
+                map[string][]string{"FIX_FAKE": {"RUNG_A", "RUNG_B"}},
+                remediations,
+                podIndexer(t, pod))
+            h.registry.SetLimiter(refusingLimiter{refuse: tt.limited})
+
+            checkResult := failedCheck(pod)
+            key := verifyKey(checkResult, "Fake")
+            h.HealContainerIssues(ctx, []ContainerCheckResult{checkResult})
+
+            if tt.wantDeferred != nil {
+                eventually(t, "the verification to be deferred", func() bool {
+                    h.mu.Lock()
+                    defer h.mu.Unlock()
+                    return len(h.deferred[containerKey(checkResult)]) == 1
+                })
+                h.mu.Lock()
+                ladder := h.deferred[containerKey(checkResult)][0].v.ladder
+                h.mu.Unlock()
+                if !reflect.DeepEqual(ladder, tt.wantDeferred) {
+                    t.Errorf("deferred ladder = %v, want %v", ladder, tt.wantDeferred)
+                }
+                if !h.isVerifying(key) {
+                    t.Error("check is not held back while its eviction is deferred")
+                }
+            } else {
+                eventually(t, "the verification to end", func() bool {
+                    return !h.isVerifying(key)
+                })
+            }
+
+            var got []wantAction
+            for _, a := range h.GetHealingHistory() {
+                got = append(got, wantAction{a.ActionType, a.Status, a.Verification, a.EscalatedFrom})
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("history = %+v, want %+v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestVerificationOnReplacementPod(t *testing.T) {
+    tests := []struct {
+        name             string
+        owned            bool
+        replacement      string // name of the pod that replaces web-0, if any
+        ready            bool
+        wantVerification string
+        wantDetails      string
+    }{
+        {
+            name:             "ready replacement",
+            owned:            true,
+            replacement:      "web-1",
+            ready:            true,
+            wantVerification: VerificationVerified,
+            wantDetails:      "on replacement web-1",
+        },
+        {
+            name:             "recreated under the same name",
+            owned:            true,
+            replacement:      "web-0",
+            ready:            true,
+            wantVerification: VerificationVerified,
+            wantDetails:      "on replacement web-0",
+        },
+        {
+            name:             "replacement not ready",
+            owned:            true,
+            replacement:      "web-1",
+            wantVerification: VerificationReplaced,
+            wantDetails:      "has no ready replacement yet",
+        },
+        {
+            name:             "no controller",
+            wantVerification: VerificationReplaced,
+            wantDetails:      "has no controller",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            ctx, cancel := context.WithCancel(context.Background())
+            defer cancel()
+
+            controller := true
+            owner := metav1.OwnerReference{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "web", UID: "web-uid", Controller: &controller}
+            pod := testPod("web-0", time.Now().Add(-time.Hour))
+            if tt.owned {
+                pod.OwnerReferences = []metav1.OwnerReference{owner}
+            }
+            pods := podIndexer(t, pod)
+
+            // The check passes wherever it runs, so only the pod it finds
+            // decides the outcome
+            check := &fakeCheck{status: "OK"}
+            h := testHealer(t, nil, check,
+                map[string][]string{"FIX_FAKE": {"RUNG_A"}},
+                map[string]remediation.Remediation{
+                    "FIX_FAKE": &fakeRemediation{name: "fix-fake"},
+                    "RUNG_A":   &fakeRemediation{name: "rung-a"},
+                },
+                pods)
+            h.settle = 200 * time.Millisecond
+
+            checkResult := failedCheck(pod)
+            key := verifyKey(checkResult, "Fake")
+            h.HealContainerIssues(ctx, []ContainerCheckResult{checkResult})
+
+            // The pod is replaced while the fix settles
+            if err := pods.Delete(pod); err != nil {
+                t.Fatal(err)
+            }
+            if tt.replacement != "" {
+                replacement := testPod(tt.replacement, time.Now().Add(time.Millisecond))
+                replacement.OwnerReferences = []metav1.OwnerReference{owner}
+                replacement.Status.ContainerStatuses[0].Ready = tt.ready
+                if err := pods.Add(replacement); err != nil {
+                    t.Fatal(err)
+                }
+            }
+
+            eventually(t, "the verification to end", func() bool {
+                return !h.isVerifying(key)
+            })
+
+            history := h.GetHealingHistory()
+            if len(history) != 1 {
+                t.Fatalf("%d actions recorded, want only the fix", len(history))
+            }
+            if history[0].Verification != tt.wantVerification {
+                t.Errorf("Verification = %q (%s), want %q", history[0].Verification, history[0].VerifyDetails, tt.wantVerification)
+            }
+            if !strings.Contains(history[0].VerifyDetails, tt.wantDetails) {
+                t.Errorf("VerifyDetails = %q, want it to mention %q", history[0].VerifyDetails, tt.wantDetails)
+            }
+        })
+    }
+}
@@ -0,0 +1,199 @@
+package eviction
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "sync"
+    "time"
+
+    policyv1 "k8s.io/api/policy/v1"
+    apierrors "k8s.io/apimachinery/pkg/api/errors"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/client-go/kubernetes"
+    "k8s.io/client-go/util/retry"
+)
+
+var (
+    ErrBlockedByPDB = errors.New("eviction would violate a PodDisruptionBudget")
+    // ErrPodNotFound means the pod was already gone, so nothing was evicted.
+    ErrPodNotFound = errors.New("pod not found")
+)
+
+// Backoff is how evictions refused by a PDB are retried: after Initial,
+// doubling up to Max, for at most Attempts tries in total.
+type Backoff struct {
+    Initial  time.Duration
+    Max      time.Duration
+    Attempts int
+}
+
+var DefaultBackoff = Backoff{
+    Initial:  30 * time.Second,
+    Max:      10 * time.Minute,
+    Attempts: 6,
+}
+
+// Evictor restarts pods through the policy/v1 Eviction subresource so
+// PodDisruptionBudgets are respected. Evictions refused by a PDB are
+// deferred and retried with backoff from RetryDeferred.
+type Evictor struct {
+    clientset kubernetes.Interface
+    backoff   Backoff
+    deferred  map[string]*deferredEviction
+    mu        sync.Mutex
+}
+
+type deferredEviction struct {
+    namespace   string
+    name        string
+    attempts    int
+    nextAttempt time.Time
+    onDone      []func(err error)
+}
+
+func New(clientset kubernetes.Interface) *Evictor {
+    return NewWithBackoff(clientset, DefaultBackoff)
+}
+
+func NewWithBackoff(clientset kubernetes.Interface, backoff Backoff) *Evictor {
+    return &Evictor{
+        clientset: clientset,
+        backoff:   backoff,
+        deferred:  make(map[string]*deferredEviction),
+    }
+}
+
+// Evict requests eviction of the pod. If a PDB blocks it, ErrBlockedByPDB is
+// returned and the eviction is queued; onDone is called with the final
+// outcome once a later retry succeeds, fails or gives up. Callers that ask
+// for a pod whose eviction is already queued are all called back.
+func (e *Evictor) Evict(ctx context.Context, namespace, name string, onDone func(err error)) error {
+    err := e.evict(ctx, namespace, name)
+    if !errors.Is(err, ErrBlockedByPDB) {
+        return err
+    }
+
+    key := fmt.Sprintf("%s/%s", namespace, name)
+    e.mu.Lock()
+    d, exists := e.deferred[key]
+    if !exists {
+        d = &deferredEviction{
+            namespace:   namespace,
+            name:        name,
+            attempts:    1,
+            nextAttempt: time.Now().Add(e.backoff.Initial),
+        }
+        e.deferred[key] = d
+    }
+    if onDone != nil {
+        d.onDone = append(d.onDone, onDone)
+    }
+    e.mu.Unlock()
+
+    return err
+}
+
+func (e *Evictor) evict(ctx context.Context, namespace, name string) error {
+    eviction := &policyv1.Eviction{
+        ObjectMeta: metav1.ObjectMeta{
+            Name:      name,
+            Namespace: namespace,
+        },
+    }
+
+    // Any other 429 is API server throttling and worth retrying right away
+    var err error
+    retryErr := retry.OnError(retry.DefaultBackoff, func(err error) bool {
+        return apierrors.IsTooManyRequests(err) && !blockedByPDB(err)
+    }, func() error {
+        err = e.clientset.PolicyV1().Evictions(namespace).Evict(ctx, eviction)
+        return err
+    })
+    if retryErr != nil {
+        err = retryErr
+    }
+
+    switch {
+    case err == nil:
+        return nil
+    case apierrors.IsNotFound(err):
+        return fmt.Errorf("%w: %s/%s", ErrPodNotFound, namespace, name)
+    case blockedByPDB(err):
+        return fmt.Errorf("%w: %v", ErrBlockedByPDB, err)
+    default:
+        return err
+    }
+}
+
+// blockedByPDB reports whether a 429 from the Eviction API was caused by a
+// PodDisruptionBudget rather than by API server throttling.
+func blockedByPDB(err error) bool {
+    var status apierrors.APIStatus
+    if !apierrors.IsTooManyRequests(err) || !errors.As(err, &status) {
+        return false
+    }
+
+    s := status.Status()
+    if s.Details != nil {
+        for _, cause := range s.Details.Causes {
+            if cause.Type == policyv1.DisruptionBudgetCause {
+                return true
+            }
+        }
+    }
+    return strings.Contains(strings.ToLower(s.Message), "disruption budget")
+}
+
+// RetryDeferred retries every deferred eviction that is due.
+func (e *Evictor) RetryDeferred(ctx context.Context) {
+    now := time.Now()
+
+    e.mu.Lock()
+    var due []*deferredEviction
+    for _, d := range e.deferred {
+        if !now.Before(d.nextAttempt) {
+            due = append(due, d)
+        }
+    }
+    e.mu.Unlock()
+
+    for _, d := range due {
+        key := fmt.Sprintf("%s/%s", d.namespace, d.name)
+        err := e.evict(ctx, d.namespace, d.name)
+
+        e.mu.Lock()
+        d.attempts++
+        if errors.Is(err, ErrBlockedByPDB) && d.attempts < e.backoff.Attempts {
+            delay := e.backoff.Initial << (d.attempts - 1)
+            if delay > e.backoff.Max {
+                delay = e.backoff.Max
+            }
+            d.nextAttempt = now.Add(delay)
+            e.mu.Unlock()
+            fmt.Printf("⏳ Eviction of %s still blocked by PDB - retry %d/%d in %v\n", key, d.attempts, e.backoff.Attempts, delay)
+            continue
+        }
+        delete(e.deferred, key)
+        callbacks := d.onDone
+        e.mu.Unlock()
+
+        if err == nil {
+            fmt.Printf("🔄 Deferred eviction of %s succeeded\n", key)
+        } else if errors.Is(err, ErrPodNotFound) {
+            fmt.Printf("⏭️  Deferred eviction of %s dropped: pod is gone\n", key)
+        } else {
+            fmt.Printf("❌ Deferred eviction of %s gave up: %v\n", key, err)
+        }
+        for _, onDone := range callbacks {
+            onDone(err)
+        }
+    }
+}
+
+func (e *Evictor) PendingCount() int {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    return len(e.deferred)
+}
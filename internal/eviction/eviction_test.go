@@ -0,0 +1,232 @@
+package eviction
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "testing"
+    "time"
+
+    policyv1 "k8s.io/api/policy/v1"
+    apierrors "k8s.io/apimachinery/pkg/api/errors"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/runtime"
+    "k8s.io/apimachinery/pkg/runtime/schema"
+    "k8s.io/client-go/kubernetes/fake"
+    k8stesting "k8s.io/client-go/testing"
+)
+
+var (
+    pdbCause = &apierrors.StatusError{ErrStatus: metav1.Status{
+        Status:  metav1.StatusFailure,
+        Code:    429,
+        Reason:  metav1.StatusReasonTooManyRequests,
+        Message: "Cannot evict pod",
+        Details: &metav1.StatusDetails{Causes: []metav1.StatusCause{{Type: policyv1.DisruptionBudgetCause}}},
+    }}
+    pdbMessage  = apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+    throttled   = apierrors.NewTooManyRequests("too many requests, please try again later", 0)
+    notFound    = apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, "web-0")
+    serverError = apierrors.NewInternalError(errors.New("etcd unavailable"))
+)
+
+// fakeEvictions answers evictions with the errors in turn, repeating the last
+// one, and counts the calls.
+type fakeEvictions struct {
+    mu    sync.Mutex
+    errs  []error
+    calls int
+}
+
+func (f *fakeEvictions) set(errs ...error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.errs = errs
+}
+
+func (f *fakeEvictions) count() int {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    return f.calls
+}
+
+func (f *fakeEvictions) clientset() *fake.Clientset {
+    clientset := fake.NewSimpleClientset()
+    clientset.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+        if action.GetSubresource() != "eviction" {
+            return false, nil, nil
+        }
+        f.mu.Lock()
+        defer f.mu.Unlock()
+        f.calls++
+        var err error
+        if len(f.errs) > 0 {
+            err = f.errs[0]
+            if len(f.errs) > 1 {
+                f.errs = f.errs[1:]
+            }
+        }
+        return true, nil, err
+    })
+    return clientset
+}
+
+func TestEvict(t *testing.T) {
+    tests := []struct {
+        name         string
+        errs         []error
+        wantErr      error // matched with errors.Is; nil for success
+        wantAPIError bool  // an error that is neither sentinel
+        wantCalls    int
+        wantDeferred bool
+    }{
+        {name: "evicted", wantCalls: 1},
+        {name: "PDB cause", errs: []error{pdbCause}, wantErr: ErrBlockedByPDB, wantCalls: 1, wantDeferred: true},
+        {name: "PDB message", errs: []error{pdbMessage}, wantErr: ErrBlockedByPDB, wantCalls: 1, wantDeferred: true},
+        {name: "throttled once", errs: []error{throttled, nil}, wantCalls: 2},
+        {name: "throttled throughout", errs: []error{throttled}, wantAPIError: true, wantCalls: 4},
+        {name: "pod gone", errs: []error{notFound}, wantErr: ErrPodNotFound, wantCalls: 1},
+        {name: "server error", errs: []error{serverError}, wantAPIError: true, wantCalls: 1},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            api := &fakeEvictions{errs: tt.errs}
+            e := New(api.clientset())
+
+            err := e.Evict(context.Background(), "shop", "web-0", nil)
+            switch {
+            case tt.wantErr != nil:
+                if !errors.Is(err, tt.wantErr) {
+                    t.Errorf("Evict() error = %v, want %v", err, tt.wantErr)
+                }
+            case tt.wantAPIError:
+                if err == nil || errors.Is(err, ErrBlockedByPDB) || errors.Is(err, ErrPodNotFound) {
+                    t.Errorf("Evict() error = %v, want the API error", err)
+                }
+            case err != nil:
+                t.Errorf("Evict() error = %v, want nil", err)
+            }
+
+            if api.count() != tt.wantCalls {
+                t.Errorf("Eviction API called %d times, want %d", api.count(), tt.wantCalls)
+            }
+            if deferred := e.PendingCount() == 1; deferred != tt.wantDeferred {
+                t.Errorf("PendingCount() = %d, want deferred %v", e.PendingCount(), tt.wantDeferred)
+            }
+        })
+    }
+}
+
+// makeDue lets the next RetryDeferred retry the pod's eviction right away and
+// returns the delay it had been given.
+func makeDue(e *Evictor, key string) time.Duration {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+
+    d := e.deferred[key]
+    delay := time.Until(d.nextAttempt)
+    d.nextAttempt = time.Now()
+    return delay
+}
+
+func TestRetryDeferred(t *testing.T) {
+    tests := []struct {
+        name        string
+        retryErrs   []error // answers to the retries, the last one repeated
+        wantRetries int
+        wantDelays  []time.Duration
+        wantErr     error
+    }{
+        {
+            name:        "evicted on the first retry",
+            retryErrs:   []error{nil},
+            wantRetries: 1,
+            wantDelays:  []time.Duration{time.Minute},
+        },
+        {
+            name:        "evicted once the PDB allows it",
+            retryErrs:   []error{pdbCause, nil},
+            wantRetries: 2,
+            wantDelays:  []time.Duration{time.Minute, 2 * time.Minute},
+        },
+        {
+            name:        "pod gone meanwhile",
+            retryErrs:   []error{notFound},
+            wantRetries: 1,
+            wantDelays:  []time.Duration{time.Minute},
+            wantErr:     ErrPodNotFound,
+        },
+        {
+            name:        "gives up with the delay capped",
+            retryErrs:   []error{pdbCause},
+            wantRetries: 3,
+            wantDelays:  []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute},
+            wantErr:     ErrBlockedByPDB,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            ctx := context.Background()
+            api := &fakeEvictions{errs: []error{pdbCause}}
+            e := NewWithBackoff(api.clientset(), Backoff{Initial: time.Minute, Max: 3 * time.Minute, Attempts: 4})
+
+            var mu sync.Mutex
+            var outcomes []error
+            onDone := func(err error) {
+                mu.Lock()
+                defer mu.Unlock()
+                outcomes = append(outcomes, err)
+            }
+
+            // Both callers are told the outcome of the one deferred eviction
+            for i := 0; i < 2; i++ {
+                if err := e.Evict(ctx, "shop", "web-0", onDone); !errors.Is(err, ErrBlockedByPDB) {
+                    t.Fatalf("Evict() error = %v, want %v", err, ErrBlockedByPDB)
+                }
+            }
+            if e.PendingCount() != 1 {
+                t.Fatalf("PendingCount() = %d, want 1", e.PendingCount())
+            }
+
+            // Nothing is retried before it is due
+            calls := api.count()
+            e.RetryDeferred(ctx)
+            if api.count() != calls {
+                t.Fatal("RetryDeferred() retried an eviction that was not due yet")
+            }
+
+            api.set(tt.retryErrs...)
+            var delays []time.Duration
+            for e.PendingCount() > 0 && len(delays) < 10 {
+                delays = append(delays, makeDue(e, "shop/web-0").Round(time.Minute))
+                e.RetryDeferred(ctx)
+            }
+
+            if retries := api.count() - calls; retries != tt.wantRetries {
+                t.Errorf("retried %d times, want %d", retries, tt.wantRetries)
+            }
+            if len(delays) != len(tt.wantDelays) {
+                t.Fatalf("delays = %v, want %v", delays, tt.wantDelays)
+            }
+            for i := range delays {
+                if delays[i] != tt.wantDelays[i] {
+                    t.Errorf("delays = %v, want %v", delays, tt.wantDelays)
+                    break
+                }
+            }
+
+            mu.Lock()
+            defer mu.Unlock()
+            if len(outcomes) != 2 {
+                t.Fatalf("onDone called %d times, want 2", len(outcomes))
+            }
+            for _, err := range outcomes {
+                if (tt.wantErr == nil && err != nil) || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+                    t.Errorf("onDone(%v), want %v", err, tt.wantErr)
+                }
+            }
+        })
+    }
+}
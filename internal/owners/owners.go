@@ -0,0 +1,86 @@
+package owners
+
+import (
+    "context"
+    "fmt"
+
+    "k8s-healer/internal/cache"
+
+    corev1 "k8s.io/api/core/v1"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/client-go/kubernetes"
+)
+
+// Workload is the top-level controller that owns a pod.
+type Workload struct {
+    Kind       string
+    Name       string
+    Namespace  string
+    APIVersion string
+}
+
+func (w Workload) String() string {
+    return fmt.Sprintf("%s %s/%s", w.Kind, w.Namespace, w.Name)
+}
+
+// Resolver walks ownerReferences from a pod up to the workload that manages
+// it: Pod → ReplicaSet → Deployment, Pod → Job → CronJob, or directly to a
+// StatefulSet, DaemonSet or any other controller.
+type Resolver struct {
+    clientset *kubernetes.Clientset
+    cache     *cache.Cache
+}
+
+func NewResolver(clientset *kubernetes.Clientset, kubeCache *cache.Cache) *Resolver {
+    return &Resolver{
+        clientset: clientset,
+        cache:     kubeCache,
+    }
+}
+
+func (r *Resolver) Resolve(ctx context.Context, namespace, podName string) (*Workload, error) {
+    pod, err := r.cache.Pods.Pods(namespace).Get(podName)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get pod %s/%s: %v", namespace, podName, err)
+    }
+    return r.ResolvePod(ctx, pod)
+}
+
+func (r *Resolver) ResolvePod(ctx context.Context, pod *corev1.Pod) (*Workload, error) {
+    ref := metav1.GetControllerOf(pod)
+    if ref == nil {
+        return nil, fmt.Errorf("pod %s/%s has no controller owner", pod.Namespace, pod.Name)
+    }
+
+    workload := fromRef(ref, pod.Namespace)
+
+    switch ref.Kind {
+    case "ReplicaSet":
+        rs, err := r.cache.ReplicaSets.ReplicaSets(pod.Namespace).Get(ref.Name)
+        if err != nil {
+            return nil, fmt.Errorf("failed to get replicaset %s/%s: %v", pod.Namespace, ref.Name, err)
+        }
+        if parent := metav1.GetControllerOf(rs); parent != nil {
+            workload = fromRef(parent, pod.Namespace)
+        }
+    case "Job":
+        job, err := r.clientset.BatchV1().Jobs(pod.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+        if err != nil {
+            return nil, fmt.Errorf("failed to get job %s/%s: %v", pod.Namespace, ref.Name, err)
+        }
+        if parent := metav1.GetControllerOf(job); parent != nil {
+            workload = fromRef(parent, pod.Namespace)
+        }
+    }
+
+    return workload, nil
+}
+
+func fromRef(ref *metav1.OwnerReference, namespace string) *Workload {
+    return &Workload{
+        Kind:       ref.Kind,
+        Name:       ref.Name,
+        Namespace:  namespace,
+        APIVersion: ref.APIVersion,
+    }
+}
@@ -0,0 +1,301 @@
+package policy
+
+import (
+    "fmt"
+    "sort"
+    "sync"
+    "time"
+
+    "k8s-healer/internal/remediation"
+
+    apierrors "k8s.io/apimachinery/pkg/api/errors"
+    "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+    "k8s.io/apimachinery/pkg/runtime"
+    "k8s.io/apimachinery/pkg/runtime/schema"
+    "k8s.io/client-go/discovery"
+    "k8s.io/client-go/dynamic"
+    "k8s.io/client-go/dynamic/dynamicinformer"
+    toolscache "k8s.io/client-go/tools/cache"
+)
+
+// BuiltinPolicy names the decision used when no HealingPolicy rule matches
+// and the detector's own suggestion is followed.
+const BuiltinPolicy = "builtin"
+
+// Store watches HealingPolicy and ClusterHealingPolicy resources and resolves
+// detections to remediations. Without the CRDs installed every detection
+// falls back to the built-in mapping.
+type Store struct {
+    dynamic   dynamic.Interface
+    discovery discovery.DiscoveryInterface
+    registry  *remediation.Registry
+    resync    time.Duration
+    window    time.Duration
+
+    mu       sync.RWMutex
+    policies map[string]*compiledPolicy
+    invalid  map[string]string
+    usage    map[string][]time.Time
+}
+
+// PolicyStatus is the validation state of one policy, as served by the API.
+type PolicyStatus struct {
+    Policy string `json:"policy"`
+    Valid  bool   `json:"valid"`
+    Rules  int    `json:"rules,omitempty"`
+    Error  string `json:"error,omitempty"`
+}
+
+func New(dynamicClient dynamic.Interface, discoveryClient discovery.DiscoveryInterface, registry *remediation.Registry, resync, window time.Duration) *Store {
+    return &Store{
+        dynamic:   dynamicClient,
+        discovery: discoveryClient,
+        registry:  registry,
+        resync:    resync,
+        window:    window,
+        policies:  make(map[string]*compiledPolicy),
+        invalid:   make(map[string]string),
+        usage:     make(map[string][]time.Time),
+    }
+}
+
+// Start watches the policy resources and blocks until they have synced. It is
+// a no-op when the CRDs are not installed.
+func (s *Store) Start(stopCh <-chan struct{}) error {
+    if s.dynamic == nil {
+        return nil
+    }
+
+    installed, err := s.crdsInstalled()
+    if err != nil {
+        return err
+    }
+    if !installed {
+        fmt.Printf("ℹ️  HealingPolicy CRDs not installed - using built-in healing mappings\n")
+        return nil
+    }
+
+    factory := dynamicinformer.NewDynamicSharedInformerFactory(s.dynamic, s.resync)
+    for _, gvr := range []schema.GroupVersionResource{HealingPolicyResource, ClusterHealingPolicyResource} {
+        clusterScoped := gvr == ClusterHealingPolicyResource
+        informer := factory.ForResource(gvr).Informer()
+        _, err := informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+            AddFunc: func(obj interface{}) {
+                s.upsert(obj, clusterScoped)
+            },
+            UpdateFunc: func(_, obj interface{}) {
+                s.upsert(obj, clusterScoped)
+            },
+            DeleteFunc: func(obj interface{}) {
+                s.remove(obj, clusterScoped)
+            },
+        })
+        if err != nil {
+            return fmt.Errorf("failed to watch %s: %v", gvr.Resource, err)
+        }
+    }
+
+    factory.Start(stopCh)
+    for gvr, synced := range factory.WaitForCacheSync(stopCh) {
+        if !synced {
+            return fmt.Errorf("failed to sync %s", gvr.Resource)
+        }
+    }
+    return nil
+}
+
+func (s *Store) crdsInstalled() (bool, error) {
+    resources, err := s.discovery.ServerResourcesForGroupVersion(Group + "/" + Version)
+    if apierrors.IsNotFound(err) {
+        return false, nil
+    }
+    if err != nil {
+        return false, fmt.Errorf("failed to discover %s/%s: %v", Group, Version, err)
+    }
+
+    found := 0
+    for _, r := range resources.APIResources {
+        if r.Name == HealingPolicyResource.Resource || r.Name == ClusterHealingPolicyResource.Resource {
+            found++
+        }
+    }
+    return found == 2, nil
+}
+
+func (s *Store) upsert(obj interface{}, clusterScoped bool) {
+    u, ok := obj.(*unstructured.Unstructured)
+    if !ok {
+        return
+    }
+
+    var hp HealingPolicy
+    key := policyKey(u.GetNamespace(), u.GetName(), clusterScoped)
+    err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &hp)
+
+    var cp *compiledPolicy
+    if err == nil {
+        cp, err = compile(&hp, clusterScoped, s.registry)
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if err != nil {
+        fmt.Printf("⚠️  Ignoring invalid %s: %v\n", key, err)
+        delete(s.policies, key)
+        s.invalid[key] = err.Error()
+        return
+    }
+
+    if _, exists := s.policies[key]; !exists {
+        fmt.Printf("📜 Loaded %s (%d rules)\n", key, len(cp.rules))
+    }
+    delete(s.invalid, key)
+    s.policies[key] = cp
+}
+
+func (s *Store) remove(obj interface{}, clusterScoped bool) {
+    if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+        obj = tombstone.Obj
+    }
+    u, ok := obj.(*unstructured.Unstructured)
+    if !ok {
+        return
+    }
+
+    key := policyKey(u.GetNamespace(), u.GetName(), clusterScoped)
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    delete(s.policies, key)
+    delete(s.invalid, key)
+    fmt.Printf("📜 Removed %s\n", key)
+}
+
+func policyKey(namespace, name string, clusterScoped bool) string {
+    if clusterScoped {
+        return fmt.Sprintf("ClusterHealingPolicy %s", name)
+    }
+    return fmt.Sprintf("HealingPolicy %s/%s", namespace, name)
+}
+
+// Resolve picks the remediation for a detection. Namespaced policies take
+// precedence over cluster policies; within a scope policies are tried in name
+// order and the first matching rule wins. Without a match the detector's
+// suggestion is used. ok is false when nothing should be done.
+func (s *Store) Resolve(d Detection) (Decision, bool) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    var namespaced, cluster []*compiledPolicy
+    for _, cp := range s.policies {
+        if cp.namespace != "" {
+            namespaced = append(namespaced, cp)
+        } else {
+            cluster = append(cluster, cp)
+        }
+    }
+
+    for _, scope := range [][]*compiledPolicy{namespaced, cluster} {
+        sort.Slice(scope, func(i, j int) bool { return scope[i].key < scope[j].key })
+        for _, cp := range scope {
+            if !cp.selects(d) {
+                continue
+            }
+            for _, rule := range cp.rules {
+                if !rule.matches(d) {
+                    continue
+                }
+                decision := Decision{
+                    Policy:      cp.key,
+                    Rule:        rule.Name,
+                    Remediation: rule.Remediation,
+                    DryRun:      cp.dryRun || rule.DryRun,
+                    MaxActions:  rule.MaxActions,
+                    Cooldown:    rule.cooldown,
+                }
+                return decision, rule.Remediation != RemediationNone
+            }
+        }
+    }
+
+    if d.Suggested == "" {
+        return Decision{}, false
+    }
+    return Decision{
+        Policy:      BuiltinPolicy,
+        Rule:        d.Kind,
+        Remediation: d.Suggested,
+    }, true
+}
+
+// Allow reports whether the decision's rule may act on target again, given
+// its cooldown and maxActions within the action budget window.
+func (s *Store) Allow(decision Decision, target string) (bool, string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    key := decision.key() + "|" + target
+    times := s.recent(key, time.Now())
+    if len(times) == 0 {
+        return true, ""
+    }
+    if decision.MaxActions > 0 && len(times) >= decision.MaxActions {
+        return false, fmt.Sprintf("max actions reached (%d per %v) by %s rule %s", decision.MaxActions, s.window, decision.Policy, decision.Rule)
+    }
+    if decision.Cooldown > 0 {
+        if remaining := decision.Cooldown - time.Since(times[len(times)-1]); remaining > 0 {
+            return false, fmt.Sprintf("cooling down for %v by %s rule %s", remaining.Round(time.Second), decision.Policy, decision.Rule)
+        }
+    }
+    return true, ""
+}
+
+// Record counts an action taken under the decision's rule. Rules without
+// limits, such as the built-in mapping, are not tracked.
+func (s *Store) Record(decision Decision, target string) {
+    if decision.MaxActions == 0 && decision.Cooldown == 0 {
+        return
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    now := time.Now()
+    key := decision.key() + "|" + target
+    s.usage[key] = append(s.recent(key, now), now)
+}
+
+// recent drops actions older than the window. The last action is kept as
+// long as a rule cooldown could still need it.
+func (s *Store) recent(key string, now time.Time) []time.Time {
+    times := s.usage[key]
+    kept := times[:0]
+    for _, t := range times {
+        if now.Sub(t) < s.window {
+            kept = append(kept, t)
+        }
+    }
+    if len(kept) == 0 && len(times) > 0 {
+        kept = append(kept, times[len(times)-1])
+    }
+    s.usage[key] = kept
+    return kept
+}
+
+// Policies returns the validation state of every watched policy.
+func (s *Store) Policies() []PolicyStatus {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    statuses := make([]PolicyStatus, 0, len(s.policies)+len(s.invalid))
+    for key, cp := range s.policies {
+        statuses = append(statuses, PolicyStatus{Policy: key, Valid: true, Rules: len(cp.rules)})
+    }
+    for key, reason := range s.invalid {
+        statuses = append(statuses, PolicyStatus{Policy: key, Error: reason})
+    }
+    sort.Slice(statuses, func(i, j int) bool { return statuses[i].Policy < statuses[j].Policy })
+    return statuses
+}
@@ -0,0 +1,264 @@
+package policy
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "k8s-healer/internal/remediation"
+
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// nopRemediation only exists so rules can name registered actions.
+type nopRemediation struct{}
+
+func (nopRemediation) Name() string                          { return "nop" }
+func (nopRemediation) Applies(req remediation.Request) bool { return true }
+func (nopRemediation) Plan(ctx context.Context, req remediation.Request) (remediation.Plan, error) {
+    return remediation.Plan{}, nil
+}
+func (nopRemediation) Execute(ctx context.Context, req remediation.Request) (remediation.Result, error) {
+    return remediation.Result{}, nil
+}
+func (nopRemediation) Verify(ctx context.Context, req remediation.Request) (bool, error) {
+    return true, nil
+}
+
+func testStore(t *testing.T, policies ...*HealingPolicy) *Store {
+    t.Helper()
+
+    registry := remediation.NewRegistry()
+    registry.MustRegister(nopRemediation{}, "RESTART_POD", "SCALE_UP", "CLEANUP_TMP", "ROLLOUT_RESTART")
+
+    s := New(nil, nil, registry, 0, time.Hour)
+    for _, hp := range policies {
+        clusterScoped := hp.Namespace == ""
+        cp, err := compile(hp, clusterScoped, registry)
+        if err != nil {
+            t.Fatalf("compile %s: %v", hp.Name, err)
+        }
+        s.policies[cp.key] = cp
+    }
+    return s
+}
+
+func policyOf(namespace, name string, spec HealingPolicySpec) *HealingPolicy {
+    return &HealingPolicy{
+        ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+        Spec:       spec,
+    }
+}
+
+func TestResolve(t *testing.T) {
+    cluster := policyOf("", "cluster", HealingPolicySpec{
+        Namespaces: []string{"shop"},
+        Rules: []Rule{
+            {Name: "critical-restart", Match: Match{Risks: []string{"CRITICAL"}}, Remediation: "RESTART_POD"},
+            {Name: "tmp", Match: Match{Check: "Temp Directory", CheckStatuses: []string{"WARNING"}}, Remediation: "CLEANUP_TMP"},
+            {Name: "oom", Match: Match{RestartPattern: "OOM_KILLED"}, Remediation: "SCALE_UP"},
+        },
+    })
+    namespaced := policyOf("shop", "web-only", HealingPolicySpec{
+        PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+        Rules: []Rule{
+            {Name: "leave-web", Match: Match{PredictedActions: []string{"RESTART_POD"}}, Remediation: RemediationNone},
+        },
+    })
+    s := testStore(t, cluster, namespaced)
+
+    tests := []struct {
+        name      string
+        detection Detection
+        wantOK    bool
+        want      Decision
+    }{
+        {
+            name:      "cluster rule by risk",
+            detection: Detection{Kind: KindPrediction, Name: "SCALE_UP", Status: "critical", Suggested: "SCALE_UP", Namespace: "shop"},
+            wantOK:    true,
+            want:      Decision{Policy: "ClusterHealingPolicy cluster", Rule: "critical-restart", Remediation: "RESTART_POD"},
+        },
+        {
+            name:      "namespaced policy takes precedence",
+            detection: Detection{Kind: KindPrediction, Name: "RESTART_POD", Status: "CRITICAL", Suggested: "RESTART_POD", Namespace: "shop", Labels: map[string]string{"app": "web"}},
+            wantOK:    false,
+            want:      Decision{Policy: "HealingPolicy shop/web-only", Rule: "leave-web", Remediation: RemediationNone},
+        },
+        {
+            name:      "pod selector does not match",
+            detection: Detection{Kind: KindPrediction, Name: "RESTART_POD", Status: "CRITICAL", Suggested: "RESTART_POD", Namespace: "shop", Labels: map[string]string{"app": "db"}},
+            wantOK:    true,
+            want:      Decision{Policy: "ClusterHealingPolicy cluster", Rule: "critical-restart", Remediation: "RESTART_POD"},
+        },
+        {
+            name:      "check status matches",
+            detection: Detection{Kind: KindCheck, Name: "temp directory", Status: "WARNING", Suggested: "", Namespace: "shop"},
+            wantOK:    true,
+            want:      Decision{Policy: "ClusterHealingPolicy cluster", Rule: "tmp", Remediation: "CLEANUP_TMP"},
+        },
+        {
+            name:      "check status does not match",
+            detection: Detection{Kind: KindCheck, Name: "Temp Directory", Status: "CRITICAL", Suggested: "CLEANUP_TMP", Namespace: "shop"},
+            wantOK:    true,
+            want:      Decision{Policy: BuiltinPolicy, Rule: KindCheck, Remediation: "CLEANUP_TMP"},
+        },
+        {
+            name:      "restart pattern",
+            detection: Detection{Kind: KindRestartPattern, Name: "OOM_KILLED", Status: "HIGH", Namespace: "shop"},
+            wantOK:    true,
+            want:      Decision{Policy: "ClusterHealingPolicy cluster", Rule: "oom", Remediation: "SCALE_UP"},
+        },
+        {
+            name:      "builtin fallback outside the policy's namespaces",
+            detection: Detection{Kind: KindPrediction, Name: "SCALE_UP", Status: "CRITICAL", Suggested: "SCALE_UP", Namespace: "other"},
+            wantOK:    true,
+            want:      Decision{Policy: BuiltinPolicy, Rule: KindPrediction, Remediation: "SCALE_UP"},
+        },
+        {
+            name:      "no rule and no suggestion",
+            detection: Detection{Kind: KindRestartPattern, Name: "CRASH_LOOP", Status: "HIGH", Namespace: "other"},
+            wantOK:    false,
+            want:      Decision{},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, ok := s.Resolve(tt.detection)
+            if ok != tt.wantOK {
+                t.Errorf("Resolve() ok = %v, want %v", ok, tt.wantOK)
+            }
+            if got != tt.want {
+                t.Errorf("Resolve() = %+v, want %+v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestResolveRolloutRestart(t *testing.T) {
+    rollout := Detection{Kind: KindPrediction, Name: PredictionRolloutRestart, Status: "CRITICAL", Suggested: PredictionRolloutRestart, Namespace: "shop"}
+
+    tests := []struct {
+        name   string
+        rules  []Rule
+        wantOK bool
+        want   Decision
+    }{
+        {
+            name:   "risk-only rule does not match",
+            rules:  []Rule{{Name: "critical-restart", Match: Match{Risks: []string{"CRITICAL"}}, Remediation: "RESTART_POD"}},
+            wantOK: true,
+            want:   Decision{Policy: BuiltinPolicy, Rule: KindPrediction, Remediation: PredictionRolloutRestart},
+        },
+        {
+            name:   "other predicted actions do not match",
+            rules:  []Rule{{Name: "restarts", Match: Match{PredictedActions: []string{"RESTART_POD"}}, Remediation: "RESTART_POD"}},
+            wantOK: true,
+            want:   Decision{Policy: BuiltinPolicy, Rule: KindPrediction, Remediation: PredictionRolloutRestart},
+        },
+        {
+            name:   "explicit predicted action",
+            rules:  []Rule{{Name: "no-rollouts", Match: Match{Risks: []string{"CRITICAL"}, PredictedActions: []string{"rollout_restart"}}, Remediation: RemediationNone}},
+            wantOK: false,
+            want:   Decision{Policy: "ClusterHealingPolicy rollouts", Rule: "no-rollouts", Remediation: RemediationNone},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            s := testStore(t, policyOf("", "rollouts", HealingPolicySpec{Rules: tt.rules}))
+
+            got, ok := s.Resolve(rollout)
+            if ok != tt.wantOK {
+                t.Errorf("Resolve() ok = %v, want %v", ok, tt.wantOK)
+            }
+            if got != tt.want {
+                t.Errorf("Resolve() = %+v, want %+v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestCompileRejectsInvalidRules(t *testing.T) {
+    registry := remediation.NewRegistry()
+    registry.MustRegister(nopRemediation{}, "RESTART_POD")
+
+    tests := []struct {
+        name string
+        rule Rule
+    }{
+        {name: "empty match", rule: Rule{Remediation: "RESTART_POD"}},
+        {name: "two kinds", rule: Rule{Match: Match{Check: "DNS", RestartPattern: "OOM_KILLED"}, Remediation: "RESTART_POD"}},
+        {name: "statuses without check", rule: Rule{Match: Match{Risks: []string{"HIGH"}, CheckStatuses: []string{"WARNING"}}, Remediation: "RESTART_POD"}},
+        {name: "unknown remediation", rule: Rule{Match: Match{Risks: []string{"HIGH"}}, Remediation: "REBOOT_NODE"}},
+        {name: "negative maxActions", rule: Rule{Match: Match{Risks: []string{"HIGH"}}, Remediation: "RESTART_POD", MaxActions: -1}},
+        {name: "invalid cooldown", rule: Rule{Match: Match{Risks: []string{"HIGH"}}, Remediation: "RESTART_POD", Cooldown: "soon"}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            hp := policyOf("shop", "bad", HealingPolicySpec{Rules: []Rule{tt.rule}})
+            if _, err := compile(hp, false, registry); err == nil {
+                t.Error("compile() accepted an invalid rule")
+            }
+        })
+    }
+}
+
+func TestAllowUsage(t *testing.T) {
+    limited := Decision{Policy: "HealingPolicy shop/p", Rule: "r", Remediation: "RESTART_POD", MaxActions: 2, Cooldown: 10 * time.Minute}
+
+    tests := []struct {
+        name     string
+        decision Decision
+        ages     []time.Duration // ages of recorded actions
+        allowed  bool
+    }{
+        {name: "never acted", decision: limited, allowed: true},
+        {name: "cooling down", decision: limited, ages: []time.Duration{5 * time.Minute}, allowed: false},
+        {name: "cooldown over", decision: limited, ages: []time.Duration{15 * time.Minute}, allowed: true},
+        {name: "max actions reached", decision: limited, ages: []time.Duration{20 * time.Minute, 30 * time.Minute}, allowed: false},
+        {name: "old actions decayed", decision: limited, ages: []time.Duration{2 * time.Hour, 3 * time.Hour}, allowed: true},
+        {name: "one action decayed", decision: limited, ages: []time.Duration{90 * time.Minute, 30 * time.Minute}, allowed: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            s := testStore(t)
+            key := tt.decision.key() + "|shop/web-1"
+            now := time.Now()
+            for _, age := range tt.ages {
+                s.usage[key] = append(s.usage[key], now.Add(-age))
+            }
+
+            allowed, reason := s.Allow(tt.decision, "shop/web-1")
+            if allowed != tt.allowed {
+                t.Errorf("Allow() = %v (%s), want %v", allowed, reason, tt.allowed)
+            }
+        })
+    }
+}
+
+func TestRecord(t *testing.T) {
+    tests := []struct {
+        name     string
+        decision Decision
+        tracked  bool
+    }{
+        {name: "builtin mapping is not tracked", decision: Decision{Policy: BuiltinPolicy, Rule: KindCheck, Remediation: "CLEANUP_TMP"}, tracked: false},
+        {name: "rule with maxActions", decision: Decision{Policy: "p", Rule: "r", MaxActions: 1}, tracked: true},
+        {name: "rule with cooldown", decision: Decision{Policy: "p", Rule: "r", Cooldown: time.Minute}, tracked: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            s := testStore(t)
+            s.Record(tt.decision, "shop/web-1")
+
+            if got := len(s.usage[tt.decision.key()+"|shop/web-1"]) > 0; got != tt.tracked {
+                t.Errorf("tracked = %v, want %v", got, tt.tracked)
+            }
+        })
+    }
+}
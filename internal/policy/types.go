@@ -0,0 +1,259 @@
+package policy
+
+import (
+    "fmt"
+    "strings"
+    "time"
+
+    "k8s-healer/internal/remediation"
+
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/labels"
+    "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+    Group   = "healer.io"
+    Version = "v1alpha1"
+)
+
+var (
+    HealingPolicyResource        = schema.GroupVersionResource{Group: Group, Version: Version, Resource: "healingpolicies"}
+    ClusterHealingPolicyResource = schema.GroupVersionResource{Group: Group, Version: Version, Resource: "clusterhealingpolicies"}
+)
+
+// Detection kinds a rule can match.
+const (
+    KindPrediction     = "prediction"
+    KindCheck          = "check"
+    KindRestartPattern = "restartPattern"
+)
+
+// RemediationNone in a rule suppresses healing for the matched detections.
+const RemediationNone = "NONE"
+
+// PredictionRolloutRestart is the prediction for a workload most of whose
+// pods leak memory. Only rules that name it in predictedActions match it, so
+// a rule written for single pods does not heal one pod for the whole
+// workload.
+const PredictionRolloutRestart = "ROLLOUT_RESTART"
+
+// HealingPolicy is both the namespaced HealingPolicy and the cluster-scoped
+// ClusterHealingPolicy; they share the same spec.
+type HealingPolicy struct {
+    metav1.TypeMeta   `json:",inline"`
+    metav1.ObjectMeta `json:"metadata,omitempty"`
+    Spec              HealingPolicySpec `json:"spec"`
+}
+
+type HealingPolicySpec struct {
+    // Namespaces limits a ClusterHealingPolicy to these namespaces (all when
+    // empty). Ignored on a namespaced HealingPolicy.
+    Namespaces  []string              `json:"namespaces,omitempty"`
+    PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+    DryRun      bool                  `json:"dryRun,omitempty"`
+    Rules       []Rule                `json:"rules"`
+}
+
+type Rule struct {
+    Name        string `json:"name"`
+    Match       Match  `json:"match"`
+    Remediation string `json:"remediation"`
+    MaxActions  int    `json:"maxActions,omitempty"`
+    Cooldown    string `json:"cooldown,omitempty"`
+    DryRun      bool   `json:"dryRun,omitempty"`
+}
+
+// Match selects one kind of detection: predictor results (by risk and/or
+// predicted action), a container check by name, or a restart pattern.
+type Match struct {
+    Risks            []string `json:"risks,omitempty"`
+    PredictedActions []string `json:"predictedActions,omitempty"`
+    Check            string   `json:"check,omitempty"`
+    CheckStatuses    []string `json:"checkStatuses,omitempty"`
+    RestartPattern   string   `json:"restartPattern,omitempty"`
+}
+
+func (m Match) kind() string {
+    switch {
+    case m.Check != "":
+        return KindCheck
+    case m.RestartPattern != "":
+        return KindRestartPattern
+    case len(m.Risks) > 0 || len(m.PredictedActions) > 0:
+        return KindPrediction
+    }
+    return ""
+}
+
+// Detection is something an engine found on a pod. Suggested is the
+// remediation the detector itself proposes; it is used when no policy rule
+// matches.
+type Detection struct {
+    Kind      string
+    Name      string
+    Status    string
+    Suggested string
+    Namespace string
+    PodName   string
+    Labels    map[string]string
+}
+
+// Decision is the remediation chosen for a detection and the rule limits
+// that apply to it.
+type Decision struct {
+    Policy      string
+    Rule        string
+    Remediation string
+    DryRun      bool
+    MaxActions  int
+    Cooldown    time.Duration
+}
+
+func (d Decision) key() string {
+    return d.Policy + "#" + d.Rule
+}
+
+type compiledPolicy struct {
+    key        string
+    namespace  string
+    namespaces []string
+    selector   labels.Selector
+    dryRun     bool
+    rules      []compiledRule
+}
+
+type compiledRule struct {
+    Rule
+    kind     string
+    cooldown time.Duration
+}
+
+// compile validates a policy and prepares it for matching. Remediations must
+// be registered in the registry.
+func compile(hp *HealingPolicy, clusterScoped bool, registry *remediation.Registry) (*compiledPolicy, error) {
+    cp := &compiledPolicy{
+        dryRun:   hp.Spec.DryRun,
+        selector: labels.Everything(),
+    }
+    if clusterScoped {
+        cp.key = policyKey("", hp.Name, true)
+        cp.namespaces = hp.Spec.Namespaces
+    } else {
+        cp.key = policyKey(hp.Namespace, hp.Name, false)
+        cp.namespace = hp.Namespace
+    }
+
+    if hp.Spec.PodSelector != nil {
+        selector, err := metav1.LabelSelectorAsSelector(hp.Spec.PodSelector)
+        if err != nil {
+            return nil, fmt.Errorf("invalid podSelector: %v", err)
+        }
+        cp.selector = selector
+    }
+
+    if len(hp.Spec.Rules) == 0 {
+        return nil, fmt.Errorf("at least one rule is required")
+    }
+
+    seen := make(map[string]bool)
+    for i, rule := range hp.Spec.Rules {
+        if rule.Name == "" {
+            rule.Name = fmt.Sprintf("rule-%d", i)
+        }
+        if seen[rule.Name] {
+            return nil, fmt.Errorf("duplicate rule name %q", rule.Name)
+        }
+        seen[rule.Name] = true
+
+        compiled := compiledRule{Rule: rule, kind: rule.Match.kind()}
+        if compiled.kind == "" {
+            return nil, fmt.Errorf("rule %q: match needs risks, predictedActions, check or restartPattern", rule.Name)
+        }
+        if set := countSet(rule.Match.Check != "", rule.Match.RestartPattern != "", len(rule.Match.Risks)+len(rule.Match.PredictedActions) > 0); set > 1 {
+            return nil, fmt.Errorf("rule %q: match must select only one kind of detection", rule.Name)
+        }
+        if len(rule.Match.CheckStatuses) > 0 && compiled.kind != KindCheck {
+            return nil, fmt.Errorf("rule %q: checkStatuses requires check", rule.Name)
+        }
+
+        if rule.Remediation == "" {
+            return nil, fmt.Errorf("rule %q: remediation is required", rule.Name)
+        }
+        if rule.Remediation != RemediationNone {
+            if _, err := registry.Lookup(rule.Remediation); err != nil {
+                return nil, fmt.Errorf("rule %q: %v", rule.Name, err)
+            }
+        }
+
+        if rule.MaxActions < 0 {
+            return nil, fmt.Errorf("rule %q: maxActions must not be negative", rule.Name)
+        }
+        if rule.Cooldown != "" {
+            cooldown, err := time.ParseDuration(rule.Cooldown)
+            if err != nil || cooldown < 0 {
+                return nil, fmt.Errorf("rule %q: invalid cooldown %q", rule.Name, rule.Cooldown)
+            }
+            compiled.cooldown = cooldown
+        }
+
+        cp.rules = append(cp.rules, compiled)
+    }
+
+    return cp, nil
+}
+
+func countSet(values ...bool) int {
+    count := 0
+    for _, v := range values {
+        if v {
+            count++
+        }
+    }
+    return count
+}
+
+func (cp *compiledPolicy) selects(d Detection) bool {
+    if cp.namespace != "" && cp.namespace != d.Namespace {
+        return false
+    }
+    if len(cp.namespaces) > 0 && !containsFold(cp.namespaces, d.Namespace) {
+        return false
+    }
+    return cp.selector.Matches(labels.Set(d.Labels))
+}
+
+func (r compiledRule) matches(d Detection) bool {
+    if r.kind != d.Kind {
+        return false
+    }
+
+    switch d.Kind {
+    case KindPrediction:
+        if len(r.Match.Risks) > 0 && !containsFold(r.Match.Risks, d.Status) {
+            return false
+        }
+        if len(r.Match.PredictedActions) > 0 && !containsFold(r.Match.PredictedActions, d.Name) {
+            return false
+        }
+        // A rollout restart needs its own predictedActions match
+        return len(r.Match.PredictedActions) > 0 || !strings.EqualFold(d.Name, PredictionRolloutRestart)
+    case KindCheck:
+        if !strings.EqualFold(r.Match.Check, d.Name) {
+            return false
+        }
+        return len(r.Match.CheckStatuses) == 0 || containsFold(r.Match.CheckStatuses, d.Status)
+    case KindRestartPattern:
+        return strings.EqualFold(r.Match.RestartPattern, d.Name)
+    }
+    return false
+}
+
+func containsFold(values []string, value string) bool {
+    for _, v := range values {
+        if strings.EqualFold(v, value) {
+            return true
+        }
+    }
+    return false
+}
@@ -3,12 +3,20 @@ package predictor
 import (
     "fmt"
     "math"
+    "time"
+
     "k8s-healer/internal/collector"
+    "k8s-healer/internal/config"
+    
+    "k8s.io/apimachinery/pkg/api/resource"
 )
 
 type Predictor struct {
     podHistory  map[string][]collector.PodMetrics
     nodeHistory map[string][]collector.NodeMetrics
+    thresholds  config.ThresholdConfig
+    trends      map[string]string
+    interval    time.Duration // time between two samples of a history
 }
 
 type PredictionResult struct {
@@ -24,6 +32,9 @@ type PredictionResult struct {
     MemoryLeakRate  float64
     CPUGrowthRate   float64
     PredictionHours int
+    Profile         string
+    ContainerName   string
+    MemoryLeak      bool
 }
 
 type TrendAnalysis struct {
@@ -36,10 +47,13 @@ type TrendAnalysis struct {
     HoursToFailure float64
 }
 
-func New() *Predictor {
+func New(cfg *config.Config) *Predictor {
     return &Predictor{
         podHistory:  make(map[string][]collector.PodMetrics),
         nodeHistory: make(map[string][]collector.NodeMetrics),
+        thresholds:  cfg.Thresholds,
+        trends:      make(map[string]string),
+        interval:    cfg.CheckInterval,
     }
 }
 
@@ -53,7 +67,7 @@ func (p *Predictor) UpdateHistory(metrics []collector.PodMetrics) {
         
         p.podHistory[key] = append(p.podHistory[key], metric)
         
-        // Keep last 20 measurements (10 minutes of history at 30s intervals)
+        // Keep last 20 measurements (10 minutes of history at the default 30s interval)
         if len(p.podHistory[key]) > 20 {
             p.podHistory[key] = p.podHistory[key][1:]
         }
@@ -62,6 +76,7 @@ func (p *Predictor) UpdateHistory(metrics []collector.PodMetrics) {
 
 func (p *Predictor) PredictIssues(currentMetrics []collector.PodMetrics) []PredictionResult {
     var predictions []PredictionResult
+    trends := make(map[string]string)
     
     for _, metric := range currentMetrics {
         key := fmt.Sprintf("%s/%s", metric.Namespace, metric.Name)
@@ -69,16 +84,55 @@ func (p *Predictor) PredictIssues(currentMetrics []collector.PodMetrics) []Predi
         
         result := p.analyzePodAdvanced(metric, history)
         
+        // Only trust the trend once there is enough history to compute it
+        if len(history) >= 5 {
+            trends[key] = result.Trend
+        }
+        
         // Report issues with score > 30 OR predictions with time to failure
         if result.Score > 30 || result.TimeToFailure != "N/A" {
             predictions = append(predictions, result)
         }
     }
     
+    p.trends = trends
     return predictions
 }
 
+// PeakContainerMemory returns the highest memory usage of a container seen in
+// the pod's history.
+func (p *Predictor) PeakContainerMemory(namespace, pod, container string) (resource.Quantity, bool) {
+    var peak resource.Quantity
+    found := false
+    
+    for _, h := range p.podHistory[fmt.Sprintf("%s/%s", namespace, pod)] {
+        for _, c := range h.Containers {
+            if c.Name != container {
+                continue
+            }
+            usage, err := resource.ParseQuantity(c.MemUsage)
+            if err != nil {
+                continue
+            }
+            if !found || usage.Cmp(peak) > 0 {
+                peak = usage
+                found = true
+            }
+        }
+    }
+    
+    return peak, found
+}
+
+// Trend returns the trend computed for a pod by the last PredictIssues call.
+func (p *Predictor) Trend(namespace, name string) (string, bool) {
+    trend, ok := p.trends[fmt.Sprintf("%s/%s", namespace, name)]
+    return trend, ok
+}
+
 func (p *Predictor) analyzePodAdvanced(current collector.PodMetrics, history []collector.PodMetrics) PredictionResult {
+    profile := p.thresholds.ProfileFor(current.Namespace, current.Labels)
+    
     result := PredictionResult{
         PodName:         current.Name,
         PodNamespace:    current.Namespace,
@@ -92,29 +146,30 @@ func (p *Predictor) analyzePodAdvanced(current collector.PodMetrics, history []c
         MemoryLeakRate:  0,
         CPUGrowthRate:   0,
         PredictionHours: 0,
+        Profile:         profile.Name,
     }
     
     score := 0.0
     
     // === 1. CURRENT RESOURCE THRESHOLDS ===
-    if current.CPUPercent > 15 {
+    if current.CPUPercent > profile.CPUCritical {
         result.Issues = append(result.Issues, fmt.Sprintf("CRITICAL CPU: %.1f%%", current.CPUPercent))
         result.Risk = "CRITICAL"
         result.Action = "SCALE_UP_URGENT"
         score += 40
-    } else if current.CPUPercent > 10 {
+    } else if current.CPUPercent > profile.CPUWarning {
         result.Issues = append(result.Issues, fmt.Sprintf("HIGH CPU: %.1f%%", current.CPUPercent))
         result.Risk = "HIGH"
         result.Action = "SCALE_UP"
         score += 25
     }
     
-    if current.MemPercent > 15 {
+    if current.MemPercent > profile.MemCritical {
         result.Issues = append(result.Issues, fmt.Sprintf("CRITICAL Memory: %.1f%%", current.MemPercent))
         result.Risk = "CRITICAL"
         result.Action = "RESTART_POD_URGENT"
         score += 40
-    } else if current.MemPercent > 10 {
+    } else if current.MemPercent > profile.MemWarning {
         result.Issues = append(result.Issues, fmt.Sprintf("HIGH Memory: %.1f%%", current.MemPercent))
         result.Risk = "HIGH"
         result.Action = "RESTART_POD"
@@ -123,14 +178,14 @@ func (p *Predictor) analyzePodAdvanced(current collector.PodMetrics, history []c
     
     // === 2. TREND ANALYSIS & 24-72 HOUR PREDICTIONS ===
     if len(history) >= 5 {
-        trend := p.calculateAdvancedTrend(history, current)
+        trend := p.calculateAdvancedTrend(history, current, profile)
         result.MemoryLeakRate = trend.MemSlope
         result.CPUGrowthRate = trend.CPUSlope
         
         // CPU Growth Prediction (24-72 hour window)
-        if trend.CPUSlope > 2 { // Growing >2% per hour
+        if trend.CPUSlope > profile.CPUSlopeLimit {
             hoursToFailure := (100 - current.CPUPercent) / trend.CPUSlope
-            if hoursToFailure > 0 && hoursToFailure <= 72 {
+            if hoursToFailure > 0 && hoursToFailure <= profile.ForecastHorizonHours {
                 result.Issues = append(result.Issues, 
                     fmt.Sprintf("🔮 CPU PREDICTION: Growing %.1f%%/hour → will reach 100%% in %.1f hours", 
                         trend.CPUSlope, hoursToFailure))
@@ -150,14 +205,15 @@ func (p *Predictor) analyzePodAdvanced(current collector.PodMetrics, history []c
         }
         
         // Memory Leak Detection (most important!)
-        if trend.MemSlope > 1 { // Growing >1% per hour
+        if trend.MemSlope > profile.MemSlopeLimit {
             hoursToFailure := (100 - current.MemPercent) / trend.MemSlope
-            if hoursToFailure > 0 && hoursToFailure <= 72 {
+            if hoursToFailure > 0 && hoursToFailure <= profile.ForecastHorizonHours {
                 result.Issues = append(result.Issues, 
                     fmt.Sprintf("🚨 MEMORY LEAK DETECTED: Growing %.1f%%/hour → OOM in %.1f hours", 
                         trend.MemSlope, hoursToFailure))
                 result.TimeToFailure = fmt.Sprintf("%.1f hours (Memory leak)", hoursToFailure)
                 result.PredictionHours = int(hoursToFailure)
+                result.MemoryLeak = true
                 score += 35
                 
                 if hoursToFailure < 12 {
@@ -175,6 +231,29 @@ func (p *Predictor) analyzePodAdvanced(current collector.PodMetrics, history []c
             }
         }
         
+        // Per-container leak detection: a leaking sidecar barely moves the pod total
+        if name, slope, hoursToFailure := p.findLeakingContainer(history, current, profile); name != "" {
+            result.ContainerName = name
+            result.MemoryLeak = true
+            result.Issues = append(result.Issues, 
+                fmt.Sprintf("🚨 Container %s memory growing %.1f%%/hour → OOM in %.1f hours", 
+                    name, slope, hoursToFailure))
+            if result.TimeToFailure == "N/A" {
+                result.TimeToFailure = fmt.Sprintf("%.1f hours (Memory leak in %s)", hoursToFailure, name)
+                result.PredictionHours = int(hoursToFailure)
+                result.MemoryLeakRate = slope
+                score += 35
+                if hoursToFailure < 12 {
+                    result.Action = "RESTART_POD_URGENT"
+                    score += 25
+                } else if hoursToFailure < 24 {
+                    result.Action = "RESTART_POD_PLANNED"
+                } else {
+                    result.Action = "MONITOR_MEMORY_LEAK"
+                }
+            }
+        }
+        
         // Performance Degradation Detection
         if p.detectPerformanceDegradation(history, current) {
             result.Issues = append(result.Issues, "Performance degradation detected over time")
@@ -186,11 +265,11 @@ func (p *Predictor) analyzePodAdvanced(current collector.PodMetrics, history []c
         }
         
         // Set trend description
-        if trend.CPUSlope > 2 && trend.MemSlope > 1 {
+        if trend.CPUSlope > profile.CPUSlopeLimit && trend.MemSlope > profile.MemSlopeLimit {
             result.Trend = "CRITICAL_GROWTH"
-        } else if trend.CPUSlope > 1 || trend.MemSlope > 0.5 {
+        } else if trend.CPUSlope > profile.CPUSlopeLimit/2 || trend.MemSlope > profile.MemSlopeLimit/2 {
             result.Trend = "GROWING"
-        } else if trend.CPUSlope < -1 || trend.MemSlope < -0.5 {
+        } else if trend.CPUSlope < -profile.CPUSlopeLimit/2 || trend.MemSlope < -profile.MemSlopeLimit/2 {
             result.Trend = "DECLINING"
         } else {
             result.Trend = "STABLE"
@@ -198,7 +277,7 @@ func (p *Predictor) analyzePodAdvanced(current collector.PodMetrics, history []c
     }
     
     // === 3. RESTART PATTERN ANALYSIS ===
-    if current.Restarts >= 3 {
+    if current.Restarts >= profile.RestartWarning {
         result.Issues = append(result.Issues, fmt.Sprintf("High restart count: %d", current.Restarts))
         score += 30
         result.Action = "INVESTIGATE_RESTARTS"
@@ -234,17 +313,11 @@ func (p *Predictor) analyzePodAdvanced(current collector.PodMetrics, history []c
     return result
 }
 
-func (p *Predictor) calculateAdvancedTrend(history []collector.PodMetrics, current collector.PodMetrics) TrendAnalysis {
+func (p *Predictor) calculateAdvancedTrend(history []collector.PodMetrics, current collector.PodMetrics, profile config.ThresholdProfile) TrendAnalysis {
     if len(history) < 3 {
         return TrendAnalysis{CPUTrend: "UNKNOWN", MemTrend: "UNKNOWN"}
     }
     
-    // Calculate time span in hours (measurements every 30 seconds)
-    timeSpan := float64(len(history)) * 0.5 / 60.0 // Convert to hours
-    if timeSpan == 0 {
-        timeSpan = 0.5 // Minimum 30 minutes
-    }
-    
     // Calculate trends using linear regression for better accuracy
     cpuSlope := p.calculateSlope(history, current, "cpu")
     memSlope := p.calculateSlope(history, current, "memory")
@@ -252,8 +325,8 @@ func (p *Predictor) calculateAdvancedTrend(history []collector.PodMetrics, curre
     trend := TrendAnalysis{
         CPUSlope:      cpuSlope,
         MemSlope:      memSlope,
-        IsMemoryLeak:  memSlope > 1,
-        IsCPUGrowing:  cpuSlope > 2,
+        IsMemoryLeak:  memSlope > profile.MemSlopeLimit,
+        IsCPUGrowing:  cpuSlope > profile.CPUSlopeLimit,
     }
     
     // Classify trends
@@ -289,10 +362,6 @@ func (p *Predictor) calculateSlope(history []collector.PodMetrics, current colle
         return 0
     }
     
-    // Simple linear regression to find slope (change per hour)
-    n := float64(len(history) + 1)
-    timeSpan := n * 0.5 / 60.0 // hours
-    
     var values []float64
     for _, h := range history {
         if resourceType == "cpu" {
@@ -308,14 +377,58 @@ func (p *Predictor) calculateSlope(history []collector.PodMetrics, current colle
         values = append(values, current.MemPercent)
     }
     
+    return p.slopeOf(values)
+}
+
+// slopeOf returns the change per hour across samples taken one check
+// interval apart.
+func (p *Predictor) slopeOf(values []float64) float64 {
+    if len(values) < 2 || p.interval <= 0 {
+        return 0
+    }
+    
+    timeSpan := float64(len(values)-1) * p.interval.Hours()
+    
     // Calculate slope using first and last values (simplified)
-    if len(values) >= 2 {
-        first := values[0]
-        last := values[len(values)-1]
-        return (last - first) / timeSpan
+    first := values[0]
+    last := values[len(values)-1]
+    return (last - first) / timeSpan
+}
+
+// findLeakingContainer returns the container with the steepest memory growth
+// above the profile's slope limit that will hit its limit within the horizon.
+func (p *Predictor) findLeakingContainer(history []collector.PodMetrics, current collector.PodMetrics, profile config.ThresholdProfile) (string, float64, float64) {
+    var leakName string
+    var leakSlope, leakHours float64
+    
+    for _, container := range current.Containers {
+        var values []float64
+        for _, h := range history {
+            for _, hc := range h.Containers {
+                if hc.Name == container.Name {
+                    values = append(values, hc.MemPercent)
+                    break
+                }
+            }
+        }
+        if len(values) < 5 {
+            continue
+        }
+        values = append(values, container.MemPercent)
+        
+        slope := p.slopeOf(values)
+        if slope <= profile.MemSlopeLimit || slope <= leakSlope {
+            continue
+        }
+        hoursToFailure := (100 - container.MemPercent) / slope
+        if hoursToFailure > 0 && hoursToFailure <= profile.ForecastHorizonHours {
+            leakName = container.Name
+            leakSlope = slope
+            leakHours = hoursToFailure
+        }
     }
     
-    return 0
+    return leakName, leakSlope, leakHours
 }
 
 func (p *Predictor) detectPerformanceDegradation(history []collector.PodMetrics, current collector.PodMetrics) bool {
@@ -361,6 +474,14 @@ func (p *Predictor) PrintPredictions(predictions []PredictionResult) {
         fmt.Printf("%s Pod: %s/%s - Risk: %s (Score: %.1f, %d%% confidence)\n", 
             riskIcon, pred.PodNamespace, pred.PodName, pred.Risk, pred.Score, pred.Confidence)
         
+        if pred.ContainerName != "" {
+            fmt.Printf("  📦 Container: %s\n", pred.ContainerName)
+        }
+        
+        if pred.Profile != "default" {
+            fmt.Printf("  🎚️  Threshold profile: %s\n", pred.Profile)
+        }
+        
         if pred.TimeToFailure != "N/A" {
             fmt.Printf("  ⏰ PREDICTION: Failure in %s\n", pred.TimeToFailure)
         }
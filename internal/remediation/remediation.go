@@ -0,0 +1,296 @@
+package remediation
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+    "sync"
+    "time"
+)
+
+var ErrUnknownAction = errors.New("unknown healing action")
+
+const (
+    StatusCompleted    = "COMPLETED"
+    StatusPartial      = "PARTIAL"
+    StatusFailed       = "FAILED"
+    StatusDryRun       = "DRY_RUN"
+    StatusSkipped      = "SKIPPED"
+    StatusBlockedByPDB = "BLOCKED_BY_PDB"
+    StatusRateLimited  = "RATE_LIMITED"
+)
+
+const (
+    StepSucceeded = "SUCCEEDED"
+    StepFailed    = "FAILED"
+    StepSkipped   = "SKIPPED"
+)
+
+// Request asks for a healing action on a pod or one of its containers.
+// Origin carries the finding that triggered it, e.g. a
+// predictor.PredictionResult or a diagnostics.ContainerCheckResult.
+// Started, if set, is called right before Execute. Done, if set, is called
+// with the final outcome of work Execute left pending, e.g. a pod eviction a
+// PodDisruptionBudget deferred.
+type Request struct {
+    Action        string
+    Namespace     string
+    PodName       string
+    ContainerName string
+    Risk          string
+    Reason        string
+    DryRun        bool
+    Requested     time.Time
+    Origin        interface{}
+    Started       func()
+    Done          func(err error)
+}
+
+func (r Request) Key() string {
+    if r.ContainerName != "" {
+        return fmt.Sprintf("%s/%s/%s", r.Namespace, r.PodName, r.ContainerName)
+    }
+    return fmt.Sprintf("%s/%s", r.Namespace, r.PodName)
+}
+
+// Plan describes what Execute would do. Read-only plans are executed even in
+// dry-run mode.
+type Plan struct {
+    Description string
+    Steps       []string
+    ReadOnly    bool
+}
+
+// Result is the outcome of Execute. Action is the action actually taken,
+// which may differ from the requested one (e.g. a network fix that ended in a
+// pod restart).
+type Result struct {
+    Action  string
+    Status  string
+    Message string
+    Target  string
+    Steps   []StepResult
+}
+
+// StepResult is the outcome of one step of a remediation, e.g. one command
+// run in the container.
+type StepResult struct {
+    Name   string `json:"name"`
+    Status string `json:"status"`
+    Output string `json:"output,omitempty"`
+    Error  string `json:"error,omitempty"`
+}
+
+// ResultFromSteps is COMPLETED when every step succeeded, FAILED when none
+// did and PARTIAL otherwise.
+func ResultFromSteps(steps []StepResult) Result {
+    failed := 0
+    for _, step := range steps {
+        if step.Status == StepFailed {
+            failed++
+        }
+    }
+
+    status := StatusCompleted
+    if failed > 0 && failed == len(steps) {
+        status = StatusFailed
+    } else if failed > 0 {
+        status = StatusPartial
+    }
+
+    return Result{
+        Status:  status,
+        Message: fmt.Sprintf("%d/%d steps succeeded", len(steps)-failed, len(steps)),
+        Steps:   steps,
+    }
+}
+
+// Remediation is a healing action that can be registered under one or more
+// action names.
+type Remediation interface {
+    Name() string
+    Applies(req Request) bool
+    Plan(ctx context.Context, req Request) (Plan, error)
+    Execute(ctx context.Context, req Request) (Result, error)
+    Verify(ctx context.Context, req Request) (bool, error)
+}
+
+// Limiter decides whether a remediation that changes the cluster may run
+// now. Allow reserves the remediation's slot when it returns true; Release
+// gives it back when the remediation did not run after all.
+type Limiter interface {
+    Allow(ctx context.Context, remediation string, req Request) (bool, string)
+    Release(ctx context.Context, remediation string, req Request)
+}
+
+// Registry maps action names to remediations. The ActionEngine and the
+// AutoHealer share one registry so every action goes through Dispatch.
+type Registry struct {
+    mu           sync.RWMutex
+    remediations map[string]Remediation
+    actions      map[string]Remediation
+    limiter      Limiter
+}
+
+func NewRegistry() *Registry {
+    return &Registry{
+        remediations: make(map[string]Remediation),
+        actions:      make(map[string]Remediation),
+    }
+}
+
+// Register adds a remediation and binds it to the given action names. The
+// remediation's own name is always bound as well.
+func (r *Registry) Register(rem Remediation, actions ...string) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if _, exists := r.remediations[rem.Name()]; exists {
+        return fmt.Errorf("remediation %q already registered", rem.Name())
+    }
+
+    names := append([]string{rem.Name()}, actions...)
+    for _, action := range names {
+        if existing, exists := r.actions[action]; exists {
+            return fmt.Errorf("action %q already handled by remediation %q", action, existing.Name())
+        }
+    }
+
+    r.remediations[rem.Name()] = rem
+    for _, action := range names {
+        r.actions[action] = rem
+    }
+    return nil
+}
+
+// MustRegister is Register for built-in remediations, where a conflict is a
+// programming error.
+func (r *Registry) MustRegister(rem Remediation, actions ...string) {
+    if err := r.Register(rem, actions...); err != nil {
+        panic(err)
+    }
+}
+
+func (r *Registry) Lookup(action string) (Remediation, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    rem, ok := r.actions[action]
+    if !ok {
+        return nil, fmt.Errorf("%w %q", ErrUnknownAction, action)
+    }
+    return rem, nil
+}
+
+// SetLimiter installs the limiter consulted before every non read-only
+// Execute.
+func (r *Registry) SetLimiter(limiter Limiter) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    r.limiter = limiter
+}
+
+// Actions returns every registered action name.
+func (r *Registry) Actions() []string {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    actions := make([]string, 0, len(r.actions))
+    for action := range r.actions {
+        actions = append(actions, action)
+    }
+    sort.Strings(actions)
+    return actions
+}
+
+// Verify asks the remediation registered for req.Action whether the action
+// requested by req has taken effect.
+func (r *Registry) Verify(ctx context.Context, req Request) (bool, error) {
+    rem, err := r.Lookup(req.Action)
+    if err != nil {
+        return false, err
+    }
+    return rem.Verify(ctx, req)
+}
+
+// Dispatch runs the remediation registered for req.Action: Applies, Plan and,
+// unless this is a dry run of a plan that changes the cluster or the limiter
+// refuses it, Execute.
+func (r *Registry) Dispatch(ctx context.Context, req Request) (Plan, Result, error) {
+    rem, err := r.Lookup(req.Action)
+    if err != nil {
+        return Plan{}, Result{Action: req.Action, Status: StatusFailed, Message: err.Error()}, err
+    }
+
+    if !rem.Applies(req) {
+        return Plan{}, Result{
+            Action:  req.Action,
+            Status:  StatusSkipped,
+            Message: fmt.Sprintf("%s does not apply to %s", rem.Name(), req.Key()),
+        }, nil
+    }
+
+    plan, err := rem.Plan(ctx, req)
+    if err != nil {
+        return plan, Result{Action: req.Action, Status: StatusFailed, Message: err.Error()}, err
+    }
+
+    if req.DryRun && !plan.ReadOnly {
+        return plan, Result{Action: req.Action, Status: StatusDryRun, Message: plan.Description}, nil
+    }
+
+    reserved := false
+    if !plan.ReadOnly {
+        if allowed, reason := r.Reserve(ctx, rem.Name(), req); !allowed {
+            return plan, Result{Action: req.Action, Status: StatusRateLimited, Message: reason}, nil
+        }
+        reserved = true
+    }
+
+    if req.Started != nil {
+        req.Started()
+    }
+    result, err := rem.Execute(ctx, req)
+    if result.Action == "" {
+        result.Action = req.Action
+    }
+    if err != nil && result.Status == "" {
+        result.Status = StatusFailed
+        result.Message = err.Error()
+    }
+    if result.Status == "" {
+        result.Status = StatusCompleted
+    }
+    if reserved && result.Status == StatusSkipped {
+        r.Release(ctx, rem.Name(), req)
+    }
+    return plan, result, err
+}
+
+// Reserve asks the limiter for a slot for a change made outside Dispatch,
+// e.g. a rollback, so it counts against the same budgets. Without a limiter
+// everything is allowed.
+func (r *Registry) Reserve(ctx context.Context, remediation string, req Request) (bool, string) {
+    r.mu.RLock()
+    limiter := r.limiter
+    r.mu.RUnlock()
+
+    if limiter == nil {
+        return true, ""
+    }
+    return limiter.Allow(ctx, remediation, req)
+}
+
+// Release gives back a slot taken with Reserve for a change that was not
+// made.
+func (r *Registry) Release(ctx context.Context, remediation string, req Request) {
+    r.mu.RLock()
+    limiter := r.limiter
+    r.mu.RUnlock()
+
+    if limiter != nil {
+        limiter.Release(ctx, remediation, req)
+    }
+}
@@ -0,0 +1,187 @@
+package watcher
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "time"
+
+    "k8s-healer/internal/cache"
+    "k8s-healer/internal/diagnostics"
+
+    corev1 "k8s.io/api/core/v1"
+    "k8s.io/apimachinery/pkg/api/errors"
+    toolscache "k8s.io/client-go/tools/cache"
+    "k8s.io/client-go/util/workqueue"
+)
+
+// Watcher reacts to pod status changes between periodic sweeps: pods whose
+// containers crash, get OOMKilled, turn NotReady or restart are queued and
+// diagnosed within seconds.
+type Watcher struct {
+    cache      *cache.Cache
+    diagEngine *diagnostics.DiagnosticsEngine
+    autoHealer *diagnostics.AutoHealer
+    queue      workqueue.RateLimitingInterface
+    workers    int
+}
+
+func New(kubeCache *cache.Cache, diagEngine *diagnostics.DiagnosticsEngine, autoHealer *diagnostics.AutoHealer, workers int) *Watcher {
+    // A pod that keeps triggering backs off from 5s up to 5m; a pod that
+    // comes back clean is forgotten and reacts quickly again.
+    rateLimiter := workqueue.NewItemExponentialFailureRateLimiter(5*time.Second, 5*time.Minute)
+
+    return &Watcher{
+        cache:      kubeCache,
+        diagEngine: diagEngine,
+        autoHealer: autoHealer,
+        queue:      workqueue.NewNamedRateLimitingQueue(rateLimiter, "pod-triggers"),
+        workers:    workers,
+    }
+}
+
+// Register hooks the watcher into the pod informer. Call before the cache starts.
+func (w *Watcher) Register() error {
+    return w.cache.AddPodHandler(toolscache.ResourceEventHandlerFuncs{
+        UpdateFunc: w.onPodUpdate,
+    })
+}
+
+func (w *Watcher) Run(ctx context.Context) {
+    for i := 0; i < w.workers; i++ {
+        go w.worker(ctx)
+    }
+
+    go func() {
+        <-ctx.Done()
+        w.queue.ShutDown()
+    }()
+}
+
+func (w *Watcher) onPodUpdate(oldObj, newObj interface{}) {
+    oldPod, ok := oldObj.(*corev1.Pod)
+    if !ok {
+        return
+    }
+    newPod, ok := newObj.(*corev1.Pod)
+    if !ok {
+        return
+    }
+
+    // Skip system pods
+    if strings.Contains(newPod.Namespace, "kube-") ||
+       strings.Contains(newPod.Namespace, "healer-") {
+        return
+    }
+
+    reason := triggerReason(oldPod, newPod)
+    if reason == "" {
+        return
+    }
+
+    key, err := toolscache.MetaNamespaceKeyFunc(newPod)
+    if err != nil {
+        return
+    }
+
+    fmt.Printf("⚡ Trigger: %s (%s) - queued for diagnostics\n", key, reason)
+    w.queue.AddRateLimited(key)
+}
+
+// triggerReason returns why a pod update needs immediate attention, or "".
+func triggerReason(oldPod, newPod *corev1.Pod) string {
+    oldStatuses := make(map[string]corev1.ContainerStatus)
+    for _, cs := range oldPod.Status.ContainerStatuses {
+        oldStatuses[cs.Name] = cs
+    }
+
+    for _, cs := range newPod.Status.ContainerStatuses {
+        old, existed := oldStatuses[cs.Name]
+
+        if cs.State.Waiting != nil && cs.State.Waiting.Reason == "CrashLoopBackOff" &&
+           (!existed || old.State.Waiting == nil || old.State.Waiting.Reason != "CrashLoopBackOff") {
+            return fmt.Sprintf("container %s in CrashLoopBackOff", cs.Name)
+        }
+
+        if !existed {
+            continue
+        }
+
+        if cs.RestartCount > old.RestartCount {
+            if cs.LastTerminationState.Terminated != nil && cs.LastTerminationState.Terminated.Reason == "OOMKilled" {
+                return fmt.Sprintf("container %s OOMKilled", cs.Name)
+            }
+            return fmt.Sprintf("container %s restarted (%d)", cs.Name, cs.RestartCount)
+        }
+
+        if old.Ready && !cs.Ready {
+            return fmt.Sprintf("container %s NotReady", cs.Name)
+        }
+    }
+
+    return ""
+}
+
+func (w *Watcher) worker(ctx context.Context) {
+    for w.processNext(ctx) {
+    }
+}
+
+func (w *Watcher) processNext(ctx context.Context) bool {
+    item, shutdown := w.queue.Get()
+    if shutdown {
+        return false
+    }
+    defer w.queue.Done(item)
+
+    key := item.(string)
+    namespace, name, err := toolscache.SplitMetaNamespaceKey(key)
+    if err != nil {
+        w.queue.Forget(item)
+        return true
+    }
+
+    pod, err := w.cache.Pods.Pods(namespace).Get(name)
+    if err != nil {
+        if !errors.IsNotFound(err) {
+            fmt.Printf("Trigger lookup error for %s: %v\n", key, err)
+        }
+        w.queue.Forget(item)
+        return true
+    }
+
+    if !w.diagnose(ctx, pod) {
+        // Healthy again - reset the backoff for this pod
+        w.queue.Forget(item)
+    }
+
+    return true
+}
+
+// diagnose runs restart analysis and container checks for one pod and heals
+// what it can. It reports whether any issue was found.
+func (w *Watcher) diagnose(ctx context.Context, pod *corev1.Pod) bool {
+    found := false
+
+    if pattern, hasRestarts := w.diagEngine.AnalyzePodRestarts(pod); hasRestarts {
+        w.diagEngine.PrintRestartAnalysis([]diagnostics.RestartPattern{pattern})
+        found = true
+    }
+
+    if pod.Status.Phase != corev1.PodRunning {
+        return found
+    }
+
+    containerChecks := w.diagEngine.RunPodChecks(ctx, pod)
+    if len(containerChecks) > 0 {
+        w.diagEngine.PrintContainerChecks(containerChecks)
+        found = true
+
+        healingActions := w.autoHealer.HealContainerIssues(ctx, containerChecks)
+        if len(healingActions) > 0 {
+            w.autoHealer.PrintHealingActions(healingActions)
+        }
+    }
+
+    return found
+}
@@ -41,7 +41,7 @@ spec:
           valueFrom:
             fieldRef:
               fieldPath: metadata.namespace
-        - name: DRY_RUN
+        - name: HEALER_DRY_RUN
           value: "false"
 YAML
 
@@ -5,9 +5,21 @@ docker build -t k8s-healer:latest .
 
 echo "Deploying to Kubernetes..."
 kubectl apply -f deployments/namespace.yaml
+kubectl apply -f deployments/crds/
 kubectl apply -f deployments/rbac.yaml
 kubectl apply -f deployments/deployment.yaml
 
+# Optional node-level diagnostic agent
+if [ "${DEPLOY_AGENT:-false}" = "true" ]; then
+    # The healer refuses agent reports without a shared token
+    if ! kubectl get secret k8s-healer-agent -n healer-system >/dev/null 2>&1; then
+        kubectl create secret generic k8s-healer-agent -n healer-system \
+            --from-literal=token="$(openssl rand -hex 32)"
+    fi
+    kubectl set env deployment/k8s-healer -n healer-system --from=secret/k8s-healer-agent --prefix=HEALER_AGENT_
+    kubectl apply -f deployments/agent-daemonset.yaml
+fi
+
 echo "Waiting for deployment..."
 kubectl wait --for=condition=available --timeout=60s deployment/k8s-healer -n healer-system
 